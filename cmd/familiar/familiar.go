@@ -2,26 +2,41 @@ package main
 
 import (
 	"fmt"
+	"github.com/colececil/familiar.sh/internal/commands"
 	"os"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Error: No command specified.")
+	commandName, args, err := commands.FindCommandName(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s.\n", err)
 		os.Exit(1)
 	}
 
-	commandName := os.Args[1]
-
-	commandRegistry := InitializeCommandRegistry()
+	globalOptions := commands.NewGlobalOptions()
+	commandRegistry := InitializeCommandRegistry(globalOptions)
 	command, err := commandRegistry.GetCommand(commandName)
 	if err != nil {
-		fmt.Printf("%s\n", err)
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	var subcommands *commands.Subcommand
+	if commandWithSubcommands, hasSubcommands := command.(commands.CommandWithSubcommands); hasSubcommands {
+		subcommands = commandWithSubcommands.Subcommands()
+	}
+	args, flagValues, err := commands.ParseFlags(args, append(append([]commands.Flag{}, commands.GlobalFlags...),
+		command.Flags()...), subcommands)
+	if err == nil {
+		err = globalOptions.Apply(flagValues)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
 
-	if err := command.Execute(os.Args[2:]); err != nil {
-		fmt.Printf("%s\n", err)
+	if err := command.Execute(args, flagValues); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
 }
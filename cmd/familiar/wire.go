@@ -17,11 +17,24 @@ var providers = wire.NewSet(
 	getFamiliarVersion,
 	commands.NewCommandRegistry,
 	commands.NewVersionCommand,
+	commands.NewInitCommand,
 	commands.NewAttuneCommand,
 	commands.NewConfigCommand,
 	commands.NewPackageCommand,
+	commands.NewMachineCommand,
 	commands.NewHelpCommand,
+	commands.NewOutput,
+	commands.NewOutputWriters,
+	commands.NewMessageWriterFunc,
+	commands.NewIsVerboseFunc,
+	commands.NewConfigOverrideFunc,
+	commands.NewInputReader,
 	config.NewConfigService,
+	config.NewConfigStoreRegistry,
+	config.NewConfigValidator,
+	config.NewFileConfigStore,
+	config.NewGitConfigStore,
+	config.NewHttpConfigStore,
 	packagemanagers.NewPackageManagerRegistry,
 	packagemanagers.NewScoopPackageManager,
 	system.NewIsWindowsFunc,
@@ -30,8 +43,9 @@ var providers = wire.NewSet(
 	system.NewShellCommandService,
 )
 
-// InitializeCommandRegistry tells Wire how to create an injector for CommandRegistry.
-func InitializeCommandRegistry() commands.CommandRegistry {
+// InitializeCommandRegistry tells Wire how to create an injector for CommandRegistry. The commands and services read
+// the values of the global flags from the given GlobalOptions.
+func InitializeCommandRegistry(globalOptions *commands.GlobalOptions) commands.CommandRegistry {
 	wire.Build(providers)
 	return commands.CommandRegistry{}
 }
@@ -0,0 +1,12 @@
+// Package collections provides helpers for working with slices, for use across the other packages.
+package collections
+
+// Contains returns whether the given slice of strings contains the given string.
+func Contains(values []string, value string) bool {
+	for _, currentValue := range values {
+		if currentValue == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package collections_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCollections(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Collections Suite")
+}
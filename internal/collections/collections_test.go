@@ -0,0 +1,15 @@
+package collections_test
+
+import (
+	. "github.com/colececil/familiar.sh/internal/collections"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Contains", func() {
+	It("should return whether the slice contains the value", func() {
+		Expect(Contains([]string{"windows", "macos"}, "macos")).To(BeTrue())
+		Expect(Contains([]string{"windows", "macos"}, "linux")).To(BeFalse())
+		Expect(Contains(nil, "linux")).To(BeFalse())
+	})
+})
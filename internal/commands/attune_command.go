@@ -4,19 +4,173 @@ import (
 	"fmt"
 	"github.com/colececil/familiar.sh/internal/config"
 	"github.com/colececil/familiar.sh/internal/packagemanagers"
+	"strings"
 )
 
 type AttuneCommand struct {
 	configService          *config.ConfigService
+	configValidator        *config.ConfigValidator
 	packageManagerRegistry packagemanagers.PackageManagerRegistry
+	output                 *Output
+}
+
+// AttuneResult is the result of the "attune" command. It describes the changes that were made to the current machine,
+// or with "--plan", the changes that would be made.
+type AttuneResult struct {
+	// Planned is whether the changes were only planned, because "--plan" was given.
+	Planned bool `yaml:"planned"`
+	// Exact is whether packages are brought to exactly their configured versions, because "--exact" was given.
+	Exact       bool                `yaml:"exact"`
+	Diagnostics []config.Diagnostic `yaml:"diagnostics"`
+	// PackageManagers are the configured package managers that are supported on the current machine.
+	PackageManagers []AttunePackageManager `yaml:"packageManagers"`
+	// Unconverged describes the packages that couldn't be brought to their configured versions with "--exact".
+	Unconverged []string `yaml:"unconverged"`
+}
+
+// AttunePackageManager describes the changes made with a package manager by the "attune" command.
+type AttunePackageManager struct {
+	Name string `yaml:"name"`
+	// Install is whether the package manager itself is installed, because it wasn't installed yet.
+	Install bool `yaml:"install"`
+	// Update is whether the package manager itself is updated, because it was already installed.
+	Update bool `yaml:"update"`
+	// InstalledPackages are the packages that were installed with the package manager before any changes were made.
+	InstalledPackages []AttunePackage `yaml:"installedPackages"`
+	// ConfiguredPackages are the packages that are configured to be installed with the package manager.
+	ConfiguredPackages []AttunePackage `yaml:"configuredPackages"`
+	Actions            []PackageAction `yaml:"actions"`
+}
+
+// AttunePackage is a package listed by the "attune" command, along with its installed or configured version.
+type AttunePackage struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+}
+
+// PackageActionType is the kind of change the "attune" command makes to a package.
+type PackageActionType string
+
+const (
+	InstallPackageAction   PackageActionType = "install"
+	UpdatePackageAction    PackageActionType = "update"
+	DowngradePackageAction PackageActionType = "downgrade"
+	UninstallPackageAction PackageActionType = "uninstall"
+	SkipPackageAction      PackageActionType = "skip"
+)
+
+// PackageAction is a change to a package made by the "attune" command. Packages that are already installed at their
+// configured versions don't have an action.
+type PackageAction struct {
+	Package string            `yaml:"package"`
+	Action  PackageActionType `yaml:"action"`
+	// ConfiguredVersion is the version of the package in the configuration, if it is configured.
+	ConfiguredVersion string `yaml:"configuredVersion,omitempty"`
+	// InstalledVersion is the version of the package that was installed before the change, if it was installed.
+	InstalledVersion string `yaml:"installedVersion,omitempty"`
+	// ResultVersion is the version of the package that is installed after the change. It is empty when the changes
+	// were only planned, and for packages that were uninstalled or skipped.
+	ResultVersion string `yaml:"resultVersion,omitempty"`
+	// Reason is why a package is skipped: "pinned" if it is pinned, or "untagged" if it isn't tagged for the current
+	// machine.
+	Reason string `yaml:"reason,omitempty"`
+}
+
+// Text returns the result as human-readable text. Since the changes are reported as they are made, only the packages
+// that were installed and configured for each package manager, the planned changes, and any unconverged packages are
+// included.
+func (attuneResult AttuneResult) Text() string {
+	var textBuilder strings.Builder
+	for _, packageManager := range attuneResult.PackageManagers {
+		if len(packageManager.InstalledPackages) > 0 {
+			textBuilder.WriteString(fmt.Sprintf("Packages installed with %s before attuning:\n", packageManager.Name))
+			for _, installedPackage := range packageManager.InstalledPackages {
+				textBuilder.WriteString(fmt.Sprintf("- %s, version %s\n", installedPackage.Name,
+					installedPackage.Version))
+			}
+		} else {
+			textBuilder.WriteString(fmt.Sprintf("No packages were installed with %s before attuning.\n",
+				packageManager.Name))
+		}
+
+		if len(packageManager.ConfiguredPackages) > 0 {
+			textBuilder.WriteString(fmt.Sprintf("Packages configured to be installed with %s:\n",
+				packageManager.Name))
+			for _, configuredPackage := range packageManager.ConfiguredPackages {
+				textBuilder.WriteString(fmt.Sprintf("- %s, version %s\n", configuredPackage.Name,
+					configuredPackage.Version))
+			}
+		}
+
+		if attuneResult.Planned {
+			if packageManager.Install {
+				textBuilder.WriteString(fmt.Sprintf("Package manager \"%s\" would be installed.\n",
+					packageManager.Name))
+			} else if packageManager.Update {
+				textBuilder.WriteString(fmt.Sprintf("Package manager \"%s\" would be updated.\n",
+					packageManager.Name))
+			}
+
+			if len(packageManager.Actions) == 0 {
+				textBuilder.WriteString(fmt.Sprintf("No changes would be made to packages for package manager "+
+					"\"%s\".\n", packageManager.Name))
+				continue
+			}
+
+			textBuilder.WriteString(fmt.Sprintf("Changes that would be made to packages for package manager "+
+				"\"%s\":\n", packageManager.Name))
+			for _, action := range packageManager.Actions {
+				textBuilder.WriteString(fmt.Sprintf("- %s\n", action.describe()))
+			}
+		}
+	}
+
+	if len(attuneResult.Unconverged) > 0 {
+		textBuilder.WriteString("The following packages could not be brought to their configured versions:\n")
+		for _, unconvergedPackage := range attuneResult.Unconverged {
+			textBuilder.WriteString(fmt.Sprintf("- %s\n", unconvergedPackage))
+		}
+	}
+	return textBuilder.String()
+}
+
+// describe returns a description of the planned action.
+func (packageAction PackageAction) describe() string {
+	switch packageAction.Action {
+	case InstallPackageAction:
+		return fmt.Sprintf("install %s at version %s", packageAction.Package, packageAction.ConfiguredVersion)
+	case UpdatePackageAction, DowngradePackageAction:
+		return fmt.Sprintf("%s %s from version %s to version %s", packageAction.Action, packageAction.Package,
+			packageAction.InstalledVersion, packageAction.ConfiguredVersion)
+	case UninstallPackageAction:
+		return fmt.Sprintf("uninstall %s (version %s)", packageAction.Package, packageAction.InstalledVersion)
+	default:
+		return fmt.Sprintf("skip %s, because %s", packageAction.Package, packageAction.skipReasonText())
+	}
+}
+
+// requestsConfiguredVersion returns whether the action installs or updates the package to its configured version, which
+// the package manager needs the VersionedInstall capability for.
+func (packageAction PackageAction) requestsConfiguredVersion() bool {
+	return packageAction.Action == InstallPackageAction || packageAction.Action == UpdatePackageAction
+}
+
+// skipReasonText returns the reason a package is skipped, as human-readable text.
+func (packageAction PackageAction) skipReasonText() string {
+	if packageAction.Reason == "pinned" {
+		return "it is pinned"
+	}
+	return "it is not tagged for this machine"
 }
 
 // NewAttuneCommand creates a new instance of AttuneCommand.
-func NewAttuneCommand(configService *config.ConfigService,
-	packageManagerRegistry packagemanagers.PackageManagerRegistry) *AttuneCommand {
+func NewAttuneCommand(configService *config.ConfigService, configValidator *config.ConfigValidator,
+	packageManagerRegistry packagemanagers.PackageManagerRegistry, output *Output) *AttuneCommand {
 	return &AttuneCommand{
 		configService:          configService,
+		configValidator:        configValidator,
 		packageManagerRegistry: packageManagerRegistry,
+		output:                 output,
 	}
 }
 
@@ -34,25 +188,119 @@ func (attuneCommand *AttuneCommand) Description() string {
 func (attuneCommand *AttuneCommand) Documentation() string {
 	return "Set up the current machine so it matches the shared configuration. To do this, Familiar.sh will perform " +
 		"the following operations as needed: installing packages, uninstalling packages, copying files, and running " +
-		"scripts."
+		"scripts.\n\n" +
+		"Before any changes are made, the configuration is validated in the same way as " +
+		"\"familiar config validate\", and nothing is done if there are any errors.\n\n" +
+		"Packages, files, and scripts that have tags are only applied if the current machine has at least one of " +
+		"their tags. Run \"familiar help machine\" for more information about tags.\n\n" +
+		"By default, packages that are installed at a newer version than the configured one are left as they are, " +
+		"and the configuration is updated if a package manager installs a newer version than the configured one. If " +
+		"the \"--exact\" flag is given, packages are instead downgraded or reinstalled as needed so that every " +
+		"package is installed at exactly its configured version. Any packages whose package manager is unable to do " +
+		"this are reported at the end.\n\n" +
+		"If the \"--plan\" flag is given, the changes that would be made are printed, and nothing is changed, not " +
+		"even updating the package managers themselves.\n\n" +
+		"With \"--output json\" or \"--output yaml\", the result is an object with the following properties: " +
+		"\"planned\", \"exact\", \"diagnostics\", \"packageManagers\" (each with a \"name\", whether it needs to be " +
+		"installed as \"install\" or updated as \"update\", the \"installedPackages\" before any changes and the " +
+		"\"configuredPackages\", each with a \"name\" and \"version\", and a list of \"actions\"), and " +
+		"\"unconverged\". Each action has a \"package\", an " +
+		"\"action\" (\"install\", \"update\", \"downgrade\", \"uninstall\", or \"skip\"), and where they apply, the " +
+		"\"configuredVersion\", the \"installedVersion\" before the change, the \"resultVersion\" after it, and the " +
+		"\"reason\" a package is skipped (\"pinned\" or \"untagged\").\n\n" +
+		"Usage:\n  familiar attune\n  familiar attune --exact\n  familiar attune --plan"
+}
+
+// Flags returns the flags the command accepts, in addition to the global flags.
+func (attuneCommand *AttuneCommand) Flags() []Flag {
+	return []Flag{
+		{
+			Name: "exact",
+			Description: "Downgrade or reinstall packages as needed so they are installed at exactly their " +
+				"configured versions.",
+		},
+		{
+			Name:        "plan",
+			Description: "Print the changes that would be made, without making them.",
+		},
+	}
 }
 
 // Execute runs the command with the given arguments.
 //
 // It takes the following parameters:
-//   - args: A slice containing the arguments to pass in to the command.
+//   - args: A slice containing the positional arguments to pass in to the command, with any flags removed.
+//   - flags: The values of the flags given on the command line, including the global flags.
 //
 // If there is an error executing the command, Execute will return an error that can be displayed to the user.
-func (attuneCommand *AttuneCommand) Execute(args []string) error {
+func (attuneCommand *AttuneCommand) Execute(args []string, flags FlagValues) error {
 	if len(args) > 0 {
-		return fmt.Errorf("the \"attune\" command does not take any arguments")
+		return fmt.Errorf("unknown argument %q for the \"attune\" command", args[0])
+	}
+
+	result := AttuneResult{
+		Planned:         flags.IsSet("plan"),
+		Exact:           flags.IsSet("exact"),
+		PackageManagers: []AttunePackageManager{},
+		Unconverged:     []string{},
+	}
+
+	diagnostics, err := attuneCommand.configValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	result.Diagnostics = diagnostics
+	if result.Diagnostics == nil {
+		result.Diagnostics = []config.Diagnostic{}
+	}
+	for _, diagnostic := range diagnostics {
+		attuneCommand.output.Println(diagnostic.String())
+	}
+
+	if config.HasErrors(diagnostics) {
+		if attuneCommand.output.Format().IsStructured() {
+			if err = attuneCommand.output.Result(result); err != nil {
+				return err
+			}
+		}
+		return fmt.Errorf("the configuration is not valid, so no changes were made")
 	}
 
+	transaction := attuneCommand.configService.BeginTransaction()
+	err = attuneCommand.attune(transaction, &result)
+	if err = transaction.Finish(err); err != nil {
+		return err
+	}
+
+	if err = attuneCommand.output.Result(result); err != nil {
+		return err
+	}
+
+	if len(result.Unconverged) > 0 {
+		return fmt.Errorf("%d package(s) are not installed at their configured versions", len(result.Unconverged))
+	}
+	return nil
+}
+
+// attune sets up the current machine so it matches the effective configuration, recording the changes in the given
+// result. If the result is only planned, the changes are recorded without being made. Any package versions that need to
+// be recorded in the shared config file are changed using the given transaction.
+//
+// It takes the following parameters:
+//   - transaction: The transaction to make changes to the shared config file with.
+//   - result: The result to record the changes in. Its Planned and Exact fields must already be set.
+func (attuneCommand *AttuneCommand) attune(transaction *config.ConfigTransaction, result *AttuneResult) error {
 	configContents, err := attuneCommand.configService.GetConfig()
 	if err != nil {
 		return err
 	}
 
+	machineTags, err := attuneCommand.configService.GetMachineTags()
+	if err != nil {
+		return err
+	}
+
 	for _, packageManagerInConfig := range configContents.PackageManagers {
 		packageManager, err := attuneCommand.packageManagerRegistry.GetPackageManager(packageManagerInConfig.Name)
 		if err != nil {
@@ -68,101 +316,269 @@ func (attuneCommand *AttuneCommand) Execute(args []string) error {
 			return err
 		}
 
-		if !installed {
+		if !installed && !result.Planned {
 			if err := packageManager.Install(); err != nil {
 				return err
 			}
-		} else {
-			fmt.Printf("Package manager \"%s\" is already installed.\n", packageManager.Name())
-			if err := packageManager.Update(); err != nil {
-				return err
+		} else if installed {
+			attuneCommand.output.Printf("Package manager \"%s\" is already installed.\n", packageManager.Name())
+			if !result.Planned {
+				if err := packageManager.Update(); err != nil {
+					return err
+				}
 			}
 		}
 
-		installedPackages, err := packageManager.InstalledPackages()
-		if err != nil {
-			return err
-		}
-
-		if len(installedPackages) > 0 {
-			fmt.Printf("Packages currently installed with %s:\n", packageManager.Name())
-			for _, installedPackage := range installedPackages {
-				fmt.Printf("- %s, version %s\n", installedPackage.Name, installedPackage.InstalledVersion)
+		var installedPackages []*packagemanagers.Package
+		if installed || !result.Planned {
+			if installedPackages, err = packageManager.InstalledPackages(); err != nil {
+				return err
 			}
-		} else {
-			fmt.Printf("No packages are currently installed with %s.\n", packageManager.Name())
 		}
 
-		if len(packageManagerInConfig.Packages) > 0 {
-			fmt.Printf("Packages configured to be installed with %s:\n", packageManager.Name())
-			for _, packageInConfig := range packageManagerInConfig.Packages {
-				fmt.Printf("- %s, version %s\n", packageInConfig.Name, packageInConfig.Version)
-			}
+		packageManagerResult := AttunePackageManager{
+			Name:               packageManager.Name(),
+			Install:            !installed,
+			Update:             installed,
+			InstalledPackages:  []AttunePackage{},
+			ConfiguredPackages: []AttunePackage{},
+			Actions: planPackageActions(packageManagerInConfig, installedPackages,
+				configContents.IsPackagePinned, machineTags, result.Exact),
+		}
+		for _, installedPackage := range installedPackages {
+			packageManagerResult.InstalledPackages = append(packageManagerResult.InstalledPackages,
+				AttunePackage{Name: installedPackage.Name, Version: installedPackage.InstalledVersion.String()})
 		}
-
-		var desiredPackageVersions = make(map[string]*packagemanagers.Version)
 		for _, packageInConfig := range packageManagerInConfig.Packages {
-			desiredPackageVersions[packageInConfig.Name] = packagemanagers.NewVersion(packageInConfig.Version)
+			packageManagerResult.ConfiguredPackages = append(packageManagerResult.ConfiguredPackages,
+				AttunePackage{Name: packageInConfig.Name, Version: packageInConfig.Version})
 		}
 
-		var installedPackageVersions = make(map[string]*packagemanagers.Version)
-		for _, installedPackage := range installedPackages {
-			installedPackageVersions[installedPackage.Name] = installedPackage.InstalledVersion
-		}
-
-		// Update packages that are installed but have a lower version than the one in the config file.
-		for packageName, desiredPackageVersion := range desiredPackageVersions {
-			if installedPackageVersion, isPresent := installedPackageVersions[packageName]; isPresent {
-				if installedPackageVersion.IsLessThan(desiredPackageVersion) {
-					newVersion, err := packageManager.UpdatePackage(packageName, desiredPackageVersion)
-					if err != nil {
-						return err
-					}
-
-					if newVersion.IsGreaterThan(desiredPackageVersion) {
-						err = configContents.UpdatePackage(packageManager.Name(), packageName, newVersion)
-						if err != nil {
-							return err
-						}
-
-						if err = attuneCommand.configService.SetConfig(configContents); err != nil {
-							return err
-						}
-					}
+		if !packageManager.Capabilities().VersionedInstall {
+			for _, action := range packageManagerResult.Actions {
+				if action.requestsConfiguredVersion() {
+					attuneCommand.output.Printf("Package manager \"%s\" cannot install specific package versions, "+
+						"so the latest versions will be installed instead.\n", packageManager.Name())
+					break
 				}
 			}
 		}
 
-		// Install packages that are in the config file but not installed.
-		for packageName, desiredPackageVersion := range desiredPackageVersions {
-			if _, isPresent := installedPackageVersions[packageName]; !isPresent {
-				newVersion, err := packageManager.InstallPackage(packageName, desiredPackageVersion)
+		if !result.Planned {
+			for i := range packageManagerResult.Actions {
+				problem, err := attuneCommand.performPackageAction(transaction, packageManager,
+					&packageManagerResult.Actions[i], result.Exact)
 				if err != nil {
 					return err
 				}
-
-				if newVersion.IsGreaterThan(desiredPackageVersion) {
-					err = configContents.UpdatePackage(packageManager.Name(), packageName, newVersion)
-					if err != nil {
-						return err
-					}
-
-					if err = attuneCommand.configService.SetConfig(configContents); err != nil {
-						return err
-					}
+				if problem != "" {
+					result.Unconverged = append(result.Unconverged, problem)
 				}
 			}
 		}
 
-		// Uninstall packages that are installed but not in the config file.
-		for packageName := range installedPackageVersions {
-			if _, isPresent := desiredPackageVersions[packageName]; !isPresent {
-				if err := packageManager.UninstallPackage(packageName); err != nil {
-					return err
-				}
-			}
-		}
+		result.PackageManagers = append(result.PackageManagers, packageManagerResult)
 	}
 
 	return nil
 }
+
+// planPackageActions returns the changes needed to bring the packages installed with a package manager in line with the
+// configuration. Packages that are installed at a lower version than the configured one are updated, and in exact mode,
+// packages installed at a higher version are downgraded as well. Configured packages that aren't installed are
+// installed, and installed packages that aren't configured are uninstalled. Pinned packages, and packages that are
+// tagged for other machines, are skipped.
+//
+// It takes the following parameters:
+//   - packageManagerInConfig: The package manager's configuration.
+//   - installedPackages: The packages currently installed with the package manager.
+//   - isPackagePinned: Returns whether the package of the given name under the given package manager is pinned.
+//   - machineTags: The tags of the current machine.
+//   - exact: Whether packages should be brought to exactly their configured versions.
+func planPackageActions(packageManagerInConfig config.ConfiguredPackageManager,
+	installedPackages []*packagemanagers.Package, isPackagePinned func(string, string) bool, machineTags []string,
+	exact bool) []PackageAction {
+	actions := []PackageAction{}
+
+	var installedPackageVersions = make(map[string]*packagemanagers.Version)
+	for _, installedPackage := range installedPackages {
+		installedPackageVersions[installedPackage.Name] = installedPackage.InstalledVersion
+	}
+
+	// Packages that are tagged for other machines are neither installed nor uninstalled.
+	var configuredPackages = make(map[string]bool)
+	var excludedPackages = make(map[string]bool)
+	for _, packageInConfig := range packageManagerInConfig.Packages {
+		if !packageInConfig.AppliesToMachine(machineTags) {
+			excludedPackages[packageInConfig.Name] = true
+			continue
+		}
+		configuredPackages[packageInConfig.Name] = true
+
+		action := PackageAction{Package: packageInConfig.Name, ConfiguredVersion: packageInConfig.Version}
+		desiredPackageVersion := packagemanagers.NewVersion(packageInConfig.Version)
+		installedPackageVersion, isPresent := installedPackageVersions[packageInConfig.Name]
+		if isPresent {
+			action.InstalledVersion = installedPackageVersion.String()
+		}
+
+		switch {
+		case !isPresent:
+			action.Action = InstallPackageAction
+		case isPackagePinned(packageManagerInConfig.Name, packageInConfig.Name):
+			action.Action, action.Reason = SkipPackageAction, "pinned"
+		case installedPackageVersion.IsLessThan(desiredPackageVersion):
+			action.Action = UpdatePackageAction
+		case exact && installedPackageVersion.IsGreaterThan(desiredPackageVersion):
+			action.Action = DowngradePackageAction
+		default:
+			continue
+		}
+		actions = append(actions, action)
+	}
+
+	for _, installedPackage := range installedPackages {
+		if configuredPackages[installedPackage.Name] {
+			continue
+		}
+
+		action := PackageAction{Package: installedPackage.Name, Action: UninstallPackageAction,
+			InstalledVersion: installedPackage.InstalledVersion.String()}
+		if excludedPackages[installedPackage.Name] {
+			action.Action, action.Reason = SkipPackageAction, "untagged"
+		}
+		actions = append(actions, action)
+	}
+
+	return actions
+}
+
+// performPackageAction makes the given change to a package, and records the version it ends up at in the action. If
+// exact mode can't bring the package to its configured version, a description of the problem is returned, so that it
+// can be reported to the user. Otherwise, an empty string is returned.
+//
+// It takes the following parameters:
+//   - transaction: The transaction to record newer installed versions in the shared config file with.
+//   - packageManager: The package manager the package is installed with.
+//   - action: The change to make.
+//   - exact: Whether the package should be brought to exactly its configured version.
+func (attuneCommand *AttuneCommand) performPackageAction(transaction *config.ConfigTransaction,
+	packageManager packagemanagers.PackageManager, action *PackageAction, exact bool) (string, error) {
+	desiredPackageVersion := packagemanagers.NewVersion(action.ConfiguredVersion)
+	capabilities := packageManager.Capabilities()
+
+	var newVersion *packagemanagers.Version
+	var err error
+	switch action.Action {
+	case SkipPackageAction:
+		attuneCommand.output.Printf("Skipping package \"%s\" because %s.\n", action.Package,
+			action.skipReasonText())
+		return "", nil
+	case UninstallPackageAction:
+		return "", packageManager.UninstallPackage(action.Package)
+	case InstallPackageAction:
+		newVersion, err = packageManager.InstallPackage(action.Package,
+			versionToRequest(capabilities, desiredPackageVersion))
+	case UpdatePackageAction:
+		newVersion, err = packageManager.UpdatePackage(action.Package,
+			versionToRequest(capabilities, desiredPackageVersion))
+	case DowngradePackageAction:
+		newVersion = packagemanagers.NewVersion(action.InstalledVersion)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	problem := ""
+	if exact {
+		newVersion, problem = attuneCommand.downgradeIfNeeded(packageManager, action.Package, newVersion,
+			desiredPackageVersion)
+	} else if newVersion.IsGreaterThan(desiredPackageVersion) {
+		err = attuneCommand.recordInstalledVersion(transaction, packageManager.Name(), action.Package, newVersion)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	action.ResultVersion = newVersion.String()
+	return problem, nil
+}
+
+// recordInstalledVersion updates the version of the given package in the shared config file to the given version. If
+// the package isn't in the shared config file (because it comes from the local overrides file), nothing is changed.
+//
+// It takes the following parameters:
+//   - transaction: The transaction to make the change with.
+//   - packageManagerName: The name of the package manager the package is installed with.
+//   - packageName: The name of the package.
+//   - version: The version of the package that was installed.
+func (attuneCommand *AttuneCommand) recordInstalledVersion(transaction *config.ConfigTransaction,
+	packageManagerName string, packageName string, version *packagemanagers.Version) error {
+	sharedConfig, err := transaction.Config()
+	if err != nil {
+		return err
+	}
+
+	if !sharedConfig.HasPackage(packageManagerName, packageName) {
+		return nil
+	}
+
+	return transaction.Mutate(func(sharedConfig *config.Config) error {
+		if !sharedConfig.HasPackage(packageManagerName, packageName) {
+			return nil
+		}
+		return sharedConfig.UpdatePackage(packageManagerName, packageName, version)
+	})
+}
+
+// downgradeIfNeeded downgrades the given package if its installed version is newer than the desired version, and
+// returns the version it ends up at. If the package manager is unable to do this, or the package still doesn't end up
+// at the desired version, a description of the problem is also returned so that it can be reported to the user.
+// Otherwise, the description is an empty string.
+//
+// It takes the following parameters:
+//   - packageManager: The package manager the package is installed with.
+//   - packageName: The name of the package.
+//   - installedVersion: The version of the package that is currently installed.
+//   - desiredVersion: The version of the package in the config file.
+func (attuneCommand *AttuneCommand) downgradeIfNeeded(packageManager packagemanagers.PackageManager,
+	packageName string, installedVersion *packagemanagers.Version,
+	desiredVersion *packagemanagers.Version) (*packagemanagers.Version, string) {
+	if !installedVersion.IsGreaterThan(desiredVersion) {
+		return installedVersion, ""
+	}
+
+	if !packageManager.Capabilities().Downgrade {
+		return installedVersion, fmt.Sprintf("%s (%s): installed version %s, configured version %s: package "+
+			"manager does not support downgrading packages", packageName, packageManager.Name(), installedVersion,
+			desiredVersion)
+	}
+
+	newVersion, err := packageManager.DowngradePackage(packageName, desiredVersion)
+	if err != nil {
+		return installedVersion, fmt.Sprintf("%s (%s): installed version %s, configured version %s: %s",
+			packageName, packageManager.Name(), installedVersion, desiredVersion, err)
+	}
+
+	if !newVersion.IsEqualTo(desiredVersion) {
+		return newVersion, fmt.Sprintf("%s (%s): installed version %s, configured version %s", packageName,
+			packageManager.Name(), newVersion, desiredVersion)
+	}
+
+	return newVersion, ""
+}
+
+// versionToRequest returns the version to pass in when installing or updating a package with a package manager that has
+// the given capabilities. If the package manager can't install specific versions, nil is returned so the latest
+// version is installed.
+//
+// It takes the following parameters:
+//   - capabilities: The capabilities of the package manager.
+//   - desiredVersion: The version of the package in the config file.
+func versionToRequest(capabilities packagemanagers.Capabilities,
+	desiredVersion *packagemanagers.Version) *packagemanagers.Version {
+	if !capabilities.VersionedInstall {
+		return nil
+	}
+	return desiredVersion
+}
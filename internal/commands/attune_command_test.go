@@ -0,0 +1,90 @@
+package commands_test
+
+import (
+	"bytes"
+
+	. "github.com/colececil/familiar.sh/internal/commands"
+	"github.com/colececil/familiar.sh/internal/config"
+	"github.com/colececil/familiar.sh/internal/packagemanagers"
+	"github.com/colececil/familiar.sh/internal/test"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AttuneCommand", func() {
+	var packageManagerDouble *test.PackageManagerDouble
+	var stdout *bytes.Buffer
+	var stderr *bytes.Buffer
+	var attuneCommand *AttuneCommand
+
+	BeforeEach(func() {
+		packageManagerDouble = test.NewPackageManagerDouble("scoop", packagemanagers.Capabilities{Hold: true})
+		packageManagerDouble.InstalledVersions["package1"] = "0.9.0"
+		packageManagerDouble.LatestVersions["package1"] = "1.0.0"
+		packageManagerDouble.LatestVersions["package2"] = "2.0.0"
+		packageManagerDouble.InstalledVersions["package3"] = "3.0.0"
+
+		configService, _ := newConfigService("packageManagers:\n  - name: scoop\n    packages:\n" +
+			"      - name: package1\n        version: 1.0.0\n      - name: package2\n        version: 2.0.0\n")
+		packageManagerRegistry := packagemanagers.PackageManagerRegistry{"scoop": packageManagerDouble}
+		var output *Output
+		output, stdout, stderr = newOutputWithFormat(JsonOutputFormat)
+		attuneCommand = NewAttuneCommand(configService, config.NewConfigValidator(configService,
+			packageManagerRegistry), packageManagerRegistry, output)
+	})
+
+	It("should print the documented structure of the planned changes in the JSON format", func() {
+		Expect(attuneCommand.Execute([]string{}, FlagValues{"plan": ""})).To(Succeed())
+
+		Expect(stdout.String()).To(MatchJSON(`{"planned": true, "exact": false, "diagnostics": [],
+			"packageManagers": [{"name": "scoop", "install": false, "update": true,
+				"installedPackages": [{"name": "package1", "version": "0.9.0"},
+					{"name": "package3", "version": "3.0.0"}],
+				"configuredPackages": [{"name": "package1", "version": "1.0.0"},
+					{"name": "package2", "version": "2.0.0"}],
+				"actions": [
+				{"package": "package1", "action": "update", "configuredVersion": "1.0.0", "installedVersion": "0.9.0"},
+				{"package": "package2", "action": "install", "configuredVersion": "2.0.0"},
+				{"package": "package3", "action": "uninstall", "installedVersion": "3.0.0"}
+			]}],
+			"unconverged": []}`))
+		Expect(packageManagerDouble.InstalledVersions).To(HaveKeyWithValue("package1", "0.9.0"))
+		Expect(packageManagerDouble.Updated).To(BeFalse())
+	})
+
+	It("should print the documented structure of the changes that were made in the JSON format", func() {
+		Expect(attuneCommand.Execute([]string{}, FlagValues{})).To(Succeed())
+
+		Expect(stdout.String()).To(MatchJSON(`{"planned": false, "exact": false, "diagnostics": [],
+			"packageManagers": [{"name": "scoop", "install": false, "update": true,
+				"installedPackages": [{"name": "package1", "version": "0.9.0"},
+					{"name": "package3", "version": "3.0.0"}],
+				"configuredPackages": [{"name": "package1", "version": "1.0.0"},
+					{"name": "package2", "version": "2.0.0"}],
+				"actions": [
+				{"package": "package1", "action": "update", "configuredVersion": "1.0.0", "installedVersion": "0.9.0",
+					"resultVersion": "1.0.0"},
+				{"package": "package2", "action": "install", "configuredVersion": "2.0.0", "resultVersion": "2.0.0"},
+				{"package": "package3", "action": "uninstall", "installedVersion": "3.0.0"}
+			]}],
+			"unconverged": []}`))
+		Expect(packageManagerDouble.InstalledVersions).To(Equal(map[string]string{"package1": "1.0.0",
+			"package2": "2.0.0"}))
+		Expect(packageManagerDouble.Updated).To(BeTrue())
+	})
+
+	It("should warn that the latest versions will be installed when a version can't be installed", func() {
+		Expect(attuneCommand.Execute([]string{}, FlagValues{"plan": ""})).To(Succeed())
+
+		Expect(stderr.String()).To(ContainSubstring(`Package manager "scoop" cannot install specific package versions`))
+	})
+
+	It("should not warn about installing specific versions when nothing needs to be installed or updated", func() {
+		packageManagerDouble.InstalledVersions["package1"] = "1.0.0"
+		packageManagerDouble.InstalledVersions["package2"] = "2.0.0"
+
+		Expect(attuneCommand.Execute([]string{}, FlagValues{})).To(Succeed())
+
+		Expect(stderr.String()).ToNot(ContainSubstring("cannot install specific package versions"))
+	})
+})
@@ -13,11 +13,15 @@ type Command interface {
 	// Documentation returns detailed documentation for the command.
 	Documentation() string
 
+	// Flags returns the flags the command accepts, in addition to the global flags. Its help output lists them.
+	Flags() []Flag
+
 	// Execute runs the command with the given arguments.
 	//
 	// It takes the following parameters:
-	//   - args: A slice containing the arguments to pass in to the command.
+	//   - args: A slice containing the positional arguments to pass in to the command, with any flags removed.
+	//   - flags: The values of the flags given on the command line, including the global flags.
 	//
 	// If there is an error executing the command, Execute will return an error that can be displayed to the user.
-	Execute(args []string) error
+	Execute(args []string, flags FlagValues) error
 }
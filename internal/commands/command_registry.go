@@ -5,14 +5,17 @@ import "fmt"
 type CommandRegistry map[string]Command
 
 // NewCommandRegistry returns a new instance of CommandRegistry.
-func NewCommandRegistry(versionCommand *VersionCommand, attuneCommand *AttuneCommand, configCommand *ConfigCommand,
-	packageCommand *PackageCommand, helpCommand *HelpCommand) CommandRegistry {
+func NewCommandRegistry(versionCommand *VersionCommand, initCommand *InitCommand, attuneCommand *AttuneCommand,
+	configCommand *ConfigCommand, packageCommand *PackageCommand, machineCommand *MachineCommand,
+	helpCommand *HelpCommand) CommandRegistry {
 	return CommandRegistry{
 		helpCommand.Name():    helpCommand,
 		versionCommand.Name(): versionCommand,
+		initCommand.Name():    initCommand,
 		attuneCommand.Name():  attuneCommand,
 		configCommand.Name():  configCommand,
 		packageCommand.Name(): packageCommand,
+		machineCommand.Name(): machineCommand,
 	}
 }
 
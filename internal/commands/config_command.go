@@ -3,20 +3,305 @@ package commands
 import (
 	"fmt"
 	"github.com/colececil/familiar.sh/internal/config"
+	"github.com/colececil/familiar.sh/internal/packagemanagers"
+	"github.com/colececil/familiar.sh/internal/system"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 )
 
 // ConfigCommand represents the "config" command.
 type ConfigCommand struct {
-	configService *config.ConfigService
+	configService          *config.ConfigService
+	configValidator        *config.ConfigValidator
+	packageManagerRegistry packagemanagers.PackageManagerRegistry
+	output                 *Output
 }
 
 // NewConfigCommand creates a new instance of ConfigCommand.
-func NewConfigCommand(configService *config.ConfigService) *ConfigCommand {
+func NewConfigCommand(configService *config.ConfigService, configValidator *config.ConfigValidator,
+	packageManagerRegistry packagemanagers.PackageManagerRegistry, output *Output) *ConfigCommand {
 	return &ConfigCommand{
-		configService: configService,
+		configService:          configService,
+		configValidator:        configValidator,
+		packageManagerRegistry: packageManagerRegistry,
+		output:                 output,
 	}
 }
 
+// effectiveConfigResult is the result of "familiar config --effective". In the structured formats, it is the effective
+// configuration itself, in the same format as the config file. In the text format, each entry is annotated with the
+// file it came from.
+type effectiveConfigResult struct {
+	config        *config.Config
+	annotatedYaml string
+}
+
+// Text returns the result as human-readable text.
+func (effectiveConfigResult effectiveConfigResult) Text() string {
+	return effectiveConfigResult.annotatedYaml + "\n"
+}
+
+// MarshalYAML returns the value to marshal in place of the result.
+func (effectiveConfigResult effectiveConfigResult) MarshalYAML() (any, error) {
+	return effectiveConfigResult.config, nil
+}
+
+// jsonSchemaResult is the result of "familiar config schema", which is a JSON Schema. In the text format, it is printed
+// as it is.
+type jsonSchemaResult []byte
+
+// Text returns the result as human-readable text.
+func (jsonSchemaResult jsonSchemaResult) Text() string {
+	return string(jsonSchemaResult)
+}
+
+// MarshalYAML returns the value to marshal in place of the result.
+func (jsonSchemaResult jsonSchemaResult) MarshalYAML() (any, error) {
+	var schema any
+	err := yaml.Unmarshal(jsonSchemaResult, &schema)
+	return schema, err
+}
+
+// ConfigLocationResult is the result of "familiar config location" when it is given no arguments.
+type ConfigLocationResult struct {
+	// Path is the path of the shared config file. For stores other than the file store, it is the local copy.
+	Path string `yaml:"path"`
+	// Store is the name of the config store that manages the shared config file, such as "file" or "git".
+	Store string `yaml:"store"`
+	// Url is where the store keeps the shared config file. For the file store, it is the same as Path.
+	Url string `yaml:"url"`
+	// Source describes where the location comes from, such as the context in use.
+	Source string `yaml:"source"`
+}
+
+// Text returns the result as human-readable text.
+func (configLocationResult ConfigLocationResult) Text() string {
+	text := fmt.Sprintf("%s\n(from %s)\n", configLocationResult.Path, configLocationResult.Source)
+	if configLocationResult.Url != configLocationResult.Path {
+		text += fmt.Sprintf("(local copy of the config file in %s store \"%s\")\n", configLocationResult.Store,
+			configLocationResult.Url)
+	}
+	return text
+}
+
+// ContextsResult is the result of "familiar config context list".
+type ContextsResult struct {
+	// Current is the name of the context in use, or "default" if no context is in use.
+	Current string `yaml:"current"`
+	// Contexts are the contexts set up on the current machine, starting with "default".
+	Contexts []ContextEntry `yaml:"contexts"`
+}
+
+// ContextEntry describes a context set up on the current machine. Layered contexts have Layers and WritableLayer
+// instead of Store and Url.
+type ContextEntry struct {
+	Name          string   `yaml:"name"`
+	Store         string   `yaml:"store,omitempty"`
+	Url           string   `yaml:"url,omitempty"`
+	Layers        []string `yaml:"layers,omitempty"`
+	WritableLayer string   `yaml:"writableLayer,omitempty"`
+}
+
+// Text returns the result as a table, marking the context in use with "*".
+func (contextsResult ContextsResult) Text() string {
+	var textBuilder strings.Builder
+	tabWriter := tabwriter.NewWriter(&textBuilder, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tabWriter, "\tCONTEXT\tSTORE\tLOCATION")
+	for _, contextEntry := range contextsResult.Contexts {
+		marker := ""
+		if contextEntry.Name == contextsResult.Current {
+			marker = "*"
+		}
+
+		store, location := contextEntry.Store, contextEntry.Url
+		if len(contextEntry.Layers) > 0 {
+			var layers []string
+			for _, layer := range contextEntry.Layers {
+				if layer == contextEntry.WritableLayer {
+					layer += " (writable)"
+				}
+				layers = append(layers, layer)
+			}
+			store, location = "layered", strings.Join(layers, ", ")
+		} else if store == "" {
+			location = "(not set)"
+		}
+		_, _ = fmt.Fprintf(tabWriter, "%s\t%s\t%s\t%s\n", marker, contextEntry.Name, store, location)
+	}
+	_ = tabWriter.Flush()
+	return textBuilder.String()
+}
+
+// HistoryResult is the result of "familiar config history".
+type HistoryResult struct {
+	// Snapshots are the saved snapshots of the shared config file, newest first.
+	Snapshots []config.ConfigSnapshot `yaml:"snapshots"`
+}
+
+// Text returns the result as a table.
+func (historyResult HistoryResult) Text() string {
+	if len(historyResult.Snapshots) == 0 {
+		return "No snapshots of the config file have been saved yet.\n"
+	}
+
+	var textBuilder strings.Builder
+	tabWriter := tabwriter.NewWriter(&textBuilder, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tabWriter, "SNAPSHOT\tSAVED AT")
+	for _, snapshot := range historyResult.Snapshots {
+		_, _ = fmt.Fprintf(tabWriter, "%s\t%s\n", snapshot.Id, snapshot.Time.Local().Format(time.DateTime))
+	}
+	_ = tabWriter.Flush()
+	return textBuilder.String()
+}
+
+// DiffResult is the result of "familiar config diff".
+type DiffResult struct {
+	// Snapshot is the ID of the snapshot the shared config file is compared with.
+	Snapshot string `yaml:"snapshot"`
+	// Changed is whether the shared config file has changed since the snapshot was saved.
+	Changed bool `yaml:"changed"`
+	// Diff is the changes made since the snapshot was saved, as a unified diff. It is empty if there are none.
+	Diff string `yaml:"diff"`
+}
+
+// Text returns the result as human-readable text.
+func (diffResult DiffResult) Text() string {
+	if !diffResult.Changed {
+		return "The config file hasn't changed since the snapshot was saved.\n"
+	}
+	return diffResult.Diff
+}
+
+// ConflictCopiesResult is the result of "familiar config conflicts".
+type ConflictCopiesResult struct {
+	// ConflictCopies are the paths of the conflicting copies of the shared config file.
+	ConflictCopies []string `yaml:"conflictCopies"`
+}
+
+// Text returns the result as human-readable text.
+func (conflictCopiesResult ConflictCopiesResult) Text() string {
+	if len(conflictCopiesResult.ConflictCopies) == 0 {
+		return "No conflicting copies of the config file were found.\n"
+	}
+	return strings.Join(conflictCopiesResult.ConflictCopies, "\n") + "\n"
+}
+
+// ConflictMergeResult is the result of "familiar config conflicts merge".
+type ConflictMergeResult struct {
+	// Merges describe each conflicting copy that was merged into the shared config file.
+	Merges []ConflictCopyMerge `yaml:"merges"`
+}
+
+// ConflictCopyMerge describes a conflicting copy that was merged into the shared config file.
+type ConflictCopyMerge struct {
+	// Path is the path of the conflicting copy, before it was renamed with a ".merged" suffix.
+	Path string `yaml:"path"`
+	// Changes describe each change made to the shared config file. It is empty if no changes were needed.
+	Changes []string `yaml:"changes"`
+}
+
+// Text returns the result as human-readable text.
+func (conflictMergeResult ConflictMergeResult) Text() string {
+	if len(conflictMergeResult.Merges) == 0 {
+		return "No conflicting copies of the config file were found.\n"
+	}
+
+	var textBuilder strings.Builder
+	for _, merge := range conflictMergeResult.Merges {
+		textBuilder.WriteString(fmt.Sprintf("Merged \"%s\":\n", merge.Path))
+		if len(merge.Changes) == 0 {
+			textBuilder.WriteString("- No changes were needed.\n")
+		}
+		for _, change := range merge.Changes {
+			textBuilder.WriteString(fmt.Sprintf("- %s\n", change))
+		}
+	}
+	return textBuilder.String()
+}
+
+// ValidationResult is the result of "familiar config validate". If the configuration is valid, it also lists the
+// entries of the effective configuration, along with the file each of them came from.
+type ValidationResult struct {
+	Valid       bool                `yaml:"valid"`
+	Diagnostics []config.Diagnostic `yaml:"diagnostics"`
+	// Sources are the config files that make up the effective configuration, in the order they were applied.
+	Sources         []string                `yaml:"sources,omitempty"`
+	PackageManagers []SourcedPackageManager `yaml:"packageManagers,omitempty"`
+	Files           []SourcedEntry          `yaml:"files,omitempty"`
+	Scripts         []SourcedEntry          `yaml:"scripts,omitempty"`
+}
+
+// SourcedPackageManager is a package manager in the effective configuration, along with the file it came from.
+type SourcedPackageManager struct {
+	Name     string           `yaml:"name"`
+	Source   string           `yaml:"source"`
+	Packages []SourcedPackage `yaml:"packages"`
+}
+
+// SourcedPackage is a package in the effective configuration, along with the file it came from.
+type SourcedPackage struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+	Source  string `yaml:"source"`
+}
+
+// SourcedEntry is a file or script in the effective configuration, along with the file it came from.
+type SourcedEntry struct {
+	SourcePath string `yaml:"sourcePath"`
+	Source     string `yaml:"source"`
+}
+
+// Text returns the result as human-readable text.
+func (validationResult ValidationResult) Text() string {
+	var reportStringBuilder strings.Builder
+	for _, diagnostic := range validationResult.Diagnostics {
+		reportStringBuilder.WriteString(diagnostic.String() + "\n")
+	}
+
+	if !validationResult.Valid {
+		return reportStringBuilder.String()
+	}
+
+	reportStringBuilder.WriteString("Config files:\n")
+	for _, source := range validationResult.Sources {
+		reportStringBuilder.WriteString(fmt.Sprintf("- %s\n", source))
+	}
+
+	if len(validationResult.PackageManagers) > 0 {
+		reportStringBuilder.WriteString("Package managers:\n")
+		for _, packageManager := range validationResult.PackageManagers {
+			reportStringBuilder.WriteString(fmt.Sprintf("- %s (from %s)\n", packageManager.Name,
+				packageManager.Source))
+			for _, configuredPackage := range packageManager.Packages {
+				reportStringBuilder.WriteString(fmt.Sprintf("  - %s, version %s (from %s)\n", configuredPackage.Name,
+					configuredPackage.Version, configuredPackage.Source))
+			}
+		}
+	}
+
+	if len(validationResult.Files) > 0 {
+		reportStringBuilder.WriteString("Files:\n")
+		for _, configuredFile := range validationResult.Files {
+			reportStringBuilder.WriteString(fmt.Sprintf("- %s (from %s)\n", configuredFile.SourcePath,
+				configuredFile.Source))
+		}
+	}
+
+	if len(validationResult.Scripts) > 0 {
+		reportStringBuilder.WriteString("Scripts:\n")
+		for _, configuredScript := range validationResult.Scripts {
+			reportStringBuilder.WriteString(fmt.Sprintf("- %s (from %s)\n", configuredScript.SourcePath,
+				configuredScript.Source))
+		}
+	}
+
+	reportStringBuilder.WriteString("The configuration is valid.\n")
+	return reportStringBuilder.String()
+}
+
 // Name returns the name of the command, as it appears on the command line while being used.
 func (configCommand *ConfigCommand) Name() string {
 	return "config"
@@ -29,47 +314,496 @@ func (configCommand *ConfigCommand) Description() string {
 
 // Documentation returns detailed documentation for the command.
 func (configCommand *ConfigCommand) Documentation() string {
-	return `The "config" command has the following subcommands:
+	return `When run without a subcommand, the "config" command prints the contents of the shared configuration file. If the "--effective" flag is given, it instead prints the effective configuration for the current machine, which is the shared configuration with any local overrides applied. Each entry is annotated with the file it came from.
+
+Local overrides are read from "` + config.ConfigOverridesLocation() + `", which is never synced to other machines. It can contain the following keys:
+
+  packageManagers: Packages to add for this machine, or to replace (for example, to change their version).
+  excludedPackages: Packages from the shared configuration that shouldn't be installed on this machine, each given as a "packageManager" and "name".
+  files: Files from the shared configuration to sync to a different location on this machine, each given as a "sourcePath" and "destinationPath".
+  disabledScripts: The source paths of scripts from the shared configuration that shouldn't be run on this machine.
 
-location: Print the config file location or set the config file location to the given path.
+The config file location is resolved in the following order: the global "--config <context or path>" flag (for example, "familiar --config team attune"), the ` + config.ConfigEnvironmentVariable + ` environment variable (which can also be a context name or a path), the context in use, and finally the default location.
 
-Run "familiar help config location" for more information about the "location" subcommand.`
+The shared configuration file can split its contents across multiple files by listing them under "include". Each entry is a path or glob pattern (for example, "packages/*.yaml"), relative to the directory of the shared configuration file. Included files are merged in the order they are listed, with the files matched by a glob pattern sorted by path. If an entry is defined in more than one file, the first definition is used, starting with the shared configuration file itself, and the others are reported by "familiar config validate". Entries in included files are never changed by Familiar.sh, so their versions must be updated by hand.
+
+With "--output json" or "--output yaml", the configuration, "location", "validate", "context", "history", "diff", "conflicts", and "conflicts merge" print structured objects, which are described in the README.`
+}
+
+// Flags returns the flags the command accepts, in addition to the global flags.
+func (configCommand *ConfigCommand) Flags() []Flag {
+	return []Flag{
+		{
+			Name:        "effective",
+			Description: "Print the effective configuration, with local overrides applied. Not used with a subcommand.",
+		},
+	}
 }
 
 // Execute runs the command with the given arguments.
 //
 // It takes the following parameters:
-//   - args: A slice containing the arguments to pass in to the command.
+//   - args: A slice containing the positional arguments to pass in to the command, with any flags removed.
+//   - flags: The values of the flags given on the command line, including the global flags.
 //
 // If there is an error executing the command, Execute will return an error that can be displayed to the user.
-func (configCommand *ConfigCommand) Execute(args []string) error {
-	if len(args) == 0 {
-		configContents, err := configCommand.configService.GetConfig()
+func (configCommand *ConfigCommand) Execute(args []string, flags FlagValues) error {
+	if flags.IsSet("effective") && len(args) > 0 {
+		return fmt.Errorf("the --effective flag can't be given with a subcommand")
+	}
+	return configCommand.Subcommands().Execute(args, flags)
+}
+
+// Subcommands returns the root of the command's tree of subcommands.
+func (configCommand *ConfigCommand) Subcommands() *Subcommand {
+	snapshotArgument := Argument{Name: "snapshot",
+		Description: "The ID of a snapshot, as listed by \"config history\"."}
+	contextNameArgument := Argument{Name: "name", Description: "The name of the context."}
+
+	return &Subcommand{
+		Name:          configCommand.Name(),
+		Description:   configCommand.Description(),
+		Documentation: configCommand.Documentation(),
+		Run: func(args []string, flags FlagValues) error {
+			return configCommand.printConfig(flags.IsSet("effective"))
+		},
+		Subcommands: []*Subcommand{
+			{
+				Name:        "location",
+				Description: "Print or set the config file location.",
+				Documentation: `When run without arguments, "location" prints the config file location. Given a path, it sets the config file location to that path. Given a config store and URL, it uses the config file at that URL in the store, instead of a local file. If the URL can hold more than one file, the path of the config file within it can be given (by default, "config.yaml"). A local copy of the config file is kept, which is brought up to date before it is read, and each change Familiar.sh makes to it is sent to the store. Setting the location to a plain path stops using the store. The following stores are supported:
+
+  file: A local file, which can be synced between machines by a cloud drive. This is the same as giving just the path.
+  git: A file in a git repository. The repository is cloned into the XDG data directory, the latest changes are pulled before the config file is read, and each change Familiar.sh makes to it is committed with a message describing the change and pushed. If another machine pushed first, its changes are pulled and rebased on before pushing again.
+  http: A file on a WebDAV or plain HTTP server, given the URL of its directory (credentials can be included in the URL). The file is fetched with GET and written with PUT, using ETags so that unchanged files aren't downloaded again and changes made by another machine aren't overwritten. The config file and the files it refers to are cached in the XDG data directory, so the cached copy is used when the server can't be reached.`,
+				Arguments: []Argument{
+					{Name: "store", Optional: true, Description: "The config store (file, git, or http)."},
+					{Name: "url", Optional: true, Description: "The URL of the config file in the store."},
+					{Name: "path", Optional: true, Description: "The path of the config file."},
+				},
+				Usages: []string{"", "<path>", "<store> <url> [<path>]"},
+				Run: func(args []string, flags FlagValues) error {
+					switch len(args) {
+					case 0:
+						return configCommand.printLocation()
+					case 1:
+						return configCommand.configService.SetConfigLocation(args[0])
+					case 2:
+						return configCommand.configService.SetConfigStoreLocation(args[0], args[1], "")
+					default:
+						return configCommand.configService.SetConfigStoreLocation(args[0], args[1], args[2])
+					}
+				},
+			},
+			{
+				Name:        "validate",
+				Description: "Check the shared configuration for problems.",
+				Documentation: "Check the shared configuration file (or, with a layered context, the config file of " +
+					"each layer), the files it includes, and the local overrides file for problems, such as unknown " +
+					"fields, unknown package managers or operating systems, duplicate entries, missing source files, " +
+					"and a \"remove\" section in the first layer. Each problem is reported with the file, line, and " +
+					"column it was found at. If there are no errors, each entry of the effective configuration is " +
+					"listed along with the file it came from. Validation is also run automatically before " +
+					"\"familiar attune\".",
+				Run: func(args []string, flags FlagValues) error {
+					return configCommand.validate()
+				},
+			},
+			{
+				Name:        "conflicts",
+				Description: "List conflicting copies of the shared configuration file.",
+				Documentation: "List any conflicting copies of the shared configuration file, which cloud drives " +
+					"create next to it (with names like \"config (1).yaml\") when it is changed on more than one " +
+					"machine at once.",
+				Run: func(args []string, flags FlagValues) error {
+					return configCommand.listConflictCopies()
+				},
+				Subcommands: []*Subcommand{
+					{
+						Name:        "merge",
+						Description: "Merge any conflicting copies into the shared configuration file.",
+						Documentation: "Entries that are only in a conflicting copy are added, and if a package has " +
+							"a different version in each, the greater version is kept. Since entries that were " +
+							"removed on only one machine are added back, check the result afterward. Merged copies " +
+							"are renamed with a \".merged\" suffix.",
+						Run: func(args []string, flags FlagValues) error {
+							return configCommand.mergeConflictCopies()
+						},
+					},
+				},
+			},
+			{
+				Name:        "history",
+				Description: "List the snapshots of the shared configuration file, newest first.",
+				Documentation: "Before Familiar.sh changes the shared configuration file, a snapshot of its current " +
+					"contents is saved to the \".familiar-history\" directory beside it, so the history is shared " +
+					"along with the file (and committed along with it, for the git store). The newest 50 snapshots " +
+					"from the last 90 days are kept.",
+				Run: func(args []string, flags FlagValues) error {
+					return configCommand.printHistory()
+				},
+			},
+			{
+				Name:        "diff",
+				Description: "Print the changes made to the shared configuration file since a snapshot was saved.",
+				Arguments:   []Argument{snapshotArgument},
+				Run: func(args []string, flags FlagValues) error {
+					return configCommand.printDiff(args[0])
+				},
+			},
+			{
+				Name:        "rollback",
+				Description: "Replace the contents of the shared configuration file with a snapshot.",
+				Documentation: "A snapshot of the current contents is saved first, so the rollback can be undone. " +
+					"Run \"familiar attune\" afterward to apply the restored configuration.",
+				Arguments: []Argument{snapshotArgument},
+				Run: func(args []string, flags FlagValues) error {
+					if err := configCommand.configService.RollBackConfig(args[0]); err != nil {
+						return err
+					}
+					configCommand.output.Printf("The config file has been rolled back to snapshot %q.\n", args[0])
+					return nil
+				},
+			},
+			{
+				Name:        "schema",
+				Description: "Print a JSON Schema describing the format of the shared configuration file.",
+				Documentation: "Editors can use the schema to autocomplete and check the configuration file, for " +
+					"example by adding \"# yaml-language-server: $schema=<path to schema>\" to the top of the file.",
+				Run: func(args []string, flags FlagValues) error {
+					packageManagerNames := configCommand.packageManagerRegistry.GetPackageManagerNames()
+					schema, err := config.GenerateJsonSchema(packageManagerNames)
+					if err != nil {
+						return err
+					}
+					return configCommand.output.Result(jsonSchemaResult(schema))
+				},
+			},
+			{
+				Name:        "context",
+				Description: "List the contexts set up on this machine, marking the one in use.",
+				Documentation: "A context is a named config file location, for switching between more than one " +
+					"shared configuration file (for example, a personal one and a team one).",
+				Run: func(args []string, flags FlagValues) error {
+					return configCommand.listContexts()
+				},
+				Subcommands: []*Subcommand{
+					{
+						Name:        "list",
+						Description: "List the contexts set up on this machine, marking the one in use.",
+						Run: func(args []string, flags FlagValues) error {
+							return configCommand.listContexts()
+						},
+					},
+					{
+						Name: "add",
+						Description: "Add a context for the given config file location, given in the same way as for " +
+							"\"config location\".",
+						Arguments: []Argument{
+							contextNameArgument,
+							{Name: "store", Optional: true, Description: "The config store (file, git, or http)."},
+							{Name: "url", Optional: true, Description: "The URL of the config file in the store."},
+							{Name: "path", Optional: true, Description: "The path of the config file."},
+						},
+						Usages: []string{"<name> <path>", "<name> <store> <url> [<path>]"},
+						Run:    configCommand.addContext,
+					},
+					{
+						Name:        "use",
+						Description: "Use the given context.",
+						Documentation: "Use \"default\" to go back to the location set while no context was in use. " +
+							"While a context is in use, \"config location\" changes the context's location.",
+						Arguments: []Argument{contextNameArgument},
+						Run: func(args []string, flags FlagValues) error {
+							if err := configCommand.configService.UseConfigContext(args[0]); err != nil {
+								return err
+							}
+							configCommand.output.Printf("Now using context %q.\n", args[0])
+							return nil
+						},
+					},
+					{
+						Name:        "remove",
+						Description: "Remove the given context. The config file itself isn't changed.",
+						Arguments:   []Argument{contextNameArgument},
+						Run: func(args []string, flags FlagValues) error {
+							if err := configCommand.configService.RemoveConfigContext(args[0]); err != nil {
+								return err
+							}
+							configCommand.output.Printf("Context %q removed.\n", args[0])
+							return nil
+						},
+					},
+					{
+						Name: "layers",
+						Description: "Create or replace a layered context, which merges the config files of other " +
+							"contexts.",
+						Documentation: "The config files of the given contexts (or \"default\") are merged in order, " +
+							"so that a team baseline and a personal configuration can be used together. Later layers " +
+							"can add entries, replace them (for example, to change a package's version), or remove " +
+							"them by listing them under \"remove\" (with \"packages\" given as \"packageManager\" " +
+							"and \"name\", and \"files\" and \"scripts\" given as source paths). Source paths in " +
+							"each layer are relative to the directory of that layer's config file.",
+						Arguments: []Argument{
+							{Name: "name", Description: "The name of the layered context."},
+							{Name: "layer", Repeated: true, Description: "The name of a context to use as a layer."},
+						},
+						Run: func(args []string, flags FlagValues) error {
+							err := configCommand.configService.SetConfigContextLayers(args[0], args[1:])
+							if err != nil {
+								return err
+							}
+							configCommand.output.Printf("Context %q now merges %s. Run \"familiar config context use "+
+								"%s\" to use it.\n", args[0], strings.Join(args[1:], ", "), args[0])
+							return nil
+						},
+					},
+					{
+						Name: "writable",
+						Description: "Set which layer of a layered context changes are written to. By default, it is " +
+							"the last layer.",
+						Arguments: []Argument{
+							{Name: "name", Description: "The name of the layered context."},
+							{Name: "layer", Description: "The name of the layer to write changes to."},
+						},
+						Run: func(args []string, flags FlagValues) error {
+							err := configCommand.configService.SetConfigContextWritableLayer(args[0], args[1])
+							if err != nil {
+								return err
+							}
+							configCommand.output.Printf("Changes will be written to layer %q of context %q.\n", args[1],
+								args[0])
+							return nil
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// printConfig prints the contents of the shared config file, or the effective configuration for the current machine
+// with each entry annotated with the file it came from.
+func (configCommand *ConfigCommand) printConfig(effective bool) error {
+	if !effective {
+		configContents, err := configCommand.configService.GetSharedConfig()
 		if err != nil {
 			return err
 		}
 
-		configYaml, err := configContents.YamlString()
-		if err != nil {
-			return err
+		return configCommand.output.Result(configContents)
+	}
+
+	effectiveConfig, provenance, err := configCommand.configService.GetEffectiveConfig()
+	if err != nil {
+		return err
+	}
+
+	configYaml, err := provenance.AnnotatedYamlString(effectiveConfig)
+	if err != nil {
+		return err
+	}
+
+	return configCommand.output.Result(effectiveConfigResult{config: effectiveConfig, annotatedYaml: configYaml})
+}
+
+// printLocation prints the config file location, along with the config store it comes from and where the location was
+// set.
+func (configCommand *ConfigCommand) printLocation() error {
+	storeLocation, err := configCommand.configService.GetConfigStoreLocation()
+	if err != nil {
+		return err
+	}
+
+	source, err := configCommand.configService.GetConfigLocationSource()
+	if err != nil {
+		return err
+	}
+
+	return configCommand.output.Result(ConfigLocationResult{Path: storeLocation.Path, Store: storeLocation.Store,
+		Url: storeLocation.RedactedUrl(), Source: source})
+}
+
+// addContext adds the context of the given name, for the config file location given in the rest of the arguments.
+func (configCommand *ConfigCommand) addContext(args []string, flags FlagValues) error {
+	var err error
+	switch len(args) {
+	case 1:
+		return fmt.Errorf("wrong number of arguments")
+	case 2:
+		err = configCommand.configService.AddConfigContext(args[0], config.FileConfigStoreName, args[1], "")
+	case 3:
+		err = configCommand.configService.AddConfigContext(args[0], args[1], args[2], "")
+	default:
+		err = configCommand.configService.AddConfigContext(args[0], args[1], args[2], args[3])
+	}
+	if err != nil {
+		return err
+	}
+
+	configCommand.output.Printf("Context %q added. Run \"familiar config context use %s\" to use it.\n", args[0],
+		args[0])
+	return nil
+}
+
+// listContexts prints the contexts set up on the current machine, marking the one in use.
+func (configCommand *ConfigCommand) listContexts() error {
+	contexts, err := configCommand.configService.GetConfigContexts()
+	if err != nil {
+		return err
+	}
+
+	result := ContextsResult{Current: contexts.Current, Contexts: []ContextEntry{{Name: "default"}}}
+	if result.Current == "" {
+		result.Current = "default"
+	}
+	if location, err := configCommand.configService.GetDefaultConfigStoreLocation(); err == nil {
+		result.Contexts[0].Store, result.Contexts[0].Url = location.Store, location.RedactedUrl()
+	}
+
+	for _, configContext := range contexts.Contexts {
+		contextEntry := ContextEntry{Name: configContext.Name, Store: configContext.Store,
+			Url: system.RedactUrl(configContext.Url)}
+		if len(configContext.Layers) > 0 {
+			contextEntry.Layers = configContext.Layers
+			contextEntry.WritableLayer = configContext.WritableLayerName()
 		}
+		result.Contexts = append(result.Contexts, contextEntry)
+	}
+
+	return configCommand.output.Result(result)
+}
 
-		fmt.Println(configYaml)
-		return nil
+// printHistory prints the snapshots of the shared configuration file, newest first.
+func (configCommand *ConfigCommand) printHistory() error {
+	snapshots, err := configCommand.configService.GetConfigHistory()
+	if err != nil {
+		return err
 	}
 
-	switch args[0] {
-	case "location":
-		if len(args) == 1 {
-			location, err := configCommand.configService.GetConfigLocation()
-			if len(location) > 0 {
-				fmt.Println(location)
-			}
+	if snapshots == nil {
+		snapshots = []config.ConfigSnapshot{}
+	}
+	return configCommand.output.Result(HistoryResult{Snapshots: snapshots})
+}
+
+// printDiff prints the changes made to the shared configuration file since the snapshot with the given ID was saved.
+func (configCommand *ConfigCommand) printDiff(snapshotId string) error {
+	configLocation, err := configCommand.configService.GetConfigLocation()
+	if err != nil {
+		return err
+	}
+
+	_, snapshotContents, err := configCommand.configService.ReadSnapshot(snapshotId)
+	if err != nil {
+		return err
+	}
+
+	currentContents, err := configCommand.configService.ReadSharedConfigFile()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	diff := config.UnifiedDiff("snapshot "+snapshotId, configLocation, string(snapshotContents),
+		string(currentContents))
+	return configCommand.output.Result(DiffResult{Snapshot: snapshotId, Changed: diff != "", Diff: diff})
+}
+
+// listConflictCopies prints the paths of any conflicting copies of the shared configuration file.
+func (configCommand *ConfigCommand) listConflictCopies() error {
+	configLocation, err := configCommand.configService.GetConfigLocation()
+	if err != nil {
+		return err
+	}
+
+	sharedConfig, err := configCommand.configService.GetSharedConfig()
+	if err != nil {
+		return err
+	}
+
+	includePaths, err := config.ResolveIncludes(sharedConfig, configLocation)
+	if err != nil {
+		return err
+	}
+
+	conflictCopies, err := config.FindConflictCopies(configLocation, includePaths)
+	if err != nil {
+		return err
+	}
+
+	if conflictCopies == nil {
+		conflictCopies = []string{}
+	}
+	return configCommand.output.Result(ConflictCopiesResult{ConflictCopies: conflictCopies})
+}
+
+// mergeConflictCopies merges any conflicting copies of the shared configuration file into it, and prints the changes
+// that were made.
+func (configCommand *ConfigCommand) mergeConflictCopies() error {
+	merges, err := configCommand.configService.MergeConflictCopies()
+	if err != nil {
+		return err
+	}
+
+	result := ConflictMergeResult{Merges: []ConflictCopyMerge{}}
+	for _, merge := range merges {
+		changes := merge.Changes
+		if changes == nil {
+			changes = []string{}
+		}
+		result.Merges = append(result.Merges, ConflictCopyMerge{Path: merge.Path, Changes: changes})
+	}
+	return configCommand.output.Result(result)
+}
+
+// validate checks the configuration files for problems and prints them. If there are no errors, it then prints each
+// entry of the effective configuration along with the file it came from.
+func (configCommand *ConfigCommand) validate() error {
+	diagnostics, err := configCommand.configValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	result := ValidationResult{Valid: !config.HasErrors(diagnostics), Diagnostics: diagnostics}
+	if result.Diagnostics == nil {
+		result.Diagnostics = []config.Diagnostic{}
+	}
+
+	if !result.Valid {
+		if err = configCommand.output.Result(result); err != nil {
 			return err
-		} else {
-			return configCommand.configService.SetConfigLocation(args[1])
 		}
-	default:
-		return fmt.Errorf("unknown subcommand %q", args[0])
+		return fmt.Errorf("the configuration is not valid")
 	}
+
+	effectiveConfig, provenance, err := configCommand.configService.GetEffectiveConfig()
+	if err != nil {
+		return err
+	}
+
+	result.Sources = provenance.Sources()
+	for _, packageManager := range effectiveConfig.PackageManagers {
+		sourcedPackageManager := SourcedPackageManager{Name: packageManager.Name,
+			Source: provenance.PackageManagerSource(packageManager.Name), Packages: []SourcedPackage{}}
+		for _, configuredPackage := range packageManager.Packages {
+			sourcedPackageManager.Packages = append(sourcedPackageManager.Packages, SourcedPackage{
+				Name:    configuredPackage.Name,
+				Version: configuredPackage.Version,
+				Source:  provenance.PackageSource(packageManager.Name, configuredPackage.Name),
+			})
+		}
+		result.PackageManagers = append(result.PackageManagers, sourcedPackageManager)
+	}
+
+	for _, configuredFile := range effectiveConfig.Files {
+		result.Files = append(result.Files, SourcedEntry{SourcePath: configuredFile.SourcePath,
+			Source: provenance.FileSource(configuredFile.SourcePath)})
+	}
+
+	for _, configuredScript := range effectiveConfig.Scripts {
+		result.Scripts = append(result.Scripts, SourcedEntry{SourcePath: configuredScript.SourcePath,
+			Source: provenance.ScriptSource(configuredScript.SourcePath)})
+	}
+
+	return configCommand.output.Result(result)
 }
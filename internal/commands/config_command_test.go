@@ -0,0 +1,129 @@
+package commands_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+	. "github.com/colececil/familiar.sh/internal/commands"
+	"github.com/colececil/familiar.sh/internal/config"
+	"github.com/colececil/familiar.sh/internal/packagemanagers"
+	"github.com/colececil/familiar.sh/internal/test"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConfigCommand", func() {
+	var configService *config.ConfigService
+	var configLocation string
+	var stdout *bytes.Buffer
+	var configCommand *ConfigCommand
+
+	BeforeEach(func() {
+		configService, configLocation = newConfigService("packageManagers:\n  - name: scoop\n    packages:\n" +
+			"      - name: package1\n        version: 1.0.0\nfiles:\n  - sourcePath: .bashrc\n")
+		Expect(os.WriteFile(filepath.Join(filepath.Dir(configLocation), ".bashrc"), []byte{}, 0600)).To(Succeed())
+
+		packageManagerRegistry := packagemanagers.PackageManagerRegistry{
+			"scoop": test.NewPackageManagerDouble("scoop", packagemanagers.Capabilities{}),
+		}
+		var output *Output
+		output, stdout, _ = newOutputWithFormat(JsonOutputFormat)
+		configCommand = NewConfigCommand(configService, config.NewConfigValidator(configService,
+			packageManagerRegistry), packageManagerRegistry, output)
+	})
+
+	Describe("location", func() {
+		It("should print the documented structure in the JSON format", func() {
+			Expect(configCommand.Execute([]string{"location"}, FlagValues{})).To(Succeed())
+
+			Expect(stdout.String()).To(MatchJSON(`{"path": "` + configLocation + `", "store": "file", "url": "` +
+				configLocation + `", "source": "the default location"}`))
+		})
+	})
+
+	Describe("context", func() {
+		It("should print the documented structure in the JSON format, with passwords in URLs redacted", func() {
+			GinkgoT().Setenv("XDG_DATA_HOME", GinkgoT().TempDir())
+			xdg.Reload()
+			server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				_, _ = writer.Write([]byte("version: 1\n"))
+			}))
+			DeferCleanup(server.Close)
+			serverHost := strings.TrimPrefix(server.URL, "http://")
+
+			teamLocation := filepath.Join(GinkgoT().TempDir(), "team.yaml")
+			Expect(configService.AddConfigContext("team", "file", teamLocation, "")).To(Succeed())
+			Expect(configService.AddConfigContext("remote", "http", "http://user:secret@"+serverHost+"/familiar/",
+				"")).To(Succeed())
+			Expect(configService.SetConfigContextLayers("work", []string{"team", "default"})).To(Succeed())
+
+			Expect(configCommand.Execute([]string{"context"}, FlagValues{})).To(Succeed())
+
+			Expect(stdout.String()).To(MatchJSON(`{"current": "default", "contexts": [
+				{"name": "default", "store": "file", "url": "` + configLocation + `"},
+				{"name": "team", "store": "file", "url": "` + teamLocation + `"},
+				{"name": "remote", "store": "http", "url": "http://user:xxxxx@` + serverHost + `/familiar"},
+				{"name": "work", "layers": ["team", "default"], "writableLayer": "default"}
+			]}`))
+		})
+	})
+
+	Describe("validate", func() {
+		It("should print the documented structure in the JSON format", func() {
+			Expect(configCommand.Execute([]string{"validate"}, FlagValues{})).To(Succeed())
+
+			Expect(stdout.String()).To(MatchJSON(`{"valid": true, "diagnostics": [],
+				"sources": ["` + configLocation + `"],
+				"packageManagers": [{"name": "scoop", "source": "` + configLocation + `", "packages": [
+					{"name": "package1", "version": "1.0.0", "source": "` + configLocation + `"}]}],
+				"files": [{"sourcePath": ".bashrc", "source": "` + configLocation + `"}]}`))
+		})
+	})
+	Describe("diff", func() {
+		It("should print the documented structure in the JSON format", func() {
+			sharedConfig, err := configService.GetSharedConfig()
+			Expect(err).To(BeNil())
+			sharedConfig.Scripts = append(sharedConfig.Scripts, config.ConfiguredScript{SourcePath: "setup.sh"})
+			Expect(configService.SetConfig(sharedConfig)).To(Succeed())
+			snapshots, err := configService.GetConfigHistory()
+			Expect(err).To(BeNil())
+
+			Expect(configCommand.Execute([]string{"diff", snapshots[0].Id}, FlagValues{})).To(Succeed())
+
+			var result map[string]any
+			Expect(json.Unmarshal(stdout.Bytes(), &result)).To(Succeed())
+			Expect(result).To(HaveKeyWithValue("snapshot", snapshots[0].Id))
+			Expect(result).To(HaveKeyWithValue("changed", true))
+			Expect(result).To(HaveKeyWithValue("diff", ContainSubstring("+  - sourcePath: setup.sh")))
+		})
+	})
+
+	Describe("conflicts", func() {
+		var conflictCopyLocation string
+
+		BeforeEach(func() {
+			conflictCopyLocation = filepath.Join(filepath.Dir(configLocation), "config (1).yaml")
+			Expect(os.WriteFile(conflictCopyLocation, []byte("packageManagers:\n  - name: scoop\n    packages:\n"+
+				"      - name: package2\n        version: 2.0.0\n"), 0600)).To(Succeed())
+		})
+
+		It("should print the documented structure in the JSON format", func() {
+			Expect(configCommand.Execute([]string{"conflicts"}, FlagValues{})).To(Succeed())
+
+			Expect(stdout.String()).To(MatchJSON(`{"conflictCopies": ["` + conflictCopyLocation + `"]}`))
+		})
+
+		It("should print the documented structure of the merges in the JSON format", func() {
+			Expect(configCommand.Execute([]string{"conflicts", "merge"}, FlagValues{})).To(Succeed())
+
+			Expect(stdout.String()).To(MatchJSON(`{"merges": [{"path": "` + conflictCopyLocation + `",
+				"changes": ["added package \"package2\" under package manager \"scoop\""]}]}`))
+		})
+	})
+})
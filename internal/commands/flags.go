@@ -0,0 +1,251 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/colececil/familiar.sh/internal/collections"
+	"github.com/colececil/familiar.sh/internal/config"
+	"github.com/colececil/familiar.sh/internal/system"
+	"strings"
+)
+
+// A Flag describes a flag that a command accepts, such as "--exact". Flags can be given anywhere after the program
+// name, either as "--<name> <value>" or "--<name>=<value>". An argument of "--" ends the flags, so that any arguments
+// after it are passed to the command as they are, even if they start with "--".
+type Flag struct {
+	// Name is the name of the flag, without the leading "--".
+	Name string
+
+	// ValueName is the name of the flag's value, as it appears in the help output. If it is empty, the flag is a switch
+	// that doesn't take a value.
+	ValueName string
+
+	// Description is a short description of what the flag does.
+	Description string
+
+	// Subcommands contains the paths of the subcommands the flag can be given with, below the command and separated by
+	// spaces, such as "add" or "context layers". If it is empty, the flag can be given with the command and any of its
+	// subcommands.
+	Subcommands []string
+}
+
+// usage returns how the flag is given on the command line, such as "--output <format>".
+func (flag Flag) usage() string {
+	if flag.ValueName == "" {
+		return "--" + flag.Name
+	}
+	return fmt.Sprintf("--%s <%s>", flag.Name, flag.ValueName)
+}
+
+// FlagValues contains the values of the flags given on the command line, keyed by flag name. Switches that are given
+// have an empty value.
+type FlagValues map[string]string
+
+// IsSet returns whether the flag of the given name was given.
+func (flagValues FlagValues) IsSet(name string) bool {
+	_, isSet := flagValues[name]
+	return isSet
+}
+
+// String returns the value of the flag of the given name, or an empty string if it wasn't given.
+func (flagValues FlagValues) String(name string) string {
+	return flagValues[name]
+}
+
+// GlobalFlags contains the flags that can be given with every command.
+var GlobalFlags = []Flag{
+	{
+		Name:        "config",
+		ValueName:   "context or path",
+		Description: "Use the given context or config file for this command only.",
+	},
+	{
+		Name:        "output",
+		ValueName:   "format",
+		Description: "Print results in the given format (" + strings.Join(OutputFormatNames, ", ") + ").",
+	},
+	{
+		Name:        "verbose",
+		Description: "Print each shell command that is run, along with its output.",
+	},
+	{
+		Name:        "yes",
+		Description: "Answer yes to every question, and use the default answer for any other prompt.",
+	},
+}
+
+// GlobalOptions holds the values of the global flags. It is shared by the commands and the services that depend on
+// them, and is filled in with Apply once the command line has been parsed.
+type GlobalOptions struct {
+	// Config is the context name or config file path to use for this command only, or an empty string to use the
+	// usual location.
+	Config string
+
+	// Output is the format commands print their results in.
+	Output OutputFormat
+
+	// Verbose is whether shell commands are printed as they are run, along with their output.
+	Verbose bool
+
+	// Yes is whether questions are answered without waiting for input.
+	Yes bool
+}
+
+// NewGlobalOptions creates a new instance of GlobalOptions, holding the default values.
+func NewGlobalOptions() *GlobalOptions {
+	return &GlobalOptions{
+		Output: TextOutputFormat,
+	}
+}
+
+// Apply sets the global options from the given flag values.
+//
+// It takes the following parameters:
+//   - flagValues: The flag values parsed from the command line.
+func (globalOptions *GlobalOptions) Apply(flagValues FlagValues) error {
+	if flagValues.IsSet("config") {
+		if flagValues.String("config") == "" {
+			return fmt.Errorf("the --config flag requires a context name or config file path")
+		}
+		globalOptions.Config = flagValues.String("config")
+	}
+
+	if flagValues.IsSet("output") {
+		format, err := ParseOutputFormat(flagValues.String("output"))
+		if err != nil {
+			return err
+		}
+		globalOptions.Output = format
+	}
+
+	globalOptions.Verbose = flagValues.IsSet("verbose")
+	globalOptions.Yes = flagValues.IsSet("yes")
+	return nil
+}
+
+// NewIsVerboseFunc returns a function for determining whether the "--verbose" flag was given.
+func NewIsVerboseFunc(globalOptions *GlobalOptions) system.IsVerboseFunc {
+	return func() bool {
+		return globalOptions.Verbose
+	}
+}
+
+// NewConfigOverrideFunc returns a function for getting the value of the "--config" flag.
+func NewConfigOverrideFunc(globalOptions *GlobalOptions) config.ConfigOverrideFunc {
+	return func() string {
+		return globalOptions.Config
+	}
+}
+
+// FindCommandName returns the name of the command given on the command line, which is the first argument that isn't a
+// global flag or one of their values, along with the remaining arguments.
+//
+// It takes the following parameters:
+//   - args: The command line arguments, without the program name.
+func FindCommandName(args []string) (string, []string, error) {
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "--") {
+			return args[i], append(append([]string{}, args[:i]...), args[i+1:]...), nil
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimPrefix(args[i], "--"), "=")
+		flag := findFlag(GlobalFlags, name)
+		if flag == nil {
+			return "", nil, fmt.Errorf("unknown flag \"--%s\" (flags other than global flags must come after the "+
+				"command name)", name)
+		}
+		if flag.ValueName != "" && !hasValue {
+			i++
+		}
+	}
+
+	return "", nil, fmt.Errorf("no command specified")
+}
+
+// ParseFlags separates the given command line arguments into positional arguments and flag values, checking that each
+// flag is one of the given flags and that it is allowed with the subcommand the positional arguments lead to.
+//
+// It takes the following parameters:
+//   - args: The command line arguments, without the program name and the command name.
+//   - flags: The flags that can be given.
+//   - subcommands: The root of the command's tree of subcommands, which is used to find the path of the subcommand
+//     that is given. It is nil if the command has no subcommands.
+//
+// It returns the positional arguments and the flag values.
+func ParseFlags(args []string, flags []Flag, subcommands *Subcommand) ([]string, FlagValues, error) {
+	positionalArgs := []string{}
+	flagValues := FlagValues{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positionalArgs = append(positionalArgs, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "--") {
+			positionalArgs = append(positionalArgs, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		flag := findFlag(flags, name)
+		if flag == nil {
+			return nil, nil, fmt.Errorf("unknown flag \"--%s\"", name)
+		}
+		if flagValues.IsSet(name) {
+			return nil, nil, fmt.Errorf("the --%s flag is given more than once", name)
+		}
+
+		if flag.ValueName == "" {
+			if hasValue {
+				return nil, nil, fmt.Errorf("the --%s flag doesn't take a value", name)
+			}
+		} else if !hasValue {
+			if i+1 == len(args) {
+				return nil, nil, fmt.Errorf("the --%s flag requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		flagValues[name] = value
+	}
+
+	var subcommandPath []string
+	if subcommands != nil {
+		subcommandPath = subcommands.resolvePath(positionalArgs)
+	}
+	for _, flag := range flags {
+		if !flagValues.IsSet(flag.Name) || len(flag.Subcommands) == 0 {
+			continue
+		}
+		if !collections.Contains(flag.Subcommands, strings.Join(subcommandPath, " ")) {
+			return nil, nil, fmt.Errorf("the --%s flag can only be given with the following subcommands: %s",
+				flag.Name, strings.Join(flag.Subcommands, ", "))
+		}
+	}
+
+	return positionalArgs, flagValues, nil
+}
+
+// FormatFlags returns the help text listing the given flags, with one line for each flag.
+func FormatFlags(flags []Flag) string {
+	var usages, descriptions []string
+	for _, flag := range flags {
+		description := flag.Description
+		if len(flag.Subcommands) > 0 {
+			description += fmt.Sprintf(" (only for: %s)", strings.Join(flag.Subcommands, ", "))
+		}
+		usages = append(usages, flag.usage())
+		descriptions = append(descriptions, description)
+	}
+	return formatColumns(usages, descriptions)
+}
+
+// findFlag returns the flag of the given name from the given flags, or nil if there is none.
+func findFlag(flags []Flag, name string) *Flag {
+	for i := range flags {
+		if flags[i].Name == name {
+			return &flags[i]
+		}
+	}
+	return nil
+}
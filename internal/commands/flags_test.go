@@ -0,0 +1,120 @@
+package commands_test
+
+import (
+	"os"
+
+	. "github.com/colececil/familiar.sh/internal/commands"
+	"github.com/colececil/familiar.sh/internal/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Flags", func() {
+	Describe("FindCommandName", func() {
+		It("should skip global flags and their values", func() {
+			commandName, args, err := FindCommandName([]string{"--config", "team", "--output=json", "--yes", "attune",
+				"--plan"})
+			Expect(err).To(BeNil())
+			Expect(commandName).To(Equal("attune"))
+			Expect(args).To(Equal([]string{"--config", "team", "--output=json", "--yes", "--plan"}))
+		})
+
+		It("should return an error for unknown flags and missing commands", func() {
+			_, _, err := FindCommandName([]string{"--plan", "attune"})
+			Expect(err).ToNot(BeNil())
+
+			_, _, err = FindCommandName([]string{"--output", "json"})
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Describe("ParseFlags", func() {
+		flags := []Flag{
+			{Name: "output", ValueName: "format"},
+			{Name: "exact"},
+			{Name: "no-save", Subcommands: []string{"add", "remove"}},
+			{Name: "all", Subcommands: []string{"context layers"}},
+		}
+		run := func(args []string, flags FlagValues) error { return nil }
+		subcommands := &Subcommand{
+			Name: "config",
+			Subcommands: []*Subcommand{
+				{Name: "add", Run: run},
+				{Name: "remove", Run: run},
+				{Name: "status", Run: run},
+				{
+					Name: "context",
+					Run:  run,
+					Subcommands: []*Subcommand{
+						{Name: "add", Run: run},
+						{Name: "layers", Run: run},
+					},
+				},
+			},
+		}
+
+		It("should separate positional arguments from flags given anywhere", func() {
+			args, flagValues, err := ParseFlags([]string{"add", "--output", "json", "scoop", "--no-save", "--exact",
+				"--", "--not-a-flag"}, flags, subcommands)
+			Expect(err).To(BeNil())
+			Expect(args).To(Equal([]string{"add", "scoop", "--not-a-flag"}))
+			Expect(flagValues).To(Equal(FlagValues{"output": "json", "no-save": "", "exact": ""}))
+			Expect(flagValues.IsSet("no-save")).To(BeTrue())
+			Expect(flagValues.IsSet("plan")).To(BeFalse())
+		})
+
+		It("should accept values given with an equals sign", func() {
+			_, flagValues, err := ParseFlags([]string{"--output=yaml"}, flags, nil)
+			Expect(err).To(BeNil())
+			Expect(flagValues.String("output")).To(Equal("yaml"))
+		})
+
+		It("should return an error for invalid flags", func() {
+			invalidArgs := [][]string{
+				{"--plan"},
+				{"--output"},
+				{"--exact=true"},
+				{"--exact", "--exact"},
+				{"status", "--no-save"},
+				{"--no-save"},
+				{"context", "add", "--no-save"},
+				{"context", "--all"},
+				{"context", "add", "--all"},
+			}
+			for _, args := range invalidArgs {
+				_, _, err := ParseFlags(args, flags, subcommands)
+				Expect(err).ToNot(BeNil(), "%v", args)
+			}
+		})
+
+		It("should allow flags with the subcommands at the full paths they are declared for", func() {
+			args, flagValues, err := ParseFlags([]string{"context", "layers", "team", "--all"}, flags, subcommands)
+			Expect(err).To(BeNil())
+			Expect(args).To(Equal([]string{"context", "layers", "team"}))
+			Expect(flagValues.IsSet("all")).To(BeTrue())
+
+			_, _, err = ParseFlags([]string{"remove", "context", "--no-save"}, flags, subcommands)
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("GlobalOptions", func() {
+		It("should apply the global flags", func() {
+			globalOptions := NewGlobalOptions()
+			Expect(globalOptions.Output).To(Equal(TextOutputFormat))
+
+			Expect(globalOptions.Apply(FlagValues{"output": "json", "verbose": ""})).To(Succeed())
+			Expect(globalOptions.Output).To(Equal(JsonOutputFormat))
+			Expect(globalOptions.Verbose).To(BeTrue())
+			Expect(globalOptions.Yes).To(BeFalse())
+
+			Expect(globalOptions.Apply(FlagValues{"config": "team"})).To(Succeed())
+			Expect(globalOptions.Config).To(Equal("team"))
+			Expect(NewConfigOverrideFunc(globalOptions)()).To(Equal("team"))
+			Expect(os.Getenv(config.ConfigEnvironmentVariable)).To(BeEmpty())
+
+			Expect(globalOptions.Apply(FlagValues{"output": "xml"})).ToNot(Succeed())
+			Expect(globalOptions.Apply(FlagValues{"config": ""})).ToNot(Succeed())
+		})
+	})
+})
@@ -8,18 +8,23 @@ import (
 type HelpCommand struct {
 	// Commands is a slice containing all available commands.
 	Commands []Command
+	output   *Output
 }
 
 // NewHelpCommand creates a new instance of HelpCommand.
-func NewHelpCommand(versionCommand *VersionCommand, attuneCommand *AttuneCommand, configCommand *ConfigCommand,
-	packageCommand *PackageCommand) *HelpCommand {
+func NewHelpCommand(versionCommand *VersionCommand, initCommand *InitCommand, attuneCommand *AttuneCommand,
+	configCommand *ConfigCommand, packageCommand *PackageCommand, machineCommand *MachineCommand,
+	output *Output) *HelpCommand {
 	return &HelpCommand{
 		Commands: []Command{
 			versionCommand,
+			initCommand,
 			attuneCommand,
 			configCommand,
 			packageCommand,
+			machineCommand,
 		},
+		output: output,
 	}
 }
 
@@ -35,45 +40,98 @@ func (helpCommand *HelpCommand) Description() string {
 
 // Documentation returns detailed documentation for the command.
 func (helpCommand *HelpCommand) Documentation() string {
-	return "The `help` command lists information about all available Familiar CLI commands. If you provide a command name as an argument, it will display detailed documentation for that command.\n\nUsage:\n  familiar help\n  familiar help <command>"
+	return "The `help` command lists information about all available Familiar CLI commands. If you provide a command name as an argument, it will display detailed documentation for that command. If you also provide the names of subcommands, it will display detailed documentation for the subcommand, at any depth (for example, `familiar help config context add`)."
+}
+
+// Flags returns the flags the command accepts, in addition to the global flags.
+func (helpCommand *HelpCommand) Flags() []Flag {
+	return nil
+}
+
+// Subcommands returns the root of the command's tree of subcommands. The command has no subcommands of its own, but
+// the tree describes its usage.
+func (helpCommand *HelpCommand) Subcommands() *Subcommand {
+	return &Subcommand{
+		Name:          helpCommand.Name(),
+		Description:   helpCommand.Description(),
+		Documentation: helpCommand.Documentation(),
+		Arguments: []Argument{
+			{Name: "command", Description: "The name of the command to describe.", Optional: true},
+			{Name: "subcommand", Description: "The names of the subcommands leading to the subcommand to describe.",
+				Optional: true, Repeated: true},
+		},
+		Usages: []string{"", "<command>", "<command> <subcommand>..."},
+		Run: func(args []string, flags FlagValues) error {
+			if len(args) == 0 {
+				helpCommand.printCommands()
+				return nil
+			}
+			return helpCommand.printHelp(args[0], args[1:])
+		},
+	}
 }
 
 // Execute runs the command with the given arguments.
 //
 // It takes the following parameters:
-//   - args: A slice containing the arguments to pass in to the command.
+//   - args: A slice containing the positional arguments to pass in to the command, with any flags removed.
+//   - flags: The values of the flags given on the command line, including the global flags.
 //
 // If there is an error executing the command, Execute will return an error that can be displayed to the user.
-func (helpCommand *HelpCommand) Execute(args []string) error {
-	if len(args) == 0 {
-		// No command name was provided - display a list of all available commands.
-		fmt.Println(`Usage: familiar <command> [<args>]
+func (helpCommand *HelpCommand) Execute(args []string, flags FlagValues) error {
+	return helpCommand.Subcommands().Execute(args, flags)
+}
+
+// printCommands prints the list of all available commands, followed by the global flags.
+func (helpCommand *HelpCommand) printCommands() {
+	helpCommand.output.Println(`Usage: familiar [<global flags>] <command> [<args>]
 
 Available commands are listed below. Run "familiar help <command>" to get detailed documentation for a specific command.`)
-		fmt.Printf("  %-15s %s\n", helpCommand.Name(), helpCommand.Description())
-		for _, command := range helpCommand.Commands {
-			fmt.Printf("  %-15s %s\n", command.Name(), command.Description())
+	helpCommand.output.Printf("  %-15s %s\n", helpCommand.Name(), helpCommand.Description())
+	for _, command := range helpCommand.Commands {
+		helpCommand.output.Printf("  %-15s %s\n", command.Name(), command.Description())
+	}
+	helpCommand.output.Printf("\nGlobal flags, which can be given before or after the command name:\n%s\n",
+		FormatFlags(GlobalFlags))
+}
+
+// printHelp prints the detailed documentation for the command of the given name, or for one of its subcommands.
+//
+// It takes the following parameters:
+//   - name: The name of the command.
+//   - path: The names of the subcommands leading to the subcommand to describe. If it is empty, the command itself is
+//     described.
+func (helpCommand *HelpCommand) printHelp(name string, path []string) error {
+	for _, command := range append([]Command{helpCommand}, helpCommand.Commands...) {
+		if command.Name() != name {
+			continue
 		}
-		fmt.Println()
-		return nil
-	} else {
-		// A command name was provided - display detailed documentation for the command.
-		name := args[0]
-		isPresent := false
-		if name == "help" {
-			fmt.Printf("%s - %s\n\n%s\n", name, helpCommand.Description(), helpCommand.Documentation())
-		} else {
-			for _, command := range helpCommand.Commands {
-				if command.Name() == name {
-					isPresent = true
-					fmt.Printf("%s - %s\n\n%s\n", name, command.Description(), command.Documentation())
-					break
-				}
-			}
-			if !isPresent {
-				return fmt.Errorf("unknown command: %s", name)
+
+		commandWithSubcommands, hasSubcommands := command.(CommandWithSubcommands)
+		if !hasSubcommands {
+			if len(path) > 0 {
+				return fmt.Errorf("command %q has no subcommands", name)
 			}
+			helpCommand.printDocumentation(command)
+			return nil
 		}
+
+		subcommand, err := commandWithSubcommands.Subcommands().Find(path)
+		if err != nil {
+			return fmt.Errorf("%w (of command %q)", err, name)
+		}
+		helpCommand.output.Print(subcommand.HelpText(append([]string{name}, path...), command.Flags()))
+		helpCommand.output.Printf("\nRun \"familiar help\" for the global flags.\n")
 		return nil
 	}
+	return fmt.Errorf("unknown command: %s", name)
+}
+
+// printDocumentation prints the detailed documentation for the given command, followed by the flags it accepts.
+func (helpCommand *HelpCommand) printDocumentation(command Command) {
+	helpCommand.output.Printf("%s - %s\n\n%s\n", command.Name(), command.Description(), command.Documentation())
+	if len(command.Flags()) > 0 {
+		helpCommand.output.Printf("\nFlags:\n%s", FormatFlags(command.Flags()))
+	}
+	helpCommand.output.Printf("\nRun \"familiar help\" for the global flags.\n")
 }
@@ -0,0 +1,41 @@
+package commands_test
+
+import (
+	"bytes"
+
+	. "github.com/colececil/familiar.sh/internal/commands"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HelpCommand", func() {
+	var stdout *bytes.Buffer
+	var helpCommand *HelpCommand
+
+	BeforeEach(func() {
+		var output *Output
+		output, stdout, _ = newOutputWithFormat(TextOutputFormat)
+		helpCommand = NewHelpCommand(nil, nil, nil, nil, nil, NewMachineCommand(nil, output), output)
+	})
+
+	It("should describe its own usage from its tree of subcommands", func() {
+		Expect(helpCommand.Execute([]string{"help"}, FlagValues{})).To(Succeed())
+
+		Expect(stdout.String()).To(ContainSubstring("Usage:\n  familiar help\n  familiar help <command>\n" +
+			"  familiar help <command> <subcommand>...\n"))
+		Expect(helpCommand.Documentation()).NotTo(ContainSubstring("Usage:"))
+	})
+
+	It("should describe subcommands at any depth", func() {
+		Expect(helpCommand.Execute([]string{"machine", "tags", "add"}, FlagValues{})).To(Succeed())
+
+		Expect(stdout.String()).To(HavePrefix("machine tags add - Add a tag to the current machine.\n\n" +
+			"Usage:\n  familiar machine tags add <tag>\n"))
+	})
+
+	It("should return an error for unknown commands and subcommands", func() {
+		Expect(helpCommand.Execute([]string{"bogus"}, FlagValues{})).To(MatchError("unknown command: bogus"))
+		Expect(helpCommand.Execute([]string{"machine", "bogus"}, FlagValues{})).To(MatchError(
+			"unknown subcommand: bogus (of command \"machine\")"))
+	})
+})
@@ -0,0 +1,62 @@
+package commands_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	. "github.com/colececil/familiar.sh/internal/commands"
+	"github.com/colececil/familiar.sh/internal/config"
+	"github.com/colececil/familiar.sh/internal/system"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// newConfigService creates a ConfigService with the real config stores, with the XDG config and state directories
+// pointed at new temporary directories and the shared config location set to a file in another temporary directory,
+// which is written with the given contents. It returns the ConfigService and the shared config location.
+func newConfigService(contents string) (*config.ConfigService, string) {
+	GinkgoT().Setenv("XDG_CONFIG_HOME", GinkgoT().TempDir())
+	GinkgoT().Setenv("XDG_STATE_HOME", GinkgoT().TempDir())
+	xdg.Reload()
+	DeferCleanup(xdg.Reload)
+
+	messageWriterFunc := func() io.Writer { return GinkgoWriter }
+	fileConfigStore := config.NewFileConfigStore()
+	shellCommandService := system.NewShellCommandService(system.NewRunShellCommandFunc(), func() bool { return false },
+		messageWriterFunc)
+	gitConfigStore := config.NewGitConfigStore(shellCommandService, fileConfigStore, messageWriterFunc)
+	configService := config.NewConfigService(config.NewConfigStoreRegistry(fileConfigStore, gitConfigStore,
+		config.NewHttpConfigStore(messageWriterFunc)), messageWriterFunc, func() string { return "" })
+
+	configLocation := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+	Expect(os.WriteFile(configLocation, []byte(fmt.Sprintf("version: %d\n%s", config.CurrentConfigVersion,
+		contents)), 0600)).To(Succeed())
+	Expect(configService.SetConfigLocation(configLocation)).To(Succeed())
+	return configService, configLocation
+}
+
+// newOutput creates an Output in the text format that prints to the GinkgoWriter, so that what is printed is only
+// shown when a test fails.
+func newOutput() *Output {
+	return NewOutput(NewGlobalOptions(), OutputWriters{Stdout: GinkgoWriter, Stderr: GinkgoWriter})
+}
+
+// newOutputWithFormat creates an Output in the given format that prints to buffers, and returns it along with the
+// buffers for standard output and standard error.
+func newOutputWithFormat(format OutputFormat) (*Output, *bytes.Buffer, *bytes.Buffer) {
+	globalOptions := NewGlobalOptions()
+	globalOptions.Output = format
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	return NewOutput(globalOptions, OutputWriters{Stdout: stdout, Stderr: stderr}), stdout, stderr
+}
+
+// readConfigFile returns the contents of the config file at the given location.
+func readConfigFile(configLocation string) string {
+	contents, err := os.ReadFile(configLocation)
+	Expect(err).NotTo(HaveOccurred())
+	return string(contents)
+}
@@ -0,0 +1,467 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/colececil/familiar.sh/internal/config"
+	"github.com/colececil/familiar.sh/internal/packagemanagers"
+	"github.com/colececil/familiar.sh/internal/system"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// dotfilesDirectoryName is the directory beside the shared config file that "familiar init" copies dotfiles into.
+const dotfilesDirectoryName = "dotfiles"
+
+// commonDotfiles are the dotfiles in the home directory that "familiar init" offers to import.
+var commonDotfiles = []string{".bashrc", ".bash_profile", ".zshrc", ".profile", ".gitconfig", ".vimrc", ".tmux.conf",
+	".inputrc"}
+
+// cloudDriveDirectories are the directories in the home directory where cloud drives commonly sync files. The first
+// one that exists is suggested as the place to keep the shared config file.
+var cloudDriveDirectories = []string{"OneDrive", "Dropbox", "Google Drive",
+	filepath.Join("Library", "Mobile Documents", "com~apple~CloudDocs")}
+
+// InitCommand represents the "init" command.
+type InitCommand struct {
+	configService          *config.ConfigService
+	configValidator        *config.ConfigValidator
+	packageManagerRegistry packagemanagers.PackageManagerRegistry
+	operatingSystemService *system.OperatingSystemService
+	packageCommand         *PackageCommand
+	globalOptions          *GlobalOptions
+	output                 *Output
+	input                  *bufio.Reader
+}
+
+// InputReader is where commands read the answers to their questions from.
+type InputReader io.Reader
+
+// NewInputReader returns an InputReader that reads from standard input.
+func NewInputReader() InputReader {
+	return os.Stdin
+}
+
+// NewInitCommand creates a new instance of InitCommand.
+func NewInitCommand(configService *config.ConfigService, configValidator *config.ConfigValidator,
+	packageManagerRegistry packagemanagers.PackageManagerRegistry,
+	operatingSystemService *system.OperatingSystemService, packageCommand *PackageCommand,
+	globalOptions *GlobalOptions, output *Output, inputReader InputReader) *InitCommand {
+	return &InitCommand{
+		configService:          configService,
+		configValidator:        configValidator,
+		packageManagerRegistry: packageManagerRegistry,
+		operatingSystemService: operatingSystemService,
+		packageCommand:         packageCommand,
+		globalOptions:          globalOptions,
+		output:                 output,
+		input:                  bufio.NewReader(inputReader),
+	}
+}
+
+// Name returns the name of the command, as it appears on the command line while being used.
+func (initCommand *InitCommand) Name() string {
+	return "init"
+}
+
+// Description returns a short description of the command.
+func (initCommand *InitCommand) Description() string {
+	return "Set up Familiar.sh on this machine for the first time."
+}
+
+// Documentation returns detailed documentation for the command.
+func (initCommand *InitCommand) Documentation() string {
+	return `The "init" command walks through setting up Familiar.sh on the current machine. It does the following:
+
+1. Detects the operating system, and which of the supported package managers are installed.
+2. Asks where to keep the shared configuration file, suggesting a folder synced by a cloud drive if one is found. A path can be entered, or a config store and URL (for example, "git <repository>" or "http <url>"). If a location is given as an argument, it is used without asking. If the location has already been set, it can be kept.
+3. Adds the installed package managers to the shared configuration, and offers to import the packages currently installed with each of them.
+4. Offers to import common dotfiles from the home directory (such as ".bashrc" and ".gitconfig"). They are copied into a "dotfiles" directory beside the shared configuration file, and added to its files. This is only offered when the shared configuration file is kept in a local folder (the "file" store), since the "git" and "http" stores only sync the configuration file itself.
+5. Checks that the resulting configuration is valid.
+
+Running "familiar init" again on a machine that is already set up only adds what is missing. If there is no input to answer a question with (for example, when input isn't from a terminal), the default answer is used. With the global "--yes" flag, every question is answered with "yes" or its default answer without waiting for input.
+
+Usage:
+  familiar init
+  familiar init <location>`
+}
+
+// Flags returns the flags the command accepts, in addition to the global flags.
+func (initCommand *InitCommand) Flags() []Flag {
+	return nil
+}
+
+// Execute runs the command with the given arguments.
+//
+// It takes the following parameters:
+//   - args: A slice containing the positional arguments to pass in to the command, with any flags removed.
+//   - flags: The values of the flags given on the command line, including the global flags.
+//
+// If there is an error executing the command, Execute will return an error that can be displayed to the user.
+func (initCommand *InitCommand) Execute(args []string, flags FlagValues) error {
+	if len(args) > 1 {
+		return fmt.Errorf("wrong number of arguments")
+	}
+
+	initCommand.output.Println("Setting up Familiar.sh on this machine.")
+	initCommand.output.Printf("Operating system: %s\n", initCommand.operatingSystemService.Name())
+
+	installedPackageManagers, err := initCommand.detectPackageManagers()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		err = initCommand.setConfigLocation(args[0])
+	} else {
+		err = initCommand.chooseConfigLocation()
+	}
+	if err != nil {
+		return err
+	}
+
+	transaction := initCommand.configService.BeginTransaction()
+	err = initCommand.populateConfig(transaction, installedPackageManagers)
+	if err = transaction.Finish(err); err != nil {
+		return err
+	}
+
+	diagnostics, err := initCommand.configValidator.Validate()
+	if err != nil {
+		return err
+	}
+	for _, diagnostic := range diagnostics {
+		initCommand.output.Println(diagnostic.String())
+	}
+	if config.HasErrors(diagnostics) {
+		return fmt.Errorf("the configuration is not valid. Fix the problems above, and then run \"familiar config " +
+			"validate\" to check it again")
+	}
+
+	initCommand.output.Println("Familiar.sh is set up. On your other machines, run \"familiar init\" with the same " +
+		"config file location, and then \"familiar attune\" to make them match this one.")
+	return nil
+}
+
+// detectPackageManagers prints which of the supported package managers are installed on the current machine, and
+// returns the names of those that are.
+func (initCommand *InitCommand) detectPackageManagers() ([]string, error) {
+	var installedPackageManagers []string
+
+	initCommand.output.Println("Package managers:")
+	for _, packageManagerName := range initCommand.packageManagerRegistry.GetPackageManagerNames() {
+		packageManager, err := initCommand.packageManagerRegistry.GetPackageManager(packageManagerName)
+		if err != nil {
+			return nil, err
+		}
+
+		if !packageManager.IsSupported() {
+			initCommand.output.Printf("- %s: not supported on this operating system\n", packageManagerName)
+			continue
+		}
+
+		isInstalled, err := packageManager.IsInstalled()
+		if err != nil {
+			return nil, err
+		}
+
+		if isInstalled {
+			initCommand.output.Printf("- %s: installed\n", packageManagerName)
+			installedPackageManagers = append(installedPackageManagers, packageManagerName)
+		} else {
+			initCommand.output.Printf("- %s: not installed (Familiar.sh can install it during \"familiar attune\")\n",
+				packageManagerName)
+		}
+	}
+
+	return installedPackageManagers, nil
+}
+
+// chooseConfigLocation asks where to keep the shared config file, unless its location has already been set and the
+// user chooses to keep it.
+func (initCommand *InitCommand) chooseConfigLocation() error {
+	if configLocation, err := initCommand.configService.GetConfigLocation(); err == nil && configLocation != "" {
+		if initCommand.confirm(fmt.Sprintf("Keep using the config file at \"%s\"?", configLocation), true) {
+			return nil
+		}
+	}
+
+	defaultLocation, err := defaultConfigLocation()
+	if err != nil {
+		return err
+	}
+
+	location := initCommand.prompt("Where should the shared config file be kept? Enter a path (ideally in a folder "+
+		"synced by a cloud drive), or a config store and URL (for example, \"git <repository>\").", defaultLocation)
+	return initCommand.setConfigLocation(location)
+}
+
+// setConfigLocation sets the location of the shared config file to the given location, which is either a path or a
+// config store name followed by a URL and an optional path within it. If a path is given and its directory doesn't
+// exist, it is created.
+func (initCommand *InitCommand) setConfigLocation(location string) error {
+	fields := strings.Fields(location)
+	if len(fields) == 2 || len(fields) == 3 {
+		for _, configStoreName := range initCommand.configService.GetConfigStoreNames() {
+			if fields[0] != configStoreName {
+				continue
+			}
+
+			path := ""
+			if len(fields) == 3 {
+				path = fields[2]
+			}
+			return initCommand.configService.SetConfigStoreLocation(configStoreName, fields[1], path)
+		}
+	}
+
+	path, err := expandHomeDirectory(location)
+	if err != nil {
+		return err
+	}
+
+	if ext := filepath.Ext(path); ext != ".yml" && ext != ".yaml" {
+		path = filepath.Join(path, "config.yaml")
+	}
+
+	directory := filepath.Dir(path)
+	if _, err = os.Stat(directory); os.IsNotExist(err) {
+		if err = os.MkdirAll(directory, 0700); err != nil {
+			return fmt.Errorf("unable to create directory \"%s\": %w", directory, err)
+		}
+		initCommand.output.Printf("Created directory \"%s\".\n", directory)
+	}
+
+	return initCommand.configService.SetConfigLocation(path)
+}
+
+// populateConfig adds the installed package managers to the shared config, and offers to import their installed
+// packages and the common dotfiles found in the home directory.
+func (initCommand *InitCommand) populateConfig(transaction *config.ConfigTransaction,
+	installedPackageManagers []string) error {
+	sharedConfig, err := transaction.Config()
+	if err != nil {
+		return err
+	}
+
+	for _, packageManagerName := range installedPackageManagers {
+		if !hasPackageManager(sharedConfig, packageManagerName) {
+			name := packageManagerName
+			err := transaction.Mutate(func(sharedConfig *config.Config) error {
+				return sharedConfig.AddPackageManager(name, initCommand.packageManagerRegistry)
+			})
+			if err != nil {
+				return err
+			}
+			initCommand.output.Printf("Added package manager \"%s\" to the configuration.\n", packageManagerName)
+		}
+
+		if initCommand.confirm(fmt.Sprintf("Import the packages currently installed with %s?", packageManagerName),
+			true) {
+			if err := initCommand.packageCommand.importPackagesFromPackageManager(transaction,
+				packageManagerName); err != nil {
+				return err
+			}
+		}
+	}
+
+	return initCommand.importDotfiles(transaction)
+}
+
+// importDotfiles offers to import the common dotfiles found in the home directory that aren't already in the shared
+// config. Each one is copied into the dotfiles directory beside the shared config file, and added to its files with
+// the original file as its destination. Dotfiles are only imported when the shared config file is kept by the file
+// store, since the other stores wouldn't sync the copies to the other machines.
+func (initCommand *InitCommand) importDotfiles(transaction *config.ConfigTransaction) error {
+	homeDirectory, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("unable to find the home directory: %w", err)
+	}
+
+	location, err := initCommand.configService.GetConfigStoreLocation()
+	if err != nil {
+		return err
+	}
+	if location.Store != config.FileConfigStoreName {
+		initCommand.output.Printf("Skipping importing dotfiles, since the \"%s\" config store only syncs the config "+
+			"file itself. To share dotfiles, add them to the config store yourself and list them in the config file's "+
+			"files.\n", location.Store)
+		return nil
+	}
+
+	sharedConfig, err := transaction.Config()
+	if err != nil {
+		return err
+	}
+
+	var foundDotfiles []string
+	for _, dotfile := range commonDotfiles {
+		fileInfo, err := os.Stat(filepath.Join(homeDirectory, dotfile))
+		if err != nil || !fileInfo.Mode().IsRegular() {
+			continue
+		}
+		if !hasFile(sharedConfig, dotfilesDirectoryName+"/"+dotfile) {
+			foundDotfiles = append(foundDotfiles, dotfile)
+		}
+	}
+
+	if len(foundDotfiles) == 0 {
+		return nil
+	}
+
+	initCommand.output.Println("Dotfiles found in the home directory:")
+	for _, dotfile := range foundDotfiles {
+		initCommand.output.Printf("- %s\n", dotfile)
+	}
+	if !initCommand.confirm("Import these dotfiles into the shared configuration?", false) {
+		return nil
+	}
+
+	dotfilesDirectory := filepath.Join(filepath.Dir(location.Path), dotfilesDirectoryName)
+	if err = os.MkdirAll(dotfilesDirectory, 0700); err != nil {
+		return err
+	}
+
+	for _, dotfile := range foundDotfiles {
+		destinationPath := filepath.Join(dotfilesDirectory, dotfile)
+		if _, err := os.Stat(destinationPath); os.IsNotExist(err) {
+			if err = copyFile(filepath.Join(homeDirectory, dotfile), destinationPath); err != nil {
+				return err
+			}
+		}
+
+		configuredFile := config.ConfiguredFile{
+			SourcePath:      dotfilesDirectoryName + "/" + dotfile,
+			DestinationPath: "~/" + dotfile,
+		}
+		err := transaction.Mutate(func(sharedConfig *config.Config) error {
+			sharedConfig.Files = append(sharedConfig.Files, configuredFile)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		initCommand.output.Printf("Imported \"%s\".\n", dotfile)
+	}
+
+	return nil
+}
+
+// prompt prints the given question and returns the answer the user enters. If nothing is entered, there is no more
+// input, or the "--yes" flag was given, the given default answer is returned.
+func (initCommand *InitCommand) prompt(question string, defaultAnswer string) string {
+	if defaultAnswer != "" {
+		initCommand.output.Printf("%s [%s]: ", question, defaultAnswer)
+	} else {
+		initCommand.output.Printf("%s: ", question)
+	}
+
+	if initCommand.globalOptions.Yes {
+		initCommand.output.Println(defaultAnswer)
+		return defaultAnswer
+	}
+
+	answer, err := initCommand.input.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if err != nil && err != io.EOF {
+		answer = ""
+	}
+	if err == io.EOF && answer == "" {
+		initCommand.output.Println("")
+	}
+
+	if answer == "" {
+		return defaultAnswer
+	}
+	return answer
+}
+
+// confirm asks the given yes or no question, and returns the answer. If nothing is entered, or there is no more input,
+// the given default answer is returned. If the "--yes" flag was given, the question is answered with "yes".
+func (initCommand *InitCommand) confirm(question string, defaultAnswer bool) bool {
+	options := "y/N"
+	if defaultAnswer {
+		options = "Y/n"
+	}
+
+	if initCommand.globalOptions.Yes {
+		initCommand.output.Printf("%s (%s): y\n", question, options)
+		return true
+	}
+
+	for {
+		switch strings.ToLower(initCommand.prompt(fmt.Sprintf("%s (%s)", question, options), "")) {
+		case "":
+			return defaultAnswer
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		default:
+			initCommand.output.Println("Please answer \"y\" or \"n\".")
+		}
+	}
+}
+
+// defaultConfigLocation returns the location suggested for the shared config file: a "Familiar" directory in the first
+// cloud drive directory found in the home directory, or a "familiar" directory in the home directory if none is
+// found.
+func defaultConfigLocation() (string, error) {
+	homeDirectory, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to find the home directory: %w", err)
+	}
+
+	for _, cloudDriveDirectory := range cloudDriveDirectories {
+		directory := filepath.Join(homeDirectory, cloudDriveDirectory)
+		if fileInfo, err := os.Stat(directory); err == nil && fileInfo.IsDir() {
+			return filepath.Join(directory, "Familiar", "config.yaml"), nil
+		}
+	}
+
+	return filepath.Join(homeDirectory, "familiar", "config.yaml"), nil
+}
+
+// expandHomeDirectory replaces a leading "~" in the given path with the home directory.
+func expandHomeDirectory(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~\\") {
+		return path, nil
+	}
+
+	homeDirectory, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to find the home directory: %w", err)
+	}
+	return filepath.Join(homeDirectory, path[1:]), nil
+}
+
+// copyFile copies the file at the given source path to the given destination path.
+func copyFile(sourcePath string, destinationPath string) error {
+	contents, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(destinationPath, contents, 0600)
+}
+
+// hasPackageManager returns whether the given Config has the package manager of the given name.
+func hasPackageManager(sharedConfig *config.Config, packageManagerName string) bool {
+	for _, configuredPackageManager := range sharedConfig.PackageManagers {
+		if configuredPackageManager.Name == packageManagerName {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFile returns whether the given Config has a file with the given source path.
+func hasFile(sharedConfig *config.Config, sourcePath string) bool {
+	for _, configuredFile := range sharedConfig.Files {
+		if configuredFile.SourcePath == sourcePath {
+			return true
+		}
+	}
+	return false
+}
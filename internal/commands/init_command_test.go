@@ -0,0 +1,172 @@
+package commands_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+	. "github.com/colececil/familiar.sh/internal/commands"
+	"github.com/colececil/familiar.sh/internal/config"
+	"github.com/colececil/familiar.sh/internal/packagemanagers"
+	"github.com/colececil/familiar.sh/internal/system"
+	"github.com/colececil/familiar.sh/internal/test"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("InitCommand", func() {
+	var homeDirectory string
+	var packageManagerDouble *test.PackageManagerDouble
+	var configService *config.ConfigService
+	var globalOptions *GlobalOptions
+
+	// newInitCommand creates an InitCommand that reads the answers to its questions from the given input.
+	newInitCommand := func(input string) *InitCommand {
+		packageManagerRegistry := packagemanagers.PackageManagerRegistry{"scoop": packageManagerDouble}
+		output := newOutput()
+		return NewInitCommand(configService, config.NewConfigValidator(configService, packageManagerRegistry),
+			packageManagerRegistry, system.NewOperatingSystemService(system.NewIsWindowsFunc()),
+			NewPackageCommand(configService, packageManagerRegistry, output), globalOptions, output,
+			strings.NewReader(input))
+	}
+
+	// getConfigLocation returns the location of the shared config file.
+	getConfigLocation := func() string {
+		configLocation, err := configService.GetConfigLocation()
+		Expect(err).NotTo(HaveOccurred())
+		return configLocation
+	}
+
+	BeforeEach(func() {
+		homeDirectory = GinkgoT().TempDir()
+		GinkgoT().Setenv("HOME", homeDirectory)
+		Expect(os.WriteFile(filepath.Join(homeDirectory, ".bashrc"), []byte("alias ll='ls -l'\n"),
+			0600)).To(Succeed())
+
+		packageManagerDouble = test.NewPackageManagerDouble("scoop", packagemanagers.Capabilities{})
+		packageManagerDouble.InstalledVersions["package1"] = "1.0.0"
+		configService, _ = newConfigService("")
+		globalOptions = NewGlobalOptions()
+	})
+
+	Describe("setting the config location", func() {
+		It("should expand \"~\", add \"config.yaml\" to directories, and create the directory", func() {
+			Expect(newInitCommand("n\n").Execute([]string{"~/Familiar"}, FlagValues{})).To(Succeed())
+
+			Expect(getConfigLocation()).To(Equal(filepath.Join(homeDirectory, "Familiar", "config.yaml")))
+			Expect(filepath.Join(homeDirectory, "Familiar")).To(BeADirectory())
+		})
+
+		It("should use a path ending in \".yaml\" or \".yml\" as the config file", func() {
+			Expect(newInitCommand("n\n").Execute([]string{"~/Familiar/shared.yml"}, FlagValues{})).To(Succeed())
+
+			Expect(getConfigLocation()).To(Equal(filepath.Join(homeDirectory, "Familiar", "shared.yml")))
+		})
+
+		It("should use a config store and URL, and not offer to import dotfiles for stores other than \"file\"",
+			func() {
+				if _, err := exec.LookPath("git"); err != nil {
+					Skip("git is not installed")
+				}
+				for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+					GinkgoT().Setenv(name, "Test")
+				}
+				for _, name := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+					GinkgoT().Setenv(name, "test@example.com")
+				}
+				GinkgoT().Setenv("XDG_DATA_HOME", GinkgoT().TempDir())
+				xdg.Reload()
+
+				remoteDirectory := filepath.Join(GinkgoT().TempDir(), "remote.git")
+				otherDirectory := filepath.Join(GinkgoT().TempDir(), "other")
+				for _, args := range [][]string{
+					{"init", "-q", "--bare", "-b", "main", remoteDirectory},
+					{"clone", "-q", remoteDirectory, otherDirectory},
+				} {
+					output, err := exec.Command("git", args...).CombinedOutput()
+					Expect(err).NotTo(HaveOccurred(), string(output))
+				}
+				Expect(os.WriteFile(filepath.Join(otherDirectory, "config.yaml"), []byte("version: 1\n"),
+					0600)).To(Succeed())
+				for _, args := range [][]string{{"add", "config.yaml"}, {"commit", "-q", "-m", "Add config"},
+					{"push", "-q"}} {
+					output, err := exec.Command("git", append([]string{"-C", otherDirectory}, args...)...).
+						CombinedOutput()
+					Expect(err).NotTo(HaveOccurred(), string(output))
+				}
+				globalOptions.Yes = true
+
+				Expect(newInitCommand("").Execute([]string{"git " + remoteDirectory}, FlagValues{})).To(Succeed())
+
+				location, err := configService.GetConfigStoreLocation()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(location.Store).To(Equal("git"))
+				Expect(location.Url).To(Equal(remoteDirectory))
+
+				sharedConfig, err := configService.GetSharedConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sharedConfig.Files).To(BeEmpty())
+				Expect(filepath.Join(filepath.Dir(location.Path), "dotfiles")).NotTo(BeADirectory())
+			})
+	})
+
+	Describe("populating the config", func() {
+		It("should add the installed package managers and import their packages and the dotfiles when asked to",
+			func() {
+				Expect(newInitCommand("y\ny\ny\n").Execute([]string{}, FlagValues{})).To(Succeed())
+
+				sharedConfig, err := configService.GetSharedConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sharedConfig.HasPackage("scoop", "package1")).To(BeTrue())
+				Expect(sharedConfig.Files).To(Equal([]config.ConfiguredFile{
+					{SourcePath: "dotfiles/.bashrc", DestinationPath: "~/.bashrc"},
+				}))
+
+				contents, err := os.ReadFile(filepath.Join(filepath.Dir(getConfigLocation()), "dotfiles", ".bashrc"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(Equal("alias ll='ls -l'\n"))
+			})
+
+		It("should only add what is missing when run again", func() {
+			Expect(newInitCommand("y\ny\ny\n").Execute([]string{}, FlagValues{})).To(Succeed())
+			contents := readConfigFile(getConfigLocation())
+
+			Expect(newInitCommand("y\ny\ny\n").Execute([]string{}, FlagValues{})).To(Succeed())
+
+			Expect(readConfigFile(getConfigLocation())).To(Equal(contents))
+		})
+	})
+
+	Describe("asking questions", func() {
+		It("should use the default answers when there is no more input", func() {
+			Expect(newInitCommand("").Execute([]string{}, FlagValues{})).To(Succeed())
+
+			sharedConfig, err := configService.GetSharedConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sharedConfig.HasPackage("scoop", "package1")).To(BeTrue())
+			Expect(sharedConfig.Files).To(BeEmpty())
+		})
+
+		It("should ask again until \"y\" or \"n\" is entered", func() {
+			Expect(newInitCommand("y\ny\nmaybe\nn\n").Execute([]string{}, FlagValues{})).To(Succeed())
+
+			sharedConfig, err := configService.GetSharedConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sharedConfig.HasPackage("scoop", "package1")).To(BeTrue())
+			Expect(sharedConfig.Files).To(BeEmpty())
+		})
+
+		It("should answer every question with \"yes\" without reading input when the --yes flag is given", func() {
+			globalOptions.Yes = true
+
+			Expect(newInitCommand("n\nn\nn\n").Execute([]string{}, FlagValues{})).To(Succeed())
+
+			sharedConfig, err := configService.GetSharedConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sharedConfig.HasPackage("scoop", "package1")).To(BeTrue())
+			Expect(sharedConfig.Files).To(HaveLen(1))
+		})
+	})
+})
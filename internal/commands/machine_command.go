@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"github.com/colececil/familiar.sh/internal/config"
+	"strings"
+)
+
+// MachineCommand represents the "machine" command.
+type MachineCommand struct {
+	configService *config.ConfigService
+	output        *Output
+}
+
+// MachineTagsResult is the result of "familiar machine tags".
+type MachineTagsResult struct {
+	// Tags are the tags of the current machine.
+	Tags []string `yaml:"tags"`
+}
+
+// Text returns the result as human-readable text, with one tag on each line.
+func (machineTagsResult MachineTagsResult) Text() string {
+	if len(machineTagsResult.Tags) == 0 {
+		return "The current machine has no tags.\n"
+	}
+	return strings.Join(machineTagsResult.Tags, "\n") + "\n"
+}
+
+// NewMachineCommand creates a new instance of MachineCommand.
+func NewMachineCommand(configService *config.ConfigService, output *Output) *MachineCommand {
+	return &MachineCommand{
+		configService: configService,
+		output:        output,
+	}
+}
+
+// Name returns the name of the command, as it appears on the command line while being used.
+func (machineCommand *MachineCommand) Name() string {
+	return "machine"
+}
+
+// Description returns a short description of the command.
+func (machineCommand *MachineCommand) Description() string {
+	return "Manage settings that only apply to the current machine."
+}
+
+// Documentation returns detailed documentation for the command.
+func (machineCommand *MachineCommand) Documentation() string {
+	return `The "machine" command manages settings that are stored locally on the current machine, rather than in the shared configuration file.
+
+Packages, files, and scripts in the shared configuration can be given a list of tags (for example, "tags: [work, gaming]"). When "familiar attune" is run, entries without any tags are always applied, while entries with tags are only applied if the current machine has at least one of them.
+
+With "--output json" or "--output yaml", "tags" prints an object with the list of "tags".`
+}
+
+// Flags returns the flags the command accepts, in addition to the global flags.
+func (machineCommand *MachineCommand) Flags() []Flag {
+	return nil
+}
+
+// Subcommands returns the root of the command's tree of subcommands.
+func (machineCommand *MachineCommand) Subcommands() *Subcommand {
+	tagArgument := Argument{Name: "tag", Description: "The tag, such as \"work\", \"gaming\", or \"server\"."}
+
+	return &Subcommand{
+		Name:          machineCommand.Name(),
+		Description:   machineCommand.Description(),
+		Documentation: machineCommand.Documentation(),
+		Subcommands: []*Subcommand{
+			{
+				Name:        "tags",
+				Description: "List the current machine's tags.",
+				Run: func(args []string, flags FlagValues) error {
+					return machineCommand.listTags()
+				},
+				Subcommands: []*Subcommand{
+					{
+						Name:        "list",
+						Description: "List the current machine's tags.",
+						Run: func(args []string, flags FlagValues) error {
+							return machineCommand.listTags()
+						},
+					},
+					{
+						Name:        "add",
+						Description: "Add a tag to the current machine.",
+						Arguments:   []Argument{tagArgument},
+						Run: func(args []string, flags FlagValues) error {
+							if err := machineCommand.configService.AddMachineTag(args[0]); err != nil {
+								return err
+							}
+							machineCommand.output.Println("Tag added.")
+							return nil
+						},
+					},
+					{
+						Name:        "remove",
+						Description: "Remove a tag from the current machine.",
+						Arguments:   []Argument{tagArgument},
+						Run: func(args []string, flags FlagValues) error {
+							if err := machineCommand.configService.RemoveMachineTag(args[0]); err != nil {
+								return err
+							}
+							machineCommand.output.Println("Tag removed.")
+							return nil
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Execute runs the command with the given arguments.
+//
+// It takes the following parameters:
+//   - args: A slice containing the positional arguments to pass in to the command, with any flags removed.
+//   - flags: The values of the flags given on the command line, including the global flags.
+//
+// If there is an error executing the command, Execute will return an error that can be displayed to the user.
+func (machineCommand *MachineCommand) Execute(args []string, flags FlagValues) error {
+	return machineCommand.Subcommands().Execute(args, flags)
+}
+
+// listTags prints the tags of the current machine.
+func (machineCommand *MachineCommand) listTags() error {
+	tags, err := machineCommand.configService.GetMachineTags()
+	if err != nil {
+		return err
+	}
+	return machineCommand.output.Result(MachineTagsResult{Tags: append([]string{}, tags...)})
+}
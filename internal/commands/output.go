@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/colececil/familiar.sh/internal/system"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+)
+
+// OutputFormat is the format commands print their results in. It is chosen with the global "--output" flag.
+type OutputFormat string
+
+const (
+	// TextOutputFormat prints results as human-readable text. It is the default.
+	TextOutputFormat OutputFormat = "text"
+
+	// JsonOutputFormat prints results as a single JSON document.
+	JsonOutputFormat OutputFormat = "json"
+
+	// YamlOutputFormat prints results as a single YAML document.
+	YamlOutputFormat OutputFormat = "yaml"
+)
+
+// OutputFormatNames contains the names of the supported output formats.
+var OutputFormatNames = []string{string(TextOutputFormat), string(JsonOutputFormat), string(YamlOutputFormat)}
+
+// ParseOutputFormat returns the output format with the given name. If the name is empty, the text format is returned.
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	if name == "" {
+		return TextOutputFormat, nil
+	}
+
+	for _, formatName := range OutputFormatNames {
+		if name == formatName {
+			return OutputFormat(name), nil
+		}
+	}
+	return "", fmt.Errorf("output format %q not valid (expected one of %v)", name, OutputFormatNames)
+}
+
+// IsStructured returns whether the format is meant to be read by other programs, rather than by people.
+func (outputFormat OutputFormat) IsStructured() bool {
+	return outputFormat == JsonOutputFormat || outputFormat == YamlOutputFormat
+}
+
+// TextResult is implemented by results that have their own text representation. Results that don't implement it are
+// printed as YAML in the text format.
+type TextResult interface {
+	// Text returns the result as human-readable text, ending with a newline.
+	Text() string
+}
+
+// Output prints what commands have to say in the chosen OutputFormat. Each command prints at most one result, which is
+// the structured object described in its documentation, along with any number of messages about its progress. In the
+// text format, both go to standard output. In the structured formats, messages go to standard error instead, so that
+// standard output only contains the result.
+type Output struct {
+	globalOptions *GlobalOptions
+	stdout        io.Writer
+	stderr        io.Writer
+}
+
+// OutputWriters holds the writers that Output prints to.
+type OutputWriters struct {
+	// Stdout is where results are printed, along with messages in the text format.
+	Stdout io.Writer
+
+	// Stderr is where messages are printed in the structured formats.
+	Stderr io.Writer
+}
+
+// NewOutputWriters returns the OutputWriters for standard output and standard error.
+func NewOutputWriters() OutputWriters {
+	return OutputWriters{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+// NewOutput creates a new instance of Output that prints in the format chosen in the given GlobalOptions to the given
+// OutputWriters. The format is looked up each time something is printed, since the global options are only filled in
+// once the command line has been parsed.
+func NewOutput(globalOptions *GlobalOptions, outputWriters OutputWriters) *Output {
+	return &Output{
+		globalOptions: globalOptions,
+		stdout:        outputWriters.Stdout,
+		stderr:        outputWriters.Stderr,
+	}
+}
+
+// NewMessageWriterFunc returns a function for getting the writer that the given Output prints messages to, so that the
+// services commands depend on can print messages in the same way.
+func NewMessageWriterFunc(output *Output) system.MessageWriterFunc {
+	return output.MessageWriter
+}
+
+// Format returns the format results are printed in.
+func (output *Output) Format() OutputFormat {
+	return output.globalOptions.Output
+}
+
+// Printf prints a message about the command's progress, formatted as with fmt.Printf.
+func (output *Output) Printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(output.MessageWriter(), format, args...)
+}
+
+// Print prints a message about the command's progress as it is.
+func (output *Output) Print(message string) {
+	_, _ = io.WriteString(output.MessageWriter(), message)
+}
+
+// Println prints a message about the command's progress, followed by a newline.
+func (output *Output) Println(message string) {
+	_, _ = fmt.Fprintln(output.MessageWriter(), message)
+}
+
+// MessageWriter returns the writer that messages are printed to, for printing them with another writer, such as a
+// tabwriter.Writer.
+func (output *Output) MessageWriter() io.Writer {
+	if output.Format().IsStructured() {
+		return output.stderr
+	}
+	return output.stdout
+}
+
+// Result prints the result of the command. In the structured formats, the result is marshalled using its yaml struct
+// tags, which are also used for the names of the JSON properties, so that both formats have the same structure.
+//
+// It takes the following parameters:
+//   - result: The result to print.
+func (output *Output) Result(result any) error {
+	if textResult, isTextResult := result.(TextResult); isTextResult && !output.Format().IsStructured() {
+		_, err := io.WriteString(output.stdout, textResult.Text())
+		return err
+	}
+
+	yamlBytes, err := yaml.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	if output.Format() != JsonOutputFormat {
+		_, err = output.stdout.Write(yamlBytes)
+		return err
+	}
+
+	var document any
+	if err = yaml.Unmarshal(yamlBytes, &document); err != nil {
+		return err
+	}
+
+	jsonBytes, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = output.stdout.Write(append(jsonBytes, '\n'))
+	return err
+}
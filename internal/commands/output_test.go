@@ -0,0 +1,98 @@
+package commands_test
+
+import (
+	. "github.com/colececil/familiar.sh/internal/commands"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// outputTestResult is a result for testing Output, with nested fields whose YAML keys differ from their Go names.
+type outputTestResult struct {
+	Name     string            `yaml:"name"`
+	Count    int               `yaml:"count"`
+	Optional string            `yaml:"optional,omitempty"`
+	Entries  []outputTestEntry `yaml:"entries"`
+}
+
+// outputTestEntry is an entry of outputTestResult.
+type outputTestEntry struct {
+	SourcePath string `yaml:"sourcePath"`
+	Enabled    bool   `yaml:"enabled"`
+}
+
+// outputTestTextResult is an outputTestResult with its own text representation.
+type outputTestTextResult struct {
+	outputTestResult `yaml:",inline"`
+}
+
+// Text returns the result as human-readable text.
+func (outputTestTextResult outputTestTextResult) Text() string {
+	return "Result " + outputTestTextResult.Name + "\n"
+}
+
+var _ = Describe("Output", func() {
+	result := outputTestResult{Name: "example", Count: 2, Entries: []outputTestEntry{
+		{SourcePath: ".bashrc", Enabled: true},
+		{SourcePath: "setup.sh"},
+	}}
+
+	Describe("Result", func() {
+		It("should print results with their own text representation as that text in the text format", func() {
+			output, stdout, stderr := newOutputWithFormat(TextOutputFormat)
+
+			Expect(output.Result(outputTestTextResult{result})).To(Succeed())
+			Expect(stdout.String()).To(Equal("Result example\n"))
+			Expect(stderr.String()).To(BeEmpty())
+		})
+
+		It("should print other results as YAML in the text format", func() {
+			output, stdout, _ := newOutputWithFormat(TextOutputFormat)
+
+			Expect(output.Result(result)).To(Succeed())
+			Expect(stdout.String()).To(MatchYAML("name: example\ncount: 2\nentries:\n" +
+				"  - sourcePath: .bashrc\n    enabled: true\n  - sourcePath: setup.sh\n    enabled: false\n"))
+		})
+
+		It("should print results as YAML using their yaml struct tags in the YAML format", func() {
+			output, stdout, stderr := newOutputWithFormat(YamlOutputFormat)
+
+			Expect(output.Result(outputTestTextResult{result})).To(Succeed())
+			Expect(stdout.String()).To(Equal("name: example\ncount: 2\nentries:\n" +
+				"    - sourcePath: .bashrc\n      enabled: true\n    - sourcePath: setup.sh\n      enabled: false\n"))
+			Expect(stderr.String()).To(BeEmpty())
+		})
+
+		It("should print results as a single JSON document with the same structure as the YAML in the JSON format",
+			func() {
+				output, stdout, stderr := newOutputWithFormat(JsonOutputFormat)
+
+				Expect(output.Result(outputTestTextResult{result})).To(Succeed())
+				Expect(stdout.String()).To(HaveSuffix("}\n"))
+				Expect(stdout.String()).To(MatchJSON(`{"name": "example", "count": 2, "entries": [
+					{"sourcePath": ".bashrc", "enabled": true}, {"sourcePath": "setup.sh", "enabled": false}]}`))
+				Expect(stderr.String()).To(BeEmpty())
+			})
+	})
+
+	Describe("Printf", func() {
+		It("should print messages to standard output in the text format", func() {
+			output, stdout, stderr := newOutputWithFormat(TextOutputFormat)
+
+			output.Printf("Installing package \"%s\"...\n", "package1")
+			Expect(stdout.String()).To(Equal("Installing package \"package1\"...\n"))
+			Expect(stderr.String()).To(BeEmpty())
+		})
+
+		It("should print messages to standard error in the structured formats", func() {
+			for _, format := range []OutputFormat{JsonOutputFormat, YamlOutputFormat} {
+				output, stdout, stderr := newOutputWithFormat(format)
+
+				output.Printf("Installing package \"%s\"...\n", "package1")
+				_, err := NewMessageWriterFunc(output)().Write([]byte("Warning\n"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stdout.String()).To(BeEmpty())
+				Expect(stderr.String()).To(Equal("Installing package \"package1\"...\nWarning\n"))
+			}
+		})
+	})
+})
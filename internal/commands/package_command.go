@@ -4,24 +4,181 @@ import (
 	"fmt"
 	"github.com/colececil/familiar.sh/internal/config"
 	"github.com/colececil/familiar.sh/internal/packagemanagers"
+	"sort"
 	"strings"
+	"sync"
+	"text/tabwriter"
 )
 
 // PackageCommand represents the "package" command.
 type PackageCommand struct {
 	configService          *config.ConfigService
 	packageManagerRegistry packagemanagers.PackageManagerRegistry
+	output                 *Output
 }
 
 // NewPackageCommand creates a new instance of PackageCommand.
 func NewPackageCommand(configService *config.ConfigService,
-	packageManagerRegistry packagemanagers.PackageManagerRegistry) *PackageCommand {
+	packageManagerRegistry packagemanagers.PackageManagerRegistry, output *Output) *PackageCommand {
 	return &PackageCommand{
 		configService:          configService,
 		packageManagerRegistry: packageManagerRegistry,
+		output:                 output,
 	}
 }
 
+// PackageStatusResult is the result of the "package status" command when it is given no arguments or a package
+// manager.
+type PackageStatusResult struct {
+	PackageManagers []PackageManagerStatus `yaml:"packageManagers"`
+}
+
+// PackageManagerStatus describes the packages that are configured or installed for a package manager.
+type PackageManagerStatus struct {
+	Name string `yaml:"name"`
+	// Installed is whether the package manager is installed. If it isn't, Packages is empty.
+	Installed bool `yaml:"installed"`
+	// Packages are the packages that are configured or installed, sorted by name.
+	Packages []PackageStatus `yaml:"packages"`
+}
+
+// PackageStatus describes the configured and installed versions of a package. It is also the result of the "package
+// status" command when it is given a package. Versions that don't apply, such as the installed version of a package
+// that isn't installed, are empty.
+type PackageStatus struct {
+	PackageManager    string `yaml:"packageManager"`
+	Name              string `yaml:"name"`
+	ConfiguredVersion string `yaml:"configuredVersion"`
+	InstalledVersion  string `yaml:"installedVersion"`
+	// NewerVersion is the latest available version of the package, if it is newer than the installed version.
+	NewerVersion string `yaml:"newerVersion"`
+	// Unsaved is whether the package is installed without being in the configuration, which means the next "familiar
+	// attune" uninstalls it. How the package was installed isn't recorded, so this is the case both for packages added
+	// with "--no-save" and for packages installed with the package manager directly.
+	Unsaved bool `yaml:"unsaved"`
+}
+
+// newPackageStatus returns the status of the given package, given its configuration and installation, either of which
+// can be nil.
+func newPackageStatus(packageManagerName string, packageName string, configuredPackage *config.ConfiguredPackage,
+	installedPackage *packagemanagers.Package) PackageStatus {
+	packageStatus := PackageStatus{PackageManager: packageManagerName, Name: packageName}
+	if configuredPackage != nil {
+		packageStatus.ConfiguredVersion = configuredPackage.Version
+	}
+	if installedPackage != nil {
+		packageStatus.InstalledVersion = installedPackage.InstalledVersion.String()
+		packageStatus.Unsaved = configuredPackage == nil
+		if installedPackage.LatestVersion.IsGreaterThan(installedPackage.InstalledVersion) {
+			packageStatus.NewerVersion = installedPackage.LatestVersion.String()
+		}
+	}
+	return packageStatus
+}
+
+// Text returns the result as human-readable text.
+func (packageStatusResult PackageStatusResult) Text() string {
+	var textBuilder strings.Builder
+	for _, packageManagerStatus := range packageStatusResult.PackageManagers {
+		textBuilder.WriteString(packageManagerStatus.Text())
+	}
+	return textBuilder.String()
+}
+
+// Text returns the status as human-readable text.
+func (packageManagerStatus PackageManagerStatus) Text() string {
+	if !packageManagerStatus.Installed {
+		return fmt.Sprintf("Package manager \"%s\" is not installed.\n", packageManagerStatus.Name)
+	}
+	if len(packageManagerStatus.Packages) == 0 {
+		return fmt.Sprintf("No packages configured or installed for package manager \"%s\".\n",
+			packageManagerStatus.Name)
+	}
+
+	var textBuilder strings.Builder
+	textBuilder.WriteString(fmt.Sprintf("Status of packages for package manager \"%s\":\n", packageManagerStatus.Name))
+	for _, packageStatus := range packageManagerStatus.Packages {
+		textBuilder.WriteString(fmt.Sprintf("- %s%s\n", packageStatus.Name, packageStatus.unsavedText()))
+		textBuilder.WriteString(fmt.Sprintf("  - Configured version: %s\n", packageStatus.ConfiguredVersion))
+		textBuilder.WriteString(fmt.Sprintf("  - Installed version: %s\n", packageStatus.InstalledVersion))
+		textBuilder.WriteString(fmt.Sprintf("  - Newer version: %s\n", packageStatus.NewerVersion))
+	}
+	return textBuilder.String()
+}
+
+// Text returns the status as human-readable text.
+func (packageStatus PackageStatus) Text() string {
+	return fmt.Sprintf("Status of package \"%s\" for package manager \"%s\"%s:\n", packageStatus.Name,
+		packageStatus.PackageManager, packageStatus.unsavedText()) +
+		fmt.Sprintf("- Configured version: %s\n", packageStatus.ConfiguredVersion) +
+		fmt.Sprintf("- Installed version: %s\n", packageStatus.InstalledVersion) +
+		fmt.Sprintf("- Newer version: %s\n", packageStatus.NewerVersion)
+}
+
+// unsavedText returns the note shown next to the package's name when it is unsaved, or an empty string otherwise.
+func (packageStatus PackageStatus) unsavedText() string {
+	if packageStatus.Unsaved {
+		return " (unsaved)"
+	}
+	return ""
+}
+
+// PackageSearchResults is the result of the "package search" command.
+type PackageSearchResults struct {
+	Term string `yaml:"term"`
+	// Packages are the packages that were found, sorted by name and then by package manager.
+	Packages []PackageSearchResult `yaml:"packages"`
+}
+
+// PackageSearchResult describes a package that was found by the "package search" command.
+type PackageSearchResult struct {
+	Name           string `yaml:"name"`
+	LatestVersion  string `yaml:"latestVersion"`
+	PackageManager string `yaml:"packageManager"`
+	Source         string `yaml:"source"`
+}
+
+// Text returns the results as a human-readable table.
+func (packageSearchResults PackageSearchResults) Text() string {
+	if len(packageSearchResults.Packages) == 0 {
+		return fmt.Sprintf("No packages found matching \"%s\".\n", packageSearchResults.Term)
+	}
+
+	var textBuilder strings.Builder
+	tableWriter := tabwriter.NewWriter(&textBuilder, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tableWriter, "NAME\tVERSION\tPACKAGE MANAGER\tSOURCE")
+	for _, packageSearchResult := range packageSearchResults.Packages {
+		_, _ = fmt.Fprintf(tableWriter, "%s\t%s\t%s\t%s\n", packageSearchResult.Name,
+			packageSearchResult.LatestVersion, packageSearchResult.PackageManager, packageSearchResult.Source)
+	}
+	_ = tableWriter.Flush()
+	return textBuilder.String()
+}
+
+// PackageInfoResult is the result of the "package info" command.
+type PackageInfoResult struct {
+	PackageManager    string   `yaml:"packageManager"`
+	Name              string   `yaml:"name"`
+	Description       string   `yaml:"description"`
+	Homepage          string   `yaml:"homepage"`
+	License           string   `yaml:"license"`
+	AvailableVersions []string `yaml:"availableVersions"`
+	Dependencies      []string `yaml:"dependencies"`
+	InstallLocation   string   `yaml:"installLocation"`
+}
+
+// Text returns the information as human-readable text.
+func (packageInfoResult PackageInfoResult) Text() string {
+	return fmt.Sprintf("Information for package \"%s\" from package manager \"%s\":\n", packageInfoResult.Name,
+		packageInfoResult.PackageManager) +
+		fmt.Sprintf("- Description: %s\n", packageInfoResult.Description) +
+		fmt.Sprintf("- Homepage: %s\n", packageInfoResult.Homepage) +
+		fmt.Sprintf("- License: %s\n", packageInfoResult.License) +
+		fmt.Sprintf("- Available versions: %s\n", strings.Join(packageInfoResult.AvailableVersions, ", ")) +
+		fmt.Sprintf("- Dependencies: %s\n", strings.Join(packageInfoResult.Dependencies, ", ")) +
+		fmt.Sprintf("- Install location: %s\n", packageInfoResult.InstallLocation)
+}
+
 // Name returns the name of the command, as it appears on the command line while being used.
 func (packageCommand *PackageCommand) Name() string {
 	return "package"
@@ -34,140 +191,399 @@ func (packageCommand *PackageCommand) Description() string {
 
 // Documentation returns detailed documentation for the command.
 func (packageCommand *PackageCommand) Documentation() string {
-	return `The "package" command provides subcommands for adding, removing, and listing packages for a given package manager. It also allows you to specify the version of a package to install. It has the following subcommands:
+	return `The "package" command provides subcommands for adding, removing, and listing packages for a given package manager. It also allows you to specify the version of a package to install.
+
+If the "--no-save" flag is given when adding, removing, or updating packages, the operation is performed without updating the shared configuration, and nothing else is recorded about it. Instead, "status" compares what is installed with the configuration: every installed package that isn't in the configuration is marked as unsaved, whether it was added with "--no-save" or installed with the package manager directly, since the next "familiar attune" uninstalls it. A package removed with "--no-save" is shown as configured but not installed, and is installed again by the next "familiar attune". A package updated with "--no-save" is shown with both its configured and installed versions, and is only brought back to its configured version by "familiar attune --exact".
 
-  search
-  info
-  add
-  remove
-  update
-  status
-  import
-`
+With "--output json" or "--output yaml", "status" prints an object with a list of "packageManagers", each with a "name", whether it is "installed", and its "packages". Each package has a "packageManager", "name", "configuredVersion", "installedVersion", and "newerVersion", which are empty when they don't apply, and whether it is "unsaved", which is when it is installed without being in the configuration (however it was installed). When a package is given, the result is that package's object.
+
+"search" prints an object with the search "term" and the "packages" that were found, sorted by name, each with a "name", "latestVersion", "packageManager", and "source". "info" prints an object with the package's "packageManager", "name", "description", "homepage", "license", "availableVersions", "dependencies", and "installLocation".`
+}
+
+// Flags returns the flags the command accepts, in addition to the global flags.
+func (packageCommand *PackageCommand) Flags() []Flag {
+	return []Flag{
+		{
+			Name:        "no-save",
+			Description: "Perform the operation without updating the shared configuration.",
+			Subcommands: []string{"add", "remove", "update"},
+		},
+	}
+}
+
+// Subcommands returns the root of the command's tree of subcommands, for describing them.
+func (packageCommand *PackageCommand) Subcommands() *Subcommand {
+	return packageCommand.subcommandTree(nil)
 }
 
 // Execute runs the command with the given arguments.
 //
 // It takes the following parameters:
-//   - args: A slice containing the arguments to pass in to the command.
+//   - args: A slice containing the positional arguments to pass in to the command, with any flags removed.
+//   - flags: The values of the flags given on the command line, including the global flags.
 //
 // If there is an error executing the command, Execute will return an error that can be displayed to the user.
-func (packageCommand *PackageCommand) Execute(args []string) error {
-	if len(args) == 0 {
-		return fmt.Errorf("subcommand must be included")
-	}
-
-	switch args[0] {
-	case "add":
-		subcommandArgs := args[1:]
-		switch len(subcommandArgs) {
-		case 1:
-			return packageCommand.addPackageManager(subcommandArgs[0])
-		case 2:
-			return packageCommand.addPackage(subcommandArgs[0], subcommandArgs[1])
-		default:
-			return fmt.Errorf("wrong number of arguments")
-		}
-	case "remove":
-		subcommandArgs := args[1:]
-		switch len(subcommandArgs) {
-		case 1:
-			return packageCommand.removePackageManager(subcommandArgs[0])
-		case 2:
-			return packageCommand.removePackage(subcommandArgs[0], subcommandArgs[1])
-		default:
-			return fmt.Errorf("wrong number of arguments")
-		}
-	case "update":
-		subcommandArgs := args[1:]
-		switch len(subcommandArgs) {
-		case 0:
-			return packageCommand.updatePackages()
-		case 1:
-			return packageCommand.updatePackagesForPackageManager(subcommandArgs[0])
-		case 2:
-			return packageCommand.updatePackage(subcommandArgs[0], subcommandArgs[1])
-		default:
-			return fmt.Errorf("wrong number of arguments")
-		}
-	case "status":
-		subcommandArgs := args[1:]
-		switch len(subcommandArgs) {
-		case 0:
-			return packageCommand.getStatus()
-		case 1:
-			return packageCommand.getStatusForPackageManager(subcommandArgs[0])
-		case 2:
-			return packageCommand.getStatusForPackage(subcommandArgs[0], subcommandArgs[1])
-		default:
-			return fmt.Errorf("wrong number of arguments")
-		}
-	case "import":
-		subcommandArgs := args[1:]
-		switch len(subcommandArgs) {
-		case 0:
-			return packageCommand.importPackages()
-		case 1:
-			return packageCommand.importPackagesFromPackageManager(subcommandArgs[0])
-		default:
-			return fmt.Errorf("wrong number of arguments")
-		}
-	default:
-		return fmt.Errorf("unknown subcommand %q", args[0])
+func (packageCommand *PackageCommand) Execute(args []string, flags FlagValues) error {
+	transaction := packageCommand.configService.BeginTransaction()
+	err := packageCommand.subcommandTree(transaction).Execute(args, flags)
+	return transaction.Finish(err)
+}
+
+// subcommandTree returns the root of the command's tree of subcommands. Any changes to the shared config file are made
+// using the given transaction, so that they are written all at once when the command finishes.
+func (packageCommand *PackageCommand) subcommandTree(transaction *config.ConfigTransaction) *Subcommand {
+	packageManagerArgument := Argument{Name: "packageManager", Description: "The name of the package manager."}
+	optionalPackageManagerArgument := Argument{Name: "packageManager", Optional: true,
+		Description: "The name of the package manager. If it isn't given, all installed package managers are used."}
+	packageArgument := Argument{Name: "package", Description: "The name of the package."}
+
+	return &Subcommand{
+		Name:          packageCommand.Name(),
+		Description:   packageCommand.Description(),
+		Documentation: packageCommand.Documentation(),
+		Subcommands: []*Subcommand{
+			{
+				Name:        "search",
+				Description: "Search for packages with the given term.",
+				Arguments: []Argument{
+					optionalPackageManagerArgument,
+					{Name: "term", Description: "The term to search for."},
+				},
+				Usages: []string{"<term>", "<packageManager> <term>"},
+				Run: func(args []string, flags FlagValues) error {
+					if len(args) == 1 {
+						return packageCommand.searchPackages(args[0])
+					}
+					return packageCommand.searchPackagesForPackageManager(args[0], args[1])
+				},
+			},
+			{
+				Name: "info",
+				Description: "Print information about a package, including its description, homepage, license, " +
+					"available versions, dependencies, and install location.",
+				Arguments: []Argument{packageManagerArgument, packageArgument},
+				Run: func(args []string, flags FlagValues) error {
+					return packageCommand.getPackageInfo(args[0], args[1])
+				},
+			},
+			{
+				Name:        "add",
+				Description: "Install a package manager, or install a package and add it to the shared configuration.",
+				Arguments: []Argument{
+					packageManagerArgument,
+					{Name: "package", Optional: true,
+						Description: "The name of the package. If it isn't given, the package manager is installed."},
+				},
+				Run: func(args []string, flags FlagValues) error {
+					save := !flags.IsSet("no-save")
+					if len(args) == 1 {
+						if !save {
+							return fmt.Errorf("the --no-save flag can't be given when adding a package manager")
+						}
+						return packageCommand.addPackageManager(transaction, args[0])
+					}
+					return packageCommand.addPackage(transaction, args[0], args[1], save)
+				},
+			},
+			{
+				Name: "remove",
+				Description: "Uninstall a package manager along with its packages, or uninstall a package and " +
+					"remove it from the shared configuration.",
+				Arguments: []Argument{
+					packageManagerArgument,
+					{Name: "package", Optional: true,
+						Description: "The name of the package. If it isn't given, the package manager is uninstalled."},
+				},
+				Run: func(args []string, flags FlagValues) error {
+					save := !flags.IsSet("no-save")
+					if len(args) == 1 {
+						if !save {
+							return fmt.Errorf("the --no-save flag can't be given when removing a package manager")
+						}
+						return packageCommand.removePackageManager(transaction, args[0])
+					}
+					return packageCommand.removePackage(transaction, args[0], args[1], save)
+				},
+			},
+			{
+				Name: "update",
+				Description: "Update installed packages to the latest available version, along with their versions " +
+					"in the shared configuration. Pinned packages are skipped.",
+				Documentation: "If updating a package fails, the command stops, but the new versions of the " +
+					"packages updated before it are still saved to the shared configuration, since they are already " +
+					"installed.",
+				Arguments: []Argument{
+					optionalPackageManagerArgument,
+					{Name: "package", Optional: true,
+						Description: "The name of the package. If it isn't given, all packages are updated."},
+				},
+				Run: func(args []string, flags FlagValues) error {
+					save := !flags.IsSet("no-save")
+					switch len(args) {
+					case 0:
+						return packageCommand.updatePackages(transaction, save)
+					case 1:
+						return packageCommand.updatePackagesForPackageManager(transaction, args[0], save)
+					default:
+						return packageCommand.updatePackage(transaction, args[0], args[1], save)
+					}
+				},
+			},
+			{
+				Name: "status",
+				Description: "Show the status of configured and installed packages, along with any available " +
+					"updates. Installed packages that aren't in the configuration are marked as unsaved.",
+				Arguments: []Argument{
+					optionalPackageManagerArgument,
+					{Name: "package", Optional: true,
+						Description: "The name of the package. If it isn't given, all packages are shown."},
+				},
+				Run: func(args []string, flags FlagValues) error {
+					switch len(args) {
+					case 0:
+						return packageCommand.getStatus()
+					case 1:
+						return packageCommand.getStatusForPackageManager(args[0])
+					default:
+						return packageCommand.getStatusForPackage(args[0], args[1])
+					}
+				},
+			},
+			{
+				Name:        "import",
+				Description: "Import all currently installed packages into the shared configuration.",
+				Arguments:   []Argument{optionalPackageManagerArgument},
+				Run: func(args []string, flags FlagValues) error {
+					if len(args) == 0 {
+						return packageCommand.importPackages(transaction)
+					}
+					return packageCommand.importPackagesFromPackageManager(transaction, args[0])
+				},
+			},
+			{
+				Name: "pin",
+				Description: "Pin a package at a version, so that it is skipped by \"package update\" and " +
+					"\"attune\". The package is also held natively if the package manager supports it.",
+				Arguments: []Argument{
+					packageManagerArgument,
+					packageArgument,
+					{Name: "version", Optional: true,
+						Description: "The version to pin the package at. If it isn't given, the installed version is " +
+							"used. If the package isn't in the shared configuration yet, it is added."},
+				},
+				Run: func(args []string, flags FlagValues) error {
+					var version *packagemanagers.Version
+					if len(args) == 3 {
+						version = packagemanagers.NewVersion(args[2])
+					}
+					return packageCommand.pinPackage(transaction, args[0], args[1], version)
+				},
+			},
+			{
+				Name:        "unpin",
+				Description: "Unpin a package, so that it is updated normally again. Any native hold is also released.",
+				Arguments:   []Argument{packageManagerArgument, packageArgument},
+				Run: func(args []string, flags FlagValues) error {
+					return packageCommand.unpinPackage(transaction, args[0], args[1])
+				},
+			},
+		},
 	}
 }
 
-// addPackageManager adds the package manager of the given name to the config file.
+// searchPackages searches for packages matching the given term with all package managers that are supported, installed,
+// and able to search. The package managers are searched concurrently, and the results are printed as a single table.
 //
 // It takes the following parameters:
-//   - packageManagerName: The name of the package manager to add.
-func (packageCommand *PackageCommand) addPackageManager(packageManagerName string) error {
-	configContents, err := packageCommand.configService.GetConfig()
+//   - term: The search term.
+func (packageCommand *PackageCommand) searchPackages(term string) error {
+	var packageManagers []packagemanagers.PackageManager
+	for _, packageManager := range packageCommand.packageManagerRegistry.GetAllPackageManagers() {
+		if !packageManager.IsSupported() || !packageManager.Capabilities().Search {
+			continue
+		}
+
+		isInstalled, err := packageManager.IsInstalled()
+		if err != nil {
+			return err
+		}
+
+		if isInstalled {
+			packageManagers = append(packageManagers, packageManager)
+		} else {
+			packageCommand.output.Printf("Skipping package manager \"%s\" because it is not installed.\n",
+				packageManager.Name())
+		}
+	}
+
+	if len(packageManagers) == 0 {
+		return fmt.Errorf("no installed package managers are able to search for packages")
+	}
+
+	return packageCommand.printSearchResults(term, packageManagers)
+}
+
+// searchPackagesForPackageManager searches for packages matching the given term with the given package manager, and
+// prints the results as a table.
+//
+// It takes the following parameters:
+//   - packageManagerName: The name of the package manager to search with.
+//   - term: The search term.
+func (packageCommand *PackageCommand) searchPackagesForPackageManager(packageManagerName string, term string) error {
+	packageManager, err := packageCommand.packageManagerRegistry.GetPackageManager(packageManagerName)
 	if err != nil {
 		return err
 	}
 
-	if err = configContents.AddPackageManager(packageManagerName, packageCommand.packageManagerRegistry); err != nil {
-		return err
+	if !packageManager.Capabilities().Search {
+		return fmt.Errorf("package manager \"%s\" does not support searching for packages", packageManagerName)
 	}
 
-	if err = packageCommand.configService.SetConfig(configContents); err != nil {
-		return err
+	return packageCommand.printSearchResults(term, []packagemanagers.PackageManager{packageManager})
+}
+
+// printSearchResults searches for packages matching the given term with each of the given package managers
+// concurrently, then merges the results into a single PackageSearchResults sorted by package name and prints it. If
+// some package managers fail, a warning is printed for each of them and the remaining results are still shown. If all
+// of them fail, an error is returned.
+//
+// It takes the following parameters:
+//   - term: The search term.
+//   - packageManagers: The package managers to search with.
+func (packageCommand *PackageCommand) printSearchResults(term string,
+	packageManagers []packagemanagers.PackageManager) error {
+	type searchOutcome struct {
+		packageManagerName string
+		results            []*packagemanagers.SearchResult
+		err                error
 	}
 
-	fmt.Println("Package manager added.")
-	return nil
+	outcomes := make([]searchOutcome, len(packageManagers))
+	var waitGroup sync.WaitGroup
+	for i, packageManager := range packageManagers {
+		waitGroup.Add(1)
+		go func(i int, packageManager packagemanagers.PackageManager) {
+			defer waitGroup.Done()
+			results, err := packageManager.SearchPackages(term)
+			outcomes[i] = searchOutcome{packageManagerName: packageManager.Name(), results: results, err: err}
+		}(i, packageManager)
+	}
+	waitGroup.Wait()
+
+	searchResults := PackageSearchResults{Term: term, Packages: []PackageSearchResult{}}
+	failedSearches := 0
+	for _, outcome := range outcomes {
+		if outcome.err != nil {
+			packageCommand.output.Printf("Warning: Unable to search with package manager \"%s\": %s\n",
+				outcome.packageManagerName, outcome.err)
+			failedSearches++
+			continue
+		}
+
+		for _, result := range outcome.results {
+			searchResults.Packages = append(searchResults.Packages, PackageSearchResult{
+				Name:           result.Name,
+				LatestVersion:  result.LatestVersion.String(),
+				PackageManager: outcome.packageManagerName,
+				Source:         result.Source,
+			})
+		}
+	}
+
+	if failedSearches == len(outcomes) {
+		return fmt.Errorf("unable to search for packages")
+	}
+
+	sort.Slice(searchResults.Packages, func(i, j int) bool {
+		if searchResults.Packages[i].Name != searchResults.Packages[j].Name {
+			return searchResults.Packages[i].Name < searchResults.Packages[j].Name
+		}
+		return searchResults.Packages[i].PackageManager < searchResults.Packages[j].PackageManager
+	})
+
+	return packageCommand.output.Result(searchResults)
 }
 
-// removePackageManager removes the package manager of the given name from the config file.
+// getPackageInfo prints detailed information about the given package under the given package manager.
 //
 // It takes the following parameters:
-//   - packageManagerName: The name of the package manager to remove.
-func (packageCommand *PackageCommand) removePackageManager(packageManagerName string) error {
-	configContents, err := packageCommand.configService.GetConfig()
+//   - packageManagerName: The name of the package manager to use.
+//   - packageName: The name of the package.
+func (packageCommand *PackageCommand) getPackageInfo(packageManagerName string, packageName string) error {
+	packageManager, err := packageCommand.packageManagerRegistry.GetPackageManager(packageManagerName)
 	if err != nil {
 		return err
 	}
 
-	if err := configContents.RemovePackageManager(packageManagerName); err != nil {
+	if !packageManager.Capabilities().Info {
+		return fmt.Errorf("package manager \"%s\" does not support getting package information", packageManagerName)
+	}
+
+	packageInfo, err := packageManager.PackageInfo(packageName)
+	if err != nil {
 		return err
 	}
 
-	if err = packageCommand.configService.SetConfig(configContents); err != nil {
+	availableVersions := []string{}
+	for _, availableVersion := range packageInfo.AvailableVersions {
+		availableVersions = append(availableVersions, availableVersion.String())
+	}
+
+	return packageCommand.output.Result(PackageInfoResult{
+		PackageManager:    packageManager.Name(),
+		Name:              packageInfo.Name,
+		Description:       packageInfo.Description,
+		Homepage:          packageInfo.Homepage,
+		License:           packageInfo.License,
+		AvailableVersions: availableVersions,
+		Dependencies:      packageInfo.Dependencies,
+		InstallLocation:   packageInfo.InstallLocation,
+	})
+}
+
+// addPackageManager adds the package manager of the given name to the config file.
+//
+// It takes the following parameters:
+//   - packageManagerName: The name of the package manager to add.
+func (packageCommand *PackageCommand) addPackageManager(transaction *config.ConfigTransaction,
+	packageManagerName string) error {
+	err := transaction.Mutate(func(sharedConfig *config.Config) error {
+		return sharedConfig.AddPackageManager(packageManagerName, packageCommand.packageManagerRegistry)
+	})
+	if err != nil {
+		return err
+	}
+
+	packageCommand.output.Println("Package manager added.")
+	return nil
+}
+
+// removePackageManager removes the package manager of the given name from the config file.
+//
+// It takes the following parameters:
+//   - packageManagerName: The name of the package manager to remove.
+func (packageCommand *PackageCommand) removePackageManager(transaction *config.ConfigTransaction,
+	packageManagerName string) error {
+	err := transaction.Mutate(func(sharedConfig *config.Config) error {
+		return sharedConfig.RemovePackageManager(packageManagerName)
+	})
+	if err != nil {
 		return err
 	}
 
-	fmt.Println("Package manager removed.")
+	packageCommand.output.Println("Package manager removed.")
 	return nil
 }
 
 // addPackage installs the given package using the given package manager. After that, it adds the package to the config
-// file under the package manager.
+// file under the package manager, unless it shouldn't be saved.
 //
 // It takes the following parameters:
 //   - packageManagerName: The name of the package manager to use.
 //   - packageName: The name of the package to add.
-func (packageCommand *PackageCommand) addPackage(packageManagerName string, packageName string) error {
+//   - save: Whether to add the package to the config file.
+func (packageCommand *PackageCommand) addPackage(transaction *config.ConfigTransaction, packageManagerName string,
+	packageName string, save bool) error {
 	packageManager, err := packageCommand.packageManagerRegistry.GetPackageManager(packageManagerName)
 	if err != nil {
 		return err
@@ -182,25 +598,26 @@ func (packageCommand *PackageCommand) addPackage(packageManagerName string, pack
 		return err
 	}
 
-	configContents, err := packageCommand.configService.GetConfig()
-	if err != nil {
-		return err
-	}
-
-	if err = configContents.AddPackage(packageManagerName, packageName, installedVersion); err != nil {
-		return err
+	if !save {
+		packageCommand.output.Printf("Package \"%s\" was installed without adding it to the shared configuration.\n",
+			packageName)
+		return nil
 	}
 
-	return packageCommand.configService.SetConfig(configContents)
+	return transaction.Mutate(func(sharedConfig *config.Config) error {
+		return sharedConfig.AddPackage(packageManagerName, packageName, installedVersion)
+	})
 }
 
 // removePackage uninstalls the given package using the given package manager. After that, it removes the package from
-// the config file under the package manager.
+// the config file under the package manager, unless the removal shouldn't be saved.
 //
 // It takes the following parameters:
 //   - packageManagerName: The name of the package manager to use.
 //   - packageName: The name of the package to remove.
-func (packageCommand *PackageCommand) removePackage(packageManagerName string, packageName string) error {
+//   - save: Whether to remove the package from the config file.
+func (packageCommand *PackageCommand) removePackage(transaction *config.ConfigTransaction, packageManagerName string,
+	packageName string, save bool) error {
 	packageManager, err := packageCommand.packageManagerRegistry.GetPackageManager(packageManagerName)
 	if err != nil {
 		return err
@@ -210,21 +627,20 @@ func (packageCommand *PackageCommand) removePackage(packageManagerName string, p
 		return err
 	}
 
-	configContents, err := packageCommand.configService.GetConfig()
-	if err != nil {
-		return err
-	}
-
-	if err = configContents.RemovePackage(packageManagerName, packageName); err != nil {
-		return err
+	if !save {
+		packageCommand.output.Printf("Package \"%s\" was uninstalled without removing it from the shared "+
+			"configuration.\n", packageName)
+		return nil
 	}
 
-	return packageCommand.configService.SetConfig(configContents)
+	return transaction.Mutate(func(sharedConfig *config.Config) error {
+		return sharedConfig.RemovePackage(packageManagerName, packageName)
+	})
 }
 
 // updatePackages updates all currently installed packages for all package managers that are both supported and
-// installed.
-func (packageCommand *PackageCommand) updatePackages() error {
+// installed. If the new versions should be saved, the versions in the config file are updated.
+func (packageCommand *PackageCommand) updatePackages(transaction *config.ConfigTransaction, save bool) error {
 	packageManagers := packageCommand.packageManagerRegistry.GetAllPackageManagers()
 
 	for _, packageManager := range packageManagers {
@@ -238,11 +654,13 @@ func (packageCommand *PackageCommand) updatePackages() error {
 		}
 
 		if isInstalled {
-			if err := packageCommand.updatePackagesForPackageManager(packageManager.Name()); err != nil {
+			err := packageCommand.updatePackagesForPackageManager(transaction, packageManager.Name(), save)
+			if err != nil {
 				return err
 			}
 		} else {
-			fmt.Printf("Skipping package manager \"%s\" because it is not installed.\n", packageManager.Name())
+			packageCommand.output.Printf("Skipping package manager \"%s\" because it is not installed.\n",
+				packageManager.Name())
 		}
 	}
 
@@ -250,19 +668,25 @@ func (packageCommand *PackageCommand) updatePackages() error {
 }
 
 // updatePackagesForPackageManager updates all currently installed packages for the package manager of the given name.
-func (packageCommand *PackageCommand) updatePackagesForPackageManager(packageManagerName string) error {
+// If the new versions should be saved, the versions in the config file are updated. Which packages are configured and
+// pinned is read from the transaction, so that changes made earlier in the same command are taken into account.
+//
+// If updating a package fails, the error is returned without updating the remaining packages. The new versions of the
+// packages updated before it are left in the transaction, so they are still saved when it is finished.
+func (packageCommand *PackageCommand) updatePackagesForPackageManager(transaction *config.ConfigTransaction,
+	packageManagerName string, save bool) error {
 	packageManager, err := packageCommand.packageManagerRegistry.GetPackageManager(packageManagerName)
 	if err != nil {
 		return err
 	}
 
-	configContents, err := packageCommand.configService.GetConfig()
+	sharedConfig, err := transaction.Config()
 	if err != nil {
 		return err
 	}
 
 	configuredPackages := make(map[string]*packagemanagers.Version)
-	for _, configuredPackageManager := range configContents.PackageManagers {
+	for _, configuredPackageManager := range sharedConfig.PackageManagers {
 		if configuredPackageManager.Name == packageManagerName {
 			for _, configuredPackage := range configuredPackageManager.Packages {
 				configuredPackages[configuredPackage.Name] = packagemanagers.NewVersion(configuredPackage.Version)
@@ -281,38 +705,53 @@ func (packageCommand *PackageCommand) updatePackagesForPackageManager(packageMan
 	}
 
 	for _, installedPackage := range installedPackages {
-		if installedPackage.LatestVersion.IsGreaterThan(installedPackage.InstalledVersion) {
+		if sharedConfig.IsPackagePinned(packageManagerName, installedPackage.Name) {
+			packageCommand.output.Printf("Skipping package \"%s\" because it is pinned.\n", installedPackage.Name)
+		} else if installedPackage.LatestVersion.IsGreaterThan(installedPackage.InstalledVersion) {
 			newVersion, err := packageManager.UpdatePackage(installedPackage.Name, nil)
 			if err != nil {
 				return err
 			}
 
-			if configuredPackages[installedPackage.Name] != nil &&
+			if save && configuredPackages[installedPackage.Name] != nil &&
 				configuredPackages[installedPackage.Name].IsLessThan(newVersion) {
-				err = configContents.UpdatePackage(packageManagerName, installedPackage.Name, newVersion)
+				packageName := installedPackage.Name
+				err = transaction.Mutate(func(sharedConfig *config.Config) error {
+					return sharedConfig.UpdatePackage(packageManagerName, packageName, newVersion)
+				})
 				if err != nil {
 					return err
 				}
-
-				if err = packageCommand.configService.SetConfig(configContents); err != nil {
-					return err
-				}
 			}
 		} else {
-			fmt.Printf("Skipping package \"%s\" because it is already up to date.\n", installedPackage.Name)
+			packageCommand.output.Printf("Skipping package \"%s\" because it is already up to date.\n",
+				installedPackage.Name)
 		}
 	}
 
 	return nil
 }
 
-// updatePackage updates the given package for the given package manager.
-func (packageCommand *PackageCommand) updatePackage(packageManagerName string, packageName string) error {
+// updatePackage updates the given package for the given package manager. If the new version should be saved, the
+// version in the config file is updated.
+func (packageCommand *PackageCommand) updatePackage(transaction *config.ConfigTransaction, packageManagerName string,
+	packageName string, save bool) error {
 	packageManager, err := packageCommand.packageManagerRegistry.GetPackageManager(packageManagerName)
 	if err != nil {
 		return err
 	}
 
+	sharedConfig, err := transaction.Config()
+	if err != nil {
+		return err
+	}
+
+	if sharedConfig.IsPackagePinned(packageManagerName, packageName) {
+		packageCommand.output.Printf("Package \"%s\" is pinned, so it will not be updated. Run \"familiar package "+
+			"unpin %s %s\" to allow updates.\n", packageName, packageManagerName, packageName)
+		return nil
+	}
+
 	if err := packageManager.Update(); err != nil {
 		return err
 	}
@@ -330,25 +769,22 @@ func (packageCommand *PackageCommand) updatePackage(packageManagerName string, p
 					return err
 				}
 
-				configContents, err := packageCommand.configService.GetConfig()
-				if err != nil {
-					return err
+				if !save {
+					return nil
 				}
 
-				for _, configuredPackageManager := range configContents.PackageManagers {
+				for _, configuredPackageManager := range sharedConfig.PackageManagers {
 					if configuredPackageManager.Name == packageManagerName {
 						for _, configuredPackage := range configuredPackageManager.Packages {
 							if configuredPackage.Name == packageName {
 								configuredVersion := packagemanagers.NewVersion(configuredPackage.Version)
 								if configuredVersion.IsLessThan(newVersion) {
-									err := configContents.UpdatePackage(packageManagerName, packageName, newVersion)
+									err := transaction.Mutate(func(sharedConfig *config.Config) error {
+										return sharedConfig.UpdatePackage(packageManagerName, packageName, newVersion)
+									})
 									if err != nil {
 										return err
 									}
-
-									if err = packageCommand.configService.SetConfig(configContents); err != nil {
-										return err
-									}
 								}
 								break
 							}
@@ -357,22 +793,123 @@ func (packageCommand *PackageCommand) updatePackage(packageManagerName string, p
 					}
 				}
 			} else {
-				fmt.Printf("Package \"%s\" is already up to date.\n", packageName)
+				packageCommand.output.Printf("Package \"%s\" is already up to date.\n", packageName)
 			}
 
 			return nil
 		}
 	}
 
-	fmt.Printf("Package \"%s\" is not installed.\n", packageName)
+	packageCommand.output.Printf("Package \"%s\" is not installed.\n", packageName)
+	return nil
+}
+
+// pinPackage pins the given package under the given package manager in the config file, so that it is skipped by
+// "package update" and "attune". If the package is not yet in the config file, it is added. If the package manager
+// supports holding packages, the package is also held natively.
+//
+// It takes the following parameters:
+//   - packageManagerName: The name of the package manager to use.
+//   - packageName: The name of the package to pin.
+//   - version: The version to pin the package at. If nil, the installed version is used, or the configured version if
+//     the package is not installed.
+func (packageCommand *PackageCommand) pinPackage(transaction *config.ConfigTransaction, packageManagerName string,
+	packageName string, version *packagemanagers.Version) error {
+	packageManager, err := packageCommand.packageManagerRegistry.GetPackageManager(packageManagerName)
+	if err != nil {
+		return err
+	}
+
+	installedPackages, err := packageManager.InstalledPackages()
+	if err != nil {
+		return err
+	}
+
+	var installedVersion *packagemanagers.Version
+	for _, installedPackage := range installedPackages {
+		if installedPackage.Name == packageName {
+			installedVersion = installedPackage.InstalledVersion
+			break
+		}
+	}
+
+	if version == nil {
+		version = installedVersion
+	}
+
+	err = transaction.Mutate(func(sharedConfig *config.Config) error {
+		if version == nil && !sharedConfig.HasPackage(packageManagerName, packageName) {
+			return fmt.Errorf("package \"%s\" is not installed or configured, so a version must be given",
+				packageName)
+		}
+
+		return sharedConfig.PinPackage(packageManagerName, packageName, version)
+	})
+	if err != nil {
+		return err
+	}
+
+	if installedVersion != nil {
+		if packageManager.Capabilities().Hold {
+			if err = packageManager.HoldPackage(packageName); err != nil {
+				return err
+			}
+		}
+
+		if version != nil && !installedVersion.IsEqualTo(version) {
+			packageCommand.output.Printf("Note: Package \"%s\" is installed at version %s, not the pinned version "+
+				"%s.\n", packageName, installedVersion, version)
+		}
+	}
+
+	packageCommand.output.Printf("Package \"%s\" pinned.\n", packageName)
+	return nil
+}
+
+// unpinPackage unpins the given package under the given package manager in the config file, so that it is updated
+// normally again. If the package manager supports holding packages, the native hold is also released.
+//
+// It takes the following parameters:
+//   - packageManagerName: The name of the package manager to use.
+//   - packageName: The name of the package to unpin.
+func (packageCommand *PackageCommand) unpinPackage(transaction *config.ConfigTransaction, packageManagerName string,
+	packageName string) error {
+	packageManager, err := packageCommand.packageManagerRegistry.GetPackageManager(packageManagerName)
+	if err != nil {
+		return err
+	}
+
+	err = transaction.Mutate(func(sharedConfig *config.Config) error {
+		return sharedConfig.UnpinPackage(packageManagerName, packageName)
+	})
+	if err != nil {
+		return err
+	}
+
+	if packageManager.Capabilities().Hold {
+		installedPackages, err := packageManager.InstalledPackages()
+		if err != nil {
+			return err
+		}
+
+		for _, installedPackage := range installedPackages {
+			if installedPackage.Name == packageName {
+				if err = packageManager.UnholdPackage(packageName); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+
+	packageCommand.output.Printf("Package \"%s\" unpinned.\n", packageName)
 	return nil
 }
 
 // getStatus prints the status for all package managers supported on the current machine.
 func (packageCommand *PackageCommand) getStatus() error {
-	packageManagers := packageCommand.packageManagerRegistry.GetAllPackageManagers()
-
-	for _, packageManager := range packageManagers {
+	result := PackageStatusResult{PackageManagers: []PackageManagerStatus{}}
+	for _, packageManager := range packageCommand.packageManagerRegistry.GetAllPackageManagers() {
 		if isSupported := packageManager.IsSupported(); !isSupported {
 			continue
 		}
@@ -382,24 +919,36 @@ func (packageCommand *PackageCommand) getStatus() error {
 			return err
 		}
 
+		packageManagerStatus := PackageManagerStatus{Name: packageManager.Name(), Packages: []PackageStatus{}}
 		if isInstalled {
-			if err := packageCommand.getStatusForPackageManager(packageManager.Name()); err != nil {
+			if packageManagerStatus, err = packageCommand.packageManagerStatus(packageManager.Name()); err != nil {
 				return err
 			}
-		} else {
-			fmt.Printf("Package manager \"%s\" is not installed.\n", packageManager.Name())
 		}
+		result.PackageManagers = append(result.PackageManagers, packageManagerStatus)
 	}
 
-	return nil
+	return packageCommand.output.Result(result)
 }
 
 // getStatusForPackageManager prints the status for the package manager of the given name.
 func (packageCommand *PackageCommand) getStatusForPackageManager(packageManagerName string) error {
-	configContents, err := packageCommand.configService.GetConfig()
+	packageManagerStatus, err := packageCommand.packageManagerStatus(packageManagerName)
 	if err != nil {
 		return err
 	}
+	return packageCommand.output.Result(PackageStatusResult{
+		PackageManagers: []PackageManagerStatus{packageManagerStatus},
+	})
+}
+
+// packageManagerStatus returns the status of the packages that are configured or installed for the package manager of
+// the given name, which must be installed. The packages are sorted by name.
+func (packageCommand *PackageCommand) packageManagerStatus(packageManagerName string) (PackageManagerStatus, error) {
+	configContents, err := packageCommand.configService.GetConfig()
+	if err != nil {
+		return PackageManagerStatus{}, err
+	}
 
 	configuredPackages := make(map[string]config.ConfiguredPackage)
 	for _, configuredPackageManager := range configContents.PackageManagers {
@@ -413,16 +962,16 @@ func (packageCommand *PackageCommand) getStatusForPackageManager(packageManagerN
 
 	packageManager, err := packageCommand.packageManagerRegistry.GetPackageManager(packageManagerName)
 	if err != nil {
-		return err
+		return PackageManagerStatus{}, err
 	}
 
 	if err := packageManager.Update(); err != nil {
-		return err
+		return PackageManagerStatus{}, err
 	}
 
 	installedPackagesSlice, err := packageManager.InstalledPackages()
 	if err != nil {
-		return err
+		return PackageManagerStatus{}, err
 	}
 
 	installedPackages := make(map[string]*packagemanagers.Package)
@@ -430,52 +979,29 @@ func (packageCommand *PackageCommand) getStatusForPackageManager(packageManagerN
 		installedPackages[installedPackage.Name] = installedPackage
 	}
 
-	if len(configuredPackages) == 0 && len(installedPackages) == 0 {
-		fmt.Printf("No packages configured or installed for package manager \"%s\".\n", packageManager.Name())
-		return nil
+	var packageNames []string
+	for packageName := range configuredPackages {
+		packageNames = append(packageNames, packageName)
 	}
-
-	fmt.Printf("Status of packages for package manager \"%s\":\n", packageManager.Name())
-	var packageListStringBuilder strings.Builder
-
-	for _, configuredPackage := range configuredPackages {
-		packageListStringBuilder.WriteString(fmt.Sprintf("- %s\n", configuredPackage.Name))
-		packageListStringBuilder.WriteString(fmt.Sprintf("  - Configured version: %s\n", configuredPackage.Version))
-
-		packageListStringBuilder.WriteString("  - Installed version: ")
-		installedPackage, installed := installedPackages[configuredPackage.Name]
-		if installed {
-			packageListStringBuilder.WriteString(fmt.Sprintf("%s\n", installedPackage.InstalledVersion))
-		} else {
-			packageListStringBuilder.WriteString("\n")
-		}
-
-		packageListStringBuilder.WriteString("  - Newer version: ")
-		if installed && installedPackage.LatestVersion.IsGreaterThan(installedPackage.InstalledVersion) {
-			packageListStringBuilder.WriteString(fmt.Sprintf("%s\n", installedPackage.LatestVersion))
-		} else {
-			packageListStringBuilder.WriteString("\n")
+	for packageName := range installedPackages {
+		if _, configured := configuredPackages[packageName]; !configured {
+			packageNames = append(packageNames, packageName)
 		}
 	}
-
-	for _, installedPackage := range installedPackages {
-		if _, configured := configuredPackages[installedPackage.Name]; !configured {
-			packageListStringBuilder.WriteString(fmt.Sprintf("- %s\n", installedPackage.Name))
-			packageListStringBuilder.WriteString("  - Configured version: \n")
-			packageListStringBuilder.WriteString(fmt.Sprintf("  - Installed version: %s\n",
-				installedPackage.InstalledVersion))
-
-			packageListStringBuilder.WriteString("  - Newer version: ")
-			if installedPackage.LatestVersion.IsGreaterThan(installedPackage.InstalledVersion) {
-				packageListStringBuilder.WriteString(fmt.Sprintf("%s\n", installedPackage.LatestVersion))
-			} else {
-				packageListStringBuilder.WriteString("\n")
-			}
+	sort.Strings(packageNames)
+
+	packageManagerStatus := PackageManagerStatus{Name: packageManager.Name(), Installed: true,
+		Packages: []PackageStatus{}}
+	for _, packageName := range packageNames {
+		var configuredPackage *config.ConfiguredPackage
+		if matchingPackage, configured := configuredPackages[packageName]; configured {
+			configuredPackage = &matchingPackage
 		}
+		packageManagerStatus.Packages = append(packageManagerStatus.Packages, newPackageStatus(packageManager.Name(),
+			packageName, configuredPackage, installedPackages[packageName]))
 	}
 
-	fmt.Print(packageListStringBuilder.String())
-	return nil
+	return packageManagerStatus, nil
 }
 
 // getStatusForPackage prints the status for the given package under the given package manager.
@@ -485,12 +1011,12 @@ func (packageCommand *PackageCommand) getStatusForPackage(packageManagerName str
 		return err
 	}
 
-	var selectedPackageConfiguration config.ConfiguredPackage
+	var selectedPackageConfiguration *config.ConfiguredPackage
 	for _, configuredPackageManager := range configContents.PackageManagers {
 		if configuredPackageManager.Name == packageManagerName {
-			for _, configuredPackage := range configuredPackageManager.Packages {
-				if configuredPackage.Name == packageName {
-					selectedPackageConfiguration = configuredPackage
+			for i := range configuredPackageManager.Packages {
+				if configuredPackageManager.Packages[i].Name == packageName {
+					selectedPackageConfiguration = &configuredPackageManager.Packages[i]
 					break
 				}
 			}
@@ -519,39 +1045,13 @@ func (packageCommand *PackageCommand) getStatusForPackage(packageManagerName str
 		}
 	}
 
-	packageStringBuilder := strings.Builder{}
-	packageStringBuilder.WriteString(fmt.Sprintf("Status of package \"%s\" for package manager \"%s\":\n", packageName,
-		packageManager.Name()))
-
-	packageStringBuilder.WriteString("- Configured version: ")
-	if &selectedPackageConfiguration != nil {
-		packageStringBuilder.WriteString(fmt.Sprintf("%s\n", selectedPackageConfiguration.Version))
-	} else {
-		packageStringBuilder.WriteString("\n")
-	}
-
-	packageStringBuilder.WriteString("- Installed version: ")
-	if selectedPackageInstallation != nil {
-		packageStringBuilder.WriteString(fmt.Sprintf("%s\n", selectedPackageInstallation.InstalledVersion))
-	} else {
-		packageStringBuilder.WriteString("\n")
-	}
-
-	packageStringBuilder.WriteString("- Newer version: ")
-	if selectedPackageInstallation != nil &&
-		selectedPackageInstallation.LatestVersion.IsGreaterThan(selectedPackageInstallation.InstalledVersion) {
-		packageStringBuilder.WriteString(fmt.Sprintf("%s\n", selectedPackageInstallation.LatestVersion))
-	} else {
-		packageStringBuilder.WriteString("\n")
-	}
-
-	fmt.Print(packageStringBuilder.String())
-	return nil
+	return packageCommand.output.Result(newPackageStatus(packageManager.Name(), packageName,
+		selectedPackageConfiguration, selectedPackageInstallation))
 }
 
 // importPackages imports all currently installed packages from all package managers that are both supported and
 // installed.
-func (packageCommand *PackageCommand) importPackages() error {
+func (packageCommand *PackageCommand) importPackages(transaction *config.ConfigTransaction) error {
 	packageManagers := packageCommand.packageManagerRegistry.GetAllPackageManagers()
 
 	for _, packageManager := range packageManagers {
@@ -565,11 +1065,12 @@ func (packageCommand *PackageCommand) importPackages() error {
 		}
 
 		if isInstalled {
-			if err := packageCommand.importPackagesFromPackageManager(packageManager.Name()); err != nil {
+			if err := packageCommand.importPackagesFromPackageManager(transaction, packageManager.Name()); err != nil {
 				return err
 			}
 		} else {
-			fmt.Printf("Skipping package manager \"%s\" because it is not installed.\n", packageManager.Name())
+			packageCommand.output.Printf("Skipping package manager \"%s\" because it is not installed.\n",
+				packageManager.Name())
 		}
 	}
 
@@ -577,14 +1078,15 @@ func (packageCommand *PackageCommand) importPackages() error {
 }
 
 // importPackagesFromPackageManager imports all currently installed packages from the given package manager.
-func (packageCommand *PackageCommand) importPackagesFromPackageManager(packageManagerName string) error {
-	configContents, err := packageCommand.configService.GetConfig()
+func (packageCommand *PackageCommand) importPackagesFromPackageManager(transaction *config.ConfigTransaction,
+	packageManagerName string) error {
+	sharedConfig, err := transaction.Config()
 	if err != nil {
 		return err
 	}
 
 	configuredPackageVersions := make(map[string]*packagemanagers.Version)
-	for _, configuredPackageManager := range configContents.PackageManagers {
+	for _, configuredPackageManager := range sharedConfig.PackageManagers {
 		if configuredPackageManager.Name == packageManagerName {
 			for _, configuredPackage := range configuredPackageManager.Packages {
 				configuredPackageVersions[configuredPackage.Name] =
@@ -609,52 +1111,49 @@ func (packageCommand *PackageCommand) importPackagesFromPackageManager(packageMa
 	}
 
 	if len(installedPackages) > 0 {
-		fmt.Printf("Packages currently installed with %s:\n", packageManager.Name())
+		packageCommand.output.Printf("Packages currently installed with %s:\n", packageManager.Name())
 		for _, installedPackage := range installedPackages {
-			fmt.Printf("- %s, version %s\n", installedPackage.Name, installedPackage.InstalledVersion)
+			packageCommand.output.Printf("- %s, version %s\n", installedPackage.Name, installedPackage.InstalledVersion)
 		}
 	} else {
-		fmt.Printf("No packages are currently installed with %s.\n", packageManager.Name())
+		packageCommand.output.Printf("No packages are currently installed with %s.\n", packageManager.Name())
 	}
 
 	packageManagerConfigUpdated := false
 	for _, installedPackage := range installedPackages {
 		configuredPackageVersion, isPresent := configuredPackageVersions[installedPackage.Name]
 		if !isPresent {
-			fmt.Printf("Adding package \"%s\" to configuration for package manager \"%s\".\n", installedPackage.Name,
-				packageManagerName)
+			packageCommand.output.Printf("Adding package \"%s\" to configuration for package manager \"%s\".\n",
+				installedPackage.Name, packageManagerName)
 
-			err := configContents.AddPackage(packageManagerName, installedPackage.Name,
-				installedPackage.InstalledVersion)
+			packageName, installedVersion := installedPackage.Name, installedPackage.InstalledVersion
+			err := transaction.Mutate(func(sharedConfig *config.Config) error {
+				return sharedConfig.AddPackage(packageManagerName, packageName, installedVersion)
+			})
 			if err != nil {
 				return err
 			}
 
-			if err := packageCommand.configService.SetConfig(configContents); err != nil {
-				return err
-			}
-
 			packageManagerConfigUpdated = true
 		} else if configuredPackageVersion.IsGreaterThan(installedPackage.InstalledVersion) {
-			fmt.Printf("Updating version of package \"%s\" in configuration for package manager \"%s\".\n",
-				installedPackage.Name, packageManagerName)
+			packageCommand.output.Printf("Updating version of package \"%s\" in configuration for package manager "+
+				"\"%s\".\n", installedPackage.Name, packageManagerName)
 
-			err := configContents.UpdatePackage(packageManagerName, installedPackage.Name,
-				installedPackage.InstalledVersion)
+			packageName, installedVersion := installedPackage.Name, installedPackage.InstalledVersion
+			err := transaction.Mutate(func(sharedConfig *config.Config) error {
+				return sharedConfig.UpdatePackage(packageManagerName, packageName, installedVersion)
+			})
 			if err != nil {
 				return err
 			}
 
-			if err := packageCommand.configService.SetConfig(configContents); err != nil {
-				return err
-			}
-
 			packageManagerConfigUpdated = true
 		}
 	}
 
 	if !packageManagerConfigUpdated {
-		fmt.Printf("No packages to add or update in configuration for package manager \"%s\".\n", packageManagerName)
+		packageCommand.output.Printf("No packages to add or update in configuration for package manager \"%s\".\n",
+			packageManagerName)
 	}
 
 	return nil
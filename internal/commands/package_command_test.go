@@ -0,0 +1,279 @@
+package commands_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	. "github.com/colececil/familiar.sh/internal/commands"
+	"github.com/colececil/familiar.sh/internal/config"
+	"github.com/colececil/familiar.sh/internal/packagemanagers"
+	"github.com/colececil/familiar.sh/internal/test"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PackageCommand", func() {
+	var packageManagerDouble *test.PackageManagerDouble
+	var configLocation string
+	var configService *config.ConfigService
+	var output *Output
+	var packageCommand *PackageCommand
+
+	setUp := func(contents string) {
+		var location string
+		configService, location = newConfigService(contents)
+		configLocation = location
+		packageCommand = NewPackageCommand(configService,
+			packagemanagers.PackageManagerRegistry{"scoop": packageManagerDouble}, output)
+	}
+
+	BeforeEach(func() {
+		packageManagerDouble = test.NewPackageManagerDouble("scoop", packagemanagers.Capabilities{Hold: true})
+		output = newOutput()
+	})
+
+	Describe("status", func() {
+		It("should print the documented structure in the JSON format", func() {
+			var stdout *bytes.Buffer
+			output, stdout, _ = newOutputWithFormat(JsonOutputFormat)
+			setUp("packageManagers:\n  - name: scoop\n    packages:\n      - name: package1\n        version: 1.0.0\n" +
+				"      - name: package2\n        version: 2.0.0\n")
+			packageManagerDouble.InstalledVersions["package1"] = "1.0.0"
+			packageManagerDouble.LatestVersions["package1"] = "1.1.0"
+			packageManagerDouble.InstalledVersions["package3"] = "3.0.0"
+
+			Expect(packageCommand.Execute([]string{"status"}, FlagValues{})).To(Succeed())
+			Expect(stdout.String()).To(MatchJSON(`{"packageManagers": [{"name": "scoop", "installed": true,
+				"packages": [
+				{"packageManager": "scoop", "name": "package1", "configuredVersion": "1.0.0",
+					"installedVersion": "1.0.0", "newerVersion": "1.1.0", "unsaved": false},
+				{"packageManager": "scoop", "name": "package2", "configuredVersion": "2.0.0", "installedVersion": "",
+					"newerVersion": "", "unsaved": false},
+				{"packageManager": "scoop", "name": "package3", "configuredVersion": "", "installedVersion": "3.0.0",
+					"newerVersion": "", "unsaved": true}
+			]}]}`))
+
+			stdout.Reset()
+			Expect(packageCommand.Execute([]string{"status", "scoop", "package3"}, FlagValues{})).To(Succeed())
+			Expect(stdout.String()).To(MatchJSON(`{"packageManager": "scoop", "name": "package3",
+				"configuredVersion": "", "installedVersion": "3.0.0", "newerVersion": "", "unsaved": true}`))
+		})
+	})
+
+	Describe("search", func() {
+		It("should print the documented structure in the JSON format, with the results sorted by name", func() {
+			var stdout, stderr *bytes.Buffer
+			output, stdout, stderr = newOutputWithFormat(JsonOutputFormat)
+			packageManagerDouble = test.NewPackageManagerDouble("scoop", packagemanagers.Capabilities{Search: true})
+			packageManagerDouble.LatestVersions["package2"] = "2.0.0"
+			packageManagerDouble.LatestVersions["package1"] = "1.0.0"
+			packageManagerDouble.LatestVersions["other"] = "3.0.0"
+			setUp("")
+
+			Expect(packageCommand.Execute([]string{"search", "scoop", "package"}, FlagValues{})).To(Succeed())
+			Expect(stdout.String()).To(MatchJSON(`{"term": "package", "packages": [
+				{"name": "package1", "latestVersion": "1.0.0", "packageManager": "scoop", "source": "main"},
+				{"name": "package2", "latestVersion": "2.0.0", "packageManager": "scoop", "source": "main"}
+			]}`))
+			Expect(stderr.String()).To(BeEmpty())
+
+			stdout.Reset()
+			Expect(packageCommand.Execute([]string{"search", "scoop", "missing"}, FlagValues{})).To(Succeed())
+			Expect(stdout.String()).To(MatchJSON(`{"term": "missing", "packages": []}`))
+			Expect(stderr.String()).To(BeEmpty())
+		})
+
+		It("should print the results as a table in the text format", func() {
+			var stdout *bytes.Buffer
+			output, stdout, _ = newOutputWithFormat(TextOutputFormat)
+			packageManagerDouble = test.NewPackageManagerDouble("scoop", packagemanagers.Capabilities{Search: true})
+			packageManagerDouble.LatestVersions["package1"] = "1.0.0"
+			setUp("")
+
+			Expect(packageCommand.Execute([]string{"search", "scoop", "package"}, FlagValues{})).To(Succeed())
+			Expect(stdout.String()).To(Equal("NAME      VERSION  PACKAGE MANAGER  SOURCE\n" +
+				"package1  1.0.0    scoop            main\n"))
+
+			stdout.Reset()
+			Expect(packageCommand.Execute([]string{"search", "scoop", "missing"}, FlagValues{})).To(Succeed())
+			Expect(stdout.String()).To(Equal("No packages found matching \"missing\".\n"))
+		})
+	})
+
+	Describe("info", func() {
+		It("should print the documented structure in the JSON format", func() {
+			var stdout *bytes.Buffer
+			output, stdout, _ = newOutputWithFormat(JsonOutputFormat)
+			packageManagerDouble = test.NewPackageManagerDouble("scoop", packagemanagers.Capabilities{Info: true})
+			packageInfo := packagemanagers.NewPackageInfo("package1")
+			packageInfo.Description = "A package."
+			packageInfo.Homepage = "https://example.com"
+			packageInfo.License = "MIT"
+			packageInfo.AvailableVersions = []*packagemanagers.Version{packagemanagers.NewVersion("1.0.0")}
+			packageInfo.Dependencies = []string{"package2"}
+			packageInfo.InstallLocation = "/apps/package1"
+			packageManagerDouble.PackageInfos["package1"] = packageInfo
+			setUp("")
+
+			Expect(packageCommand.Execute([]string{"info", "scoop", "package1"}, FlagValues{})).To(Succeed())
+			Expect(stdout.String()).To(MatchJSON(`{"packageManager": "scoop", "name": "package1",
+				"description": "A package.", "homepage": "https://example.com", "license": "MIT",
+				"availableVersions": ["1.0.0"], "dependencies": ["package2"], "installLocation": "/apps/package1"}`))
+		})
+	})
+
+	Describe("--no-save", func() {
+		// getPackageStatus returns the status of the given package, as printed by "package status" in the JSON format.
+		getPackageStatus := func(packageName string) map[string]any {
+			var stdout *bytes.Buffer
+			output, stdout, _ = newOutputWithFormat(JsonOutputFormat)
+			packageCommand = NewPackageCommand(configService, packagemanagers.PackageManagerRegistry{
+				"scoop": packageManagerDouble}, output)
+			Expect(packageCommand.Execute([]string{"status", "scoop", packageName}, FlagValues{})).To(Succeed())
+
+			var packageStatus map[string]any
+			Expect(json.Unmarshal(stdout.Bytes(), &packageStatus)).To(Succeed())
+			return packageStatus
+		}
+
+		// expectConfigUnchanged checks that the shared config file still has the given contents, and that no snapshot
+		// of it was saved, since nothing was written to it.
+		expectConfigUnchanged := func(contents string) {
+			Expect(readConfigFile(configLocation)).To(Equal(contents))
+			Expect(configService.GetConfigHistory()).To(BeEmpty())
+		}
+
+		BeforeEach(func() {
+			setUp("packageManagers:\n  - name: scoop\n    packages:\n      - name: package1\n        version: 1.0.0\n")
+			packageManagerDouble.InstalledVersions["package1"] = "1.0.0"
+		})
+
+		It("should install a package without adding it to the configuration, and mark it as unsaved", func() {
+			contents := readConfigFile(configLocation)
+
+			Expect(packageCommand.Execute([]string{"add", "scoop", "package2"}, FlagValues{"no-save": ""})).To(
+				Succeed())
+
+			Expect(packageManagerDouble.InstalledVersions).To(HaveKeyWithValue("package2", "1.0.0"))
+			expectConfigUnchanged(contents)
+			Expect(getPackageStatus("package2")).To(And(HaveKeyWithValue("configuredVersion", ""),
+				HaveKeyWithValue("installedVersion", "1.0.0"), HaveKeyWithValue("unsaved", true)))
+		})
+
+		It("should uninstall a package without removing it from the configuration", func() {
+			contents := readConfigFile(configLocation)
+
+			Expect(packageCommand.Execute([]string{"remove", "scoop", "package1"}, FlagValues{"no-save": ""})).To(
+				Succeed())
+
+			Expect(packageManagerDouble.InstalledVersions).NotTo(HaveKey("package1"))
+			expectConfigUnchanged(contents)
+			Expect(getPackageStatus("package1")).To(And(HaveKeyWithValue("configuredVersion", "1.0.0"),
+				HaveKeyWithValue("installedVersion", ""), HaveKeyWithValue("unsaved", false)))
+		})
+
+		It("should update a package without changing its version in the configuration", func() {
+			packageManagerDouble.LatestVersions["package1"] = "1.1.0"
+			contents := readConfigFile(configLocation)
+
+			Expect(packageCommand.Execute([]string{"update", "scoop", "package1"}, FlagValues{"no-save": ""})).To(
+				Succeed())
+
+			Expect(packageManagerDouble.InstalledVersions).To(HaveKeyWithValue("package1", "1.1.0"))
+			expectConfigUnchanged(contents)
+			Expect(getPackageStatus("package1")).To(And(HaveKeyWithValue("configuredVersion", "1.0.0"),
+				HaveKeyWithValue("installedVersion", "1.1.0"), HaveKeyWithValue("unsaved", false)))
+		})
+	})
+
+	Describe("update", func() {
+		BeforeEach(func() {
+			setUp("packageManagers:\n  - name: scoop\n    packages:\n      - name: package1\n        version: 1.0.0\n" +
+				"      - name: package2\n        version: 2.0.0\n      - name: package3\n        version: 3.0.0\n")
+			packageManagerDouble.InstalledVersions["package1"] = "1.0.0"
+			packageManagerDouble.LatestVersions["package1"] = "1.1.0"
+			packageManagerDouble.InstalledVersions["package2"] = "2.0.0"
+			packageManagerDouble.LatestVersions["package2"] = "2.1.0"
+			packageManagerDouble.InstalledVersions["package3"] = "3.0.0"
+			packageManagerDouble.LatestVersions["package3"] = "3.1.0"
+		})
+
+		It("should skip pinned packages and save the new versions of the others", func() {
+			Expect(packageCommand.Execute([]string{"pin", "scoop", "package2"}, FlagValues{})).To(Succeed())
+
+			Expect(packageCommand.Execute([]string{"update", "scoop"}, FlagValues{})).To(Succeed())
+
+			Expect(packageManagerDouble.InstalledVersions).To(And(HaveKeyWithValue("package1", "1.1.0"),
+				HaveKeyWithValue("package2", "2.0.0"), HaveKeyWithValue("package3", "3.1.0")))
+			Expect(readConfigFile(configLocation)).To(And(ContainSubstring("version: 1.1.0"),
+				ContainSubstring("version: 2.0.0\n        pinned: true"), ContainSubstring("version: 3.1.0")))
+		})
+
+		It("should stop at a failed update and still save the versions of the packages updated before it", func() {
+			packageManagerDouble.UpdateErrors["package2"] = errors.New("download failed")
+
+			err := packageCommand.Execute([]string{"update", "scoop"}, FlagValues{})
+			Expect(err).To(MatchError("download failed"))
+
+			Expect(packageManagerDouble.InstalledVersions).To(And(HaveKeyWithValue("package1", "1.1.0"),
+				HaveKeyWithValue("package3", "3.0.0")))
+			Expect(readConfigFile(configLocation)).To(And(ContainSubstring("version: 1.1.0"),
+				ContainSubstring("version: 2.0.0"), ContainSubstring("version: 3.0.0")))
+		})
+	})
+
+	Describe("pin", func() {
+		It("should add an installed package that isn't configured at its installed version, and hold it", func() {
+			setUp("packageManagers:\n  - name: scoop\n    packages: []\n")
+			packageManagerDouble.InstalledVersions["package1"] = "1.2.0"
+
+			Expect(packageCommand.Execute([]string{"pin", "scoop", "package1"}, FlagValues{})).To(Succeed())
+
+			Expect(readConfigFile(configLocation)).To(ContainSubstring(
+				"- name: package1\n        version: 1.2.0\n        pinned: true\n"))
+			Expect(packageManagerDouble.HeldPackages).To(HaveKey("package1"))
+		})
+
+		It("should add a package that isn't installed or configured at the given version", func() {
+			setUp("packageManagers:\n  - name: scoop\n    packages: []\n")
+
+			Expect(packageCommand.Execute([]string{"pin", "scoop", "package1", "2.0.0"}, FlagValues{})).To(Succeed())
+
+			Expect(readConfigFile(configLocation)).To(ContainSubstring(
+				"- name: package1\n        version: 2.0.0\n        pinned: true\n"))
+			Expect(packageManagerDouble.HeldPackages).To(BeEmpty())
+		})
+
+		It("should return an error for a package that isn't installed or configured when no version is given",
+			func() {
+				setUp("packageManagers:\n  - name: scoop\n    packages: []\n")
+				contents := readConfigFile(configLocation)
+
+				Expect(packageCommand.Execute([]string{"pin", "scoop", "package1"}, FlagValues{})).To(MatchError(
+					"package \"package1\" is not installed or configured, so a version must be given"))
+				Expect(readConfigFile(configLocation)).To(Equal(contents))
+			})
+	})
+
+	Describe("unpin", func() {
+		It("should unpin a pinned package and release its hold", func() {
+			setUp("packageManagers:\n  - name: scoop\n    packages:\n      - name: package1\n        version: 1.0.0\n" +
+				"        pinned: true\n")
+			packageManagerDouble.InstalledVersions["package1"] = "1.0.0"
+			packageManagerDouble.HeldPackages["package1"] = true
+
+			Expect(packageCommand.Execute([]string{"unpin", "scoop", "package1"}, FlagValues{})).To(Succeed())
+
+			Expect(readConfigFile(configLocation)).NotTo(ContainSubstring("pinned"))
+			Expect(packageManagerDouble.HeldPackages).To(BeEmpty())
+		})
+
+		It("should return an error for a package that isn't pinned", func() {
+			setUp("packageManagers:\n  - name: scoop\n    packages:\n      - name: package1\n        version: 1.0.0\n")
+
+			Expect(packageCommand.Execute([]string{"unpin", "scoop", "package1"}, FlagValues{})).To(
+				MatchError("package not pinned"))
+		})
+	})
+})
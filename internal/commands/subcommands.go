@@ -0,0 +1,256 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/colececil/familiar.sh/internal/collections"
+	"strings"
+)
+
+// A CommandWithSubcommands is a Command whose arguments are handled by a tree of subcommands. The "help" command can
+// describe each subcommand in the tree, at any depth.
+type CommandWithSubcommands interface {
+	Command
+
+	// Subcommands returns the root of the command's tree of subcommands, which represents the command itself. The
+	// "help" command uses it to describe the subcommands, without calling their Run functions.
+	Subcommands() *Subcommand
+}
+
+// An Argument describes a positional argument of a Subcommand.
+type Argument struct {
+	// Name is the name of the argument, as it appears in usage lines, such as "packageManager" in "<packageManager>".
+	Name string
+
+	// Description is a short description of the argument.
+	Description string
+
+	// Optional is whether the argument can be left out. Optional arguments must come after all required ones.
+	Optional bool
+
+	// Repeated is whether the argument can be given more than once. Only the last argument can be repeated.
+	Repeated bool
+}
+
+// usage returns how the argument appears in usage lines, such as "<packageManager>" or "[<path>]".
+func (argument Argument) usage() string {
+	usage := "<" + argument.Name + ">"
+	if argument.Repeated {
+		usage += "..."
+	}
+	if argument.Optional {
+		usage = "[" + usage + "]"
+	}
+	return usage
+}
+
+// A Subcommand is a node in the tree of subcommands of a command, such as "context" in "familiar config context add".
+// A subcommand can be run itself, have subcommands of its own, or both. When it has both, an argument matching the
+// name of one of its subcommands runs that subcommand.
+type Subcommand struct {
+	// Name is the name of the subcommand, as it appears on the command line.
+	Name string
+
+	// Description is a short description of the subcommand.
+	Description string
+
+	// Documentation is detailed documentation for the subcommand. It can be empty if the description is enough.
+	Documentation string
+
+	// Arguments describes the positional arguments the subcommand takes when it is run. The number of arguments given
+	// is checked against them before Run is called.
+	Arguments []Argument
+
+	// Usages contains the ways the subcommand can be given its arguments, such as "<path>" and "<store> <url>", for
+	// when they can't be described by Arguments alone. If it is empty, the usage is generated from Arguments.
+	Usages []string
+
+	// Subcommands contains the subcommands of the subcommand, if any.
+	Subcommands []*Subcommand
+
+	// Run runs the subcommand with the given positional arguments and flag values. If it is nil, one of the subcommands
+	// must be given.
+	Run func(args []string, flags FlagValues) error
+}
+
+// Execute runs the subcommand named by the first of the given arguments, if there is one, or otherwise runs this
+// subcommand with the given arguments.
+//
+// It takes the following parameters:
+//   - args: The positional arguments that come after the subcommand's name.
+//   - flags: The values of the flags given on the command line.
+func (subcommand *Subcommand) Execute(args []string, flags FlagValues) error {
+	if len(args) > 0 {
+		if childSubcommand := subcommand.findSubcommand(args[0]); childSubcommand != nil {
+			return childSubcommand.Execute(args[1:], flags)
+		}
+	}
+
+	if subcommand.Run == nil {
+		if len(args) == 0 {
+			return fmt.Errorf("subcommand must be included")
+		}
+		return fmt.Errorf("unknown subcommand %q", args[0])
+	}
+
+	minimumArgs, maximumArgs := subcommand.argumentCounts()
+	if len(args) < minimumArgs || (maximumArgs >= 0 && len(args) > maximumArgs) {
+		if maximumArgs == 0 && len(subcommand.Subcommands) > 0 {
+			return fmt.Errorf("unknown subcommand %q", args[0])
+		}
+		return fmt.Errorf("wrong number of arguments")
+	}
+
+	return subcommand.Run(args, flags)
+}
+
+// Find returns the subcommand at the given path below this one, such as ["context", "add"].
+func (subcommand *Subcommand) Find(path []string) (*Subcommand, error) {
+	currentSubcommand := subcommand
+	for i, name := range path {
+		childSubcommand := currentSubcommand.findSubcommand(name)
+		if childSubcommand == nil {
+			return nil, fmt.Errorf("unknown subcommand: %s", strings.Join(path[:i+1], " "))
+		}
+		currentSubcommand = childSubcommand
+	}
+	return currentSubcommand, nil
+}
+
+// resolvePath returns the names of the subcommands below this one that the given positional arguments lead to, in the
+// same way as Execute, such as ["context", "add"] for the arguments "context add team".
+func (subcommand *Subcommand) resolvePath(args []string) []string {
+	var path []string
+	currentSubcommand := subcommand
+	for _, arg := range args {
+		childSubcommand := currentSubcommand.findSubcommand(arg)
+		if childSubcommand == nil {
+			break
+		}
+		path = append(path, arg)
+		currentSubcommand = childSubcommand
+	}
+	return path
+}
+
+// HelpText returns the help output for the subcommand, made up of its description, its documentation, its usage, and
+// the arguments, subcommands, and flags it takes.
+//
+// It takes the following parameters:
+//   - path: The names of the command and subcommands leading to this subcommand, such as ["config", "location"].
+//   - flags: The flags of the command. The command itself lists all of them, while its subcommands only list those
+//     that are declared for their full path below the command, such as "context layers".
+func (subcommand *Subcommand) HelpText(path []string, flags []Flag) string {
+	var textBuilder strings.Builder
+	textBuilder.WriteString(fmt.Sprintf("%s - %s\n", strings.Join(path, " "), subcommand.Description))
+	if subcommand.Documentation != "" {
+		textBuilder.WriteString(fmt.Sprintf("\n%s\n", subcommand.Documentation))
+	}
+
+	textBuilder.WriteString("\nUsage:\n")
+	for _, usage := range subcommand.usageLines(path) {
+		textBuilder.WriteString(fmt.Sprintf("  %s\n", usage))
+	}
+
+	if len(subcommand.Arguments) > 0 {
+		var names, descriptions []string
+		for _, argument := range subcommand.Arguments {
+			names = append(names, "<"+argument.Name+">")
+			descriptions = append(descriptions, argument.Description)
+		}
+		textBuilder.WriteString("\nArguments:\n" + formatColumns(names, descriptions))
+	}
+
+	if len(subcommand.Subcommands) > 0 {
+		var names, descriptions []string
+		for _, childSubcommand := range subcommand.Subcommands {
+			names = append(names, childSubcommand.Name)
+			descriptions = append(descriptions, childSubcommand.Description)
+		}
+		textBuilder.WriteString("\nSubcommands:\n" + formatColumns(names, descriptions))
+		textBuilder.WriteString(fmt.Sprintf("\nRun \"familiar help %s <subcommand>\" for more information about a "+
+			"subcommand.\n", strings.Join(path, " ")))
+	}
+
+	subcommandPath := strings.Join(path[1:], " ")
+	var subcommandFlags []Flag
+	for _, flag := range flags {
+		if len(path) == 1 || collections.Contains(flag.Subcommands, subcommandPath) {
+			subcommandFlags = append(subcommandFlags, flag)
+		}
+	}
+	if len(subcommandFlags) > 0 {
+		textBuilder.WriteString("\nFlags:\n" + FormatFlags(subcommandFlags))
+	}
+
+	return textBuilder.String()
+}
+
+// usageLines returns the ways the subcommand can be run, each starting with "familiar" and the given path.
+func (subcommand *Subcommand) usageLines(path []string) []string {
+	prefix := "familiar " + strings.Join(path, " ")
+
+	var usageLines []string
+	if subcommand.Run != nil {
+		usages := subcommand.Usages
+		if len(usages) == 0 {
+			var argumentUsages []string
+			for _, argument := range subcommand.Arguments {
+				argumentUsages = append(argumentUsages, argument.usage())
+			}
+			usages = []string{strings.Join(argumentUsages, " ")}
+		}
+
+		for _, usage := range usages {
+			usageLines = append(usageLines, strings.TrimSpace(prefix+" "+usage))
+		}
+	}
+
+	if len(subcommand.Subcommands) > 0 {
+		usageLines = append(usageLines, prefix+" <subcommand> [<args>]")
+	}
+	return usageLines
+}
+
+// argumentCounts returns the minimum and maximum number of arguments the subcommand can be run with. The maximum is -1
+// if the last argument can be repeated.
+func (subcommand *Subcommand) argumentCounts() (int, int) {
+	minimumArgs := 0
+	for _, argument := range subcommand.Arguments {
+		if argument.Repeated {
+			if !argument.Optional {
+				minimumArgs++
+			}
+			return minimumArgs, -1
+		}
+		if !argument.Optional {
+			minimumArgs++
+		}
+	}
+	return minimumArgs, len(subcommand.Arguments)
+}
+
+// findSubcommand returns the direct subcommand of the given name, or nil if there is none.
+func (subcommand *Subcommand) findSubcommand(name string) *Subcommand {
+	for _, childSubcommand := range subcommand.Subcommands {
+		if childSubcommand.Name == name {
+			return childSubcommand
+		}
+	}
+	return nil
+}
+
+// formatColumns returns the given names and descriptions as two aligned columns, with one line for each name.
+func formatColumns(names []string, descriptions []string) string {
+	nameWidth := 0
+	for _, name := range names {
+		if len(name) > nameWidth {
+			nameWidth = len(name)
+		}
+	}
+
+	var textBuilder strings.Builder
+	for i, name := range names {
+		textBuilder.WriteString(fmt.Sprintf("  %-*s  %s\n", nameWidth, name, descriptions[i]))
+	}
+	return textBuilder.String()
+}
@@ -0,0 +1,129 @@
+package commands_test
+
+import (
+	. "github.com/colececil/familiar.sh/internal/commands"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Subcommand", func() {
+	var ranSubcommand string
+	var ranArgs []string
+	var tree *Subcommand
+
+	run := func(name string) func(args []string, flags FlagValues) error {
+		return func(args []string, flags FlagValues) error {
+			ranSubcommand = name
+			ranArgs = args
+			return nil
+		}
+	}
+
+	BeforeEach(func() {
+		ranSubcommand = ""
+		ranArgs = nil
+		tree = &Subcommand{
+			Name:        "config",
+			Description: "Manage the config.",
+			Run:         run("config"),
+			Subcommands: []*Subcommand{
+				{
+					Name:        "context",
+					Description: "List the contexts.",
+					Run:         run("context"),
+					Subcommands: []*Subcommand{
+						{
+							Name:        "layers",
+							Description: "Create a layered context.",
+							Arguments: []Argument{
+								{Name: "name", Description: "The name of the context."},
+								{Name: "layer", Repeated: true, Description: "A layer."},
+							},
+							Run: run("context layers"),
+						},
+					},
+				},
+				{
+					Name:        "location",
+					Description: "Print or set the location.",
+					Arguments:   []Argument{{Name: "path", Optional: true, Description: "The path."}},
+					Run:         run("location"),
+				},
+				{
+					Name:        "nested",
+					Description: "Requires a subcommand.",
+					Subcommands: []*Subcommand{{Name: "leaf", Description: "A leaf.", Run: run("nested leaf")}},
+				},
+			},
+		}
+	})
+
+	Describe("Execute", func() {
+		It("should run the subcommand at any depth with the remaining arguments", func() {
+			Expect(tree.Execute([]string{}, FlagValues{})).To(Succeed())
+			Expect(ranSubcommand).To(Equal("config"))
+
+			Expect(tree.Execute([]string{"context"}, FlagValues{})).To(Succeed())
+			Expect(ranSubcommand).To(Equal("context"))
+
+			Expect(tree.Execute([]string{"context", "layers", "work", "team", "default"}, FlagValues{})).To(Succeed())
+			Expect(ranSubcommand).To(Equal("context layers"))
+			Expect(ranArgs).To(Equal([]string{"work", "team", "default"}))
+
+			Expect(tree.Execute([]string{"location", "config.yaml"}, FlagValues{})).To(Succeed())
+			Expect(ranSubcommand).To(Equal("location"))
+			Expect(ranArgs).To(Equal([]string{"config.yaml"}))
+		})
+
+		It("should return an error for unknown subcommands and wrong numbers of arguments", func() {
+			Expect(tree.Execute([]string{"bogus"}, FlagValues{})).To(MatchError(`unknown subcommand "bogus"`))
+			Expect(tree.Execute([]string{"context", "bogus"}, FlagValues{})).To(
+				MatchError(`unknown subcommand "bogus"`))
+			Expect(tree.Execute([]string{"nested"}, FlagValues{})).To(MatchError("subcommand must be included"))
+			Expect(tree.Execute([]string{"context", "layers", "work"}, FlagValues{})).To(
+				MatchError("wrong number of arguments"))
+			Expect(tree.Execute([]string{"location", "a", "b"}, FlagValues{})).To(
+				MatchError("wrong number of arguments"))
+			Expect(ranSubcommand).To(BeEmpty())
+		})
+	})
+
+	Describe("Find", func() {
+		It("should find subcommands at any depth", func() {
+			Expect(tree.Find([]string{})).To(BeIdenticalTo(tree))
+			Expect(tree.Find([]string{"context", "layers"})).To(BeIdenticalTo(tree.Subcommands[0].Subcommands[0]))
+
+			_, err := tree.Find([]string{"context", "bogus"})
+			Expect(err).To(MatchError("unknown subcommand: context bogus"))
+		})
+	})
+
+	Describe("HelpText", func() {
+		It("should describe the usage, arguments, subcommands, and flags of the subcommand", func() {
+			flags := []Flag{
+				{Name: "effective", Description: "Print the effective config."},
+				{Name: "no-save", Description: "Don't save.", Subcommands: []string{"context layers"}},
+				{Name: "all", Description: "List all contexts.", Subcommands: []string{"context"}},
+			}
+
+			layers := tree.Subcommands[0].Subcommands[0]
+			Expect(layers.HelpText([]string{"config", "context", "layers"}, flags)).To(Equal(
+				"config context layers - Create a layered context.\n\n" +
+					"Usage:\n  familiar config context layers <name> <layer>...\n\n" +
+					"Arguments:\n  <name>   The name of the context.\n  <layer>  A layer.\n\n" +
+					"Flags:\n  --no-save  Don't save. (only for: context layers)\n"))
+			Expect(tree.Subcommands[0].HelpText([]string{"config", "context"}, flags)).To(HaveSuffix(
+				"Flags:\n  --all  List all contexts. (only for: context)\n"))
+
+			Expect(tree.Subcommands[1].HelpText([]string{"config", "location"}, flags)).To(Equal(
+				"config location - Print or set the location.\n\n" +
+					"Usage:\n  familiar config location [<path>]\n\n" +
+					"Arguments:\n  <path>  The path.\n"))
+
+			Expect(tree.HelpText([]string{"config"}, flags)).To(ContainSubstring(
+				"Usage:\n  familiar config\n  familiar config <subcommand> [<args>]\n\n" +
+					"Subcommands:\n  context   List the contexts.\n"))
+			Expect(tree.HelpText([]string{"config"}, flags)).To(ContainSubstring("--effective"))
+		})
+	})
+})
@@ -1,20 +1,31 @@
 package commands
 
-import "fmt"
-
 // VersionCommand represents the "version" command.
 type VersionCommand struct {
 	// Version is the current version of Familiar.
 	Version FamiliarVersionString
+	output  *Output
+}
+
+// VersionResult is the result of the "version" command.
+type VersionResult struct {
+	// Version is the installed version of Familiar.sh, in the form "X.Y.Z".
+	Version string `yaml:"version"`
+}
+
+// Text returns the result as human-readable text.
+func (versionResult VersionResult) Text() string {
+	return "Familiar.sh v" + versionResult.Version + "\n"
 }
 
 // FamiliarVersionString represents a version of Familiar.sh.
 type FamiliarVersionString string
 
 // NewVersionCommand returns a new instance of VersionCommand.
-func NewVersionCommand(version FamiliarVersionString) *VersionCommand {
+func NewVersionCommand(version FamiliarVersionString, output *Output) *VersionCommand {
 	return &VersionCommand{
 		Version: version,
+		output:  output,
 	}
 }
 
@@ -32,16 +43,23 @@ func (versionCommand *VersionCommand) Description() string {
 func (versionCommand *VersionCommand) Documentation() string {
 	return `Print the version of Familiar that is currently installed.
 
-The version is displayed in the form "vX.Y.Z", where X is the major version number, Y is the minor version number, and Z is the patch number.`
+The version is displayed in the form "vX.Y.Z", where X is the major version number, Y is the minor version number, and Z is the patch number.
+
+With "--output json" or "--output yaml", the result is an object with a "version" property, in the form "X.Y.Z".`
+}
+
+// Flags returns the flags the command accepts, in addition to the global flags.
+func (versionCommand *VersionCommand) Flags() []Flag {
+	return nil
 }
 
 // Execute runs the command with the given arguments.
 //
 // It takes the following parameters:
-//   - args: A slice containing the arguments to pass in to the command.
+//   - args: A slice containing the positional arguments to pass in to the command, with any flags removed.
+//   - flags: The values of the flags given on the command line, including the global flags.
 //
 // If there is an error executing the command, Execute will return an error that can be displayed to the user.
-func (versionCommand *VersionCommand) Execute(args []string) error {
-	fmt.Println("Familiar.sh v" + versionCommand.Version)
-	return nil
+func (versionCommand *VersionCommand) Execute(args []string, flags FlagValues) error {
+	return versionCommand.output.Result(VersionResult{Version: string(versionCommand.Version)})
 }
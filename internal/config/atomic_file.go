@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomically writes the given contents to the file at the given path, so that the file either keeps its old
+// contents or has all the new contents, even if the process is interrupted. The contents are written to a temporary
+// file in the same directory and flushed to disk before it is renamed over the original file. If the file already
+// exists, its permissions are kept.
+//
+// It takes the following parameters:
+//   - path: The path of the file to write.
+//   - contents: The contents to write.
+//   - perm: The permissions to use if the file doesn't exist yet.
+func writeFileAtomically(path string, contents []byte, perm os.FileMode) error {
+	if fileInfo, err := os.Stat(path); err == nil {
+		perm = fileInfo.Mode().Perm()
+	}
+
+	dir := filepath.Dir(path)
+	tempFile, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file for \"%s\": %w", path, err)
+	}
+	tempPath := tempFile.Name()
+
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			_ = tempFile.Close()
+			_ = os.Remove(tempPath)
+		}
+	}()
+
+	if _, err = tempFile.Write(contents); err != nil {
+		return fmt.Errorf("unable to write \"%s\": %w", tempPath, err)
+	}
+	if err = tempFile.Sync(); err != nil {
+		return fmt.Errorf("unable to flush \"%s\" to disk: %w", tempPath, err)
+	}
+	if err = tempFile.Close(); err != nil {
+		return fmt.Errorf("unable to write \"%s\": %w", tempPath, err)
+	}
+	if err = os.Chmod(tempPath, perm); err != nil {
+		return fmt.Errorf("unable to set permissions of \"%s\": %w", tempPath, err)
+	}
+	if err = os.Rename(tempPath, path); err != nil {
+		return fmt.Errorf("unable to replace \"%s\": %w", path, err)
+	}
+	succeeded = true
+
+	syncDirectory(dir)
+	return nil
+}
+
+// syncDirectory flushes the given directory to disk, so that a file renamed into it isn't lost if the machine crashes.
+// This isn't supported on every platform (for example, Windows), so any error is ignored.
+func syncDirectory(dir string) {
+	directory, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer func(directory *os.File) {
+		_ = directory.Close()
+	}(directory)
+
+	_ = directory.Sync()
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"github.com/colececil/familiar.sh/internal/collections"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+// DescribeConfigChanges returns a description of each change between the given old and new configurations, such as
+// packages that were added, removed, updated, pinned, or unpinned.
+//
+// It takes the following parameters:
+//   - oldConfig: The configuration before the change.
+//   - newConfig: The configuration after the change.
+func DescribeConfigChanges(oldConfig *Config, newConfig *Config) []string {
+	var changes []string
+
+	for _, includeEntry := range newConfig.Include {
+		if !collections.Contains(oldConfig.Include, includeEntry) {
+			changes = append(changes, fmt.Sprintf("add include %q", includeEntry))
+		}
+	}
+	for _, includeEntry := range oldConfig.Include {
+		if !collections.Contains(newConfig.Include, includeEntry) {
+			changes = append(changes, fmt.Sprintf("remove include %q", includeEntry))
+		}
+	}
+
+	oldFiles, newFiles := fileSourcePaths(oldConfig), fileSourcePaths(newConfig)
+	for _, sourcePath := range newFiles {
+		if !collections.Contains(oldFiles, sourcePath) {
+			changes = append(changes, fmt.Sprintf("add file %q", sourcePath))
+		}
+	}
+	for _, sourcePath := range oldFiles {
+		if !collections.Contains(newFiles, sourcePath) {
+			changes = append(changes, fmt.Sprintf("remove file %q", sourcePath))
+		}
+	}
+
+	oldScripts, newScripts := scriptSourcePaths(oldConfig), scriptSourcePaths(newConfig)
+	for _, sourcePath := range newScripts {
+		if !collections.Contains(oldScripts, sourcePath) {
+			changes = append(changes, fmt.Sprintf("add script %q", sourcePath))
+		}
+	}
+	for _, sourcePath := range oldScripts {
+		if !collections.Contains(newScripts, sourcePath) {
+			changes = append(changes, fmt.Sprintf("remove script %q", sourcePath))
+		}
+	}
+
+	for _, newPackageManager := range newConfig.PackageManagers {
+		oldPackageManager := findConfiguredPackageManager(oldConfig, newPackageManager.Name)
+		if oldPackageManager == nil {
+			changes = append(changes, fmt.Sprintf("add package manager %q", newPackageManager.Name))
+			oldPackageManager = &ConfiguredPackageManager{Name: newPackageManager.Name}
+		}
+
+		changes = append(changes, describePackageChanges(oldPackageManager, &newPackageManager)...)
+	}
+	for _, oldPackageManager := range oldConfig.PackageManagers {
+		if findConfiguredPackageManager(newConfig, oldPackageManager.Name) == nil {
+			changes = append(changes, fmt.Sprintf("remove package manager %q", oldPackageManager.Name))
+		}
+	}
+
+	return changes
+}
+
+// describePackageChanges returns a description of each change to the packages of the given package manager.
+func describePackageChanges(oldPackageManager *ConfiguredPackageManager,
+	newPackageManager *ConfiguredPackageManager) []string {
+	var changes []string
+
+	oldPackages := map[string]ConfiguredPackage{}
+	for _, oldPackage := range oldPackageManager.Packages {
+		oldPackages[oldPackage.Name] = oldPackage
+	}
+
+	newPackageNames := map[string]bool{}
+	for _, newPackage := range newPackageManager.Packages {
+		newPackageNames[newPackage.Name] = true
+		oldPackage, isPresent := oldPackages[newPackage.Name]
+		if !isPresent {
+			changes = append(changes, fmt.Sprintf("add package %q %s under package manager %q", newPackage.Name,
+				newPackage.Version, newPackageManager.Name))
+			continue
+		}
+
+		if oldPackage.Version != newPackage.Version {
+			changes = append(changes, fmt.Sprintf("update package %q under package manager %q from %s to %s",
+				newPackage.Name, newPackageManager.Name, oldPackage.Version, newPackage.Version))
+		}
+		if !oldPackage.Pinned && newPackage.Pinned {
+			changes = append(changes, fmt.Sprintf("pin package %q under package manager %q", newPackage.Name,
+				newPackageManager.Name))
+		} else if oldPackage.Pinned && !newPackage.Pinned {
+			changes = append(changes, fmt.Sprintf("unpin package %q under package manager %q", newPackage.Name,
+				newPackageManager.Name))
+		}
+	}
+
+	for _, oldPackage := range oldPackageManager.Packages {
+		if !newPackageNames[oldPackage.Name] {
+			changes = append(changes, fmt.Sprintf("remove package %q under package manager %q", oldPackage.Name,
+				newPackageManager.Name))
+		}
+	}
+
+	return changes
+}
+
+// findConfiguredPackageManager returns the package manager with the given name in the given Config, or nil if it isn't
+// there.
+func findConfiguredPackageManager(config *Config, packageManagerName string) *ConfiguredPackageManager {
+	for i := range config.PackageManagers {
+		if config.PackageManagers[i].Name == packageManagerName {
+			return &config.PackageManagers[i]
+		}
+	}
+	return nil
+}
+
+// fileSourcePaths returns the source paths of the files in the given Config.
+func fileSourcePaths(config *Config) []string {
+	var sourcePaths []string
+	for _, configuredFile := range config.Files {
+		sourcePaths = append(sourcePaths, configuredFile.SourcePath)
+	}
+	return sourcePaths
+}
+
+// scriptSourcePaths returns the source paths of the scripts in the given Config.
+func scriptSourcePaths(config *Config) []string {
+	var sourcePaths []string
+	for _, configuredScript := range config.Scripts {
+		sourcePaths = append(sourcePaths, configuredScript.SourcePath)
+	}
+	return sourcePaths
+}
+
+// describeConfigFileChange returns a description of the change from the given old contents of the shared config file to
+// the given new contents, which stores can record with the change (for example, as a commit message). If the change
+// can't be described, a generic description is returned.
+func describeConfigFileChange(oldContents []byte, newContents []byte) string {
+	var oldConfig, newConfig Config
+	if yaml.Unmarshal(oldContents, &oldConfig) != nil || yaml.Unmarshal(newContents, &newConfig) != nil {
+		return "Familiar.sh: update config"
+	}
+
+	changes := DescribeConfigChanges(&oldConfig, &newConfig)
+	switch len(changes) {
+	case 0:
+		return "Familiar.sh: update config"
+	case 1:
+		return "Familiar.sh: " + changes[0]
+	default:
+		return fmt.Sprintf("Familiar.sh: %s, and %d more changes\n\n- %s", changes[0], len(changes)-1,
+			strings.Join(changes, "\n- "))
+	}
+}
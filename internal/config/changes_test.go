@@ -0,0 +1,46 @@
+package config_test
+
+import (
+	. "github.com/colececil/familiar.sh/internal/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DescribeConfigChanges", func() {
+	It("should describe each change to the configuration", func() {
+		oldConfig := &Config{
+			Files: []ConfiguredFile{{SourcePath: ".bashrc"}},
+			PackageManagers: []ConfiguredPackageManager{
+				{Name: "scoop", Packages: []ConfiguredPackage{
+					{Name: "package1", Version: "1.0.0"},
+					{Name: "package2", Version: "2.0.0"},
+					{Name: "package3", Version: "3.0.0"},
+				}},
+			},
+		}
+		newConfig := &Config{
+			Scripts: []ConfiguredScript{{SourcePath: "setup.sh"}},
+			PackageManagers: []ConfiguredPackageManager{
+				{Name: "scoop", Packages: []ConfiguredPackage{
+					{Name: "package1", Version: "1.1.0"},
+					{Name: "package2", Version: "2.0.0", Pinned: true},
+					{Name: "package4", Version: "4.0.0"},
+				}},
+			},
+		}
+
+		Expect(DescribeConfigChanges(oldConfig, newConfig)).To(Equal([]string{
+			`remove file ".bashrc"`,
+			`add script "setup.sh"`,
+			`update package "package1" under package manager "scoop" from 1.0.0 to 1.1.0`,
+			`pin package "package2" under package manager "scoop"`,
+			`add package "package4" 4.0.0 under package manager "scoop"`,
+			`remove package "package3" under package manager "scoop"`,
+		}))
+	})
+
+	It("should return no changes for identical configurations", func() {
+		config := &Config{PackageManagers: []ConfiguredPackageManager{{Name: "scoop"}}}
+		Expect(DescribeConfigChanges(config, config)).To(BeEmpty())
+	})
+})
@@ -10,9 +10,15 @@ import (
 // Config represents the contents of the config file.
 type Config struct {
 	Version         int                        `yaml:"version"`
+	Include         []string                   `yaml:"include,omitempty"`
 	Files           []ConfiguredFile           `yaml:"files"`
 	Scripts         []ConfiguredScript         `yaml:"scripts"`
 	PackageManagers []ConfiguredPackageManager `yaml:"packageManagers"`
+	Remove          ConfigRemovals             `yaml:"remove,omitempty"`
+
+	// sharedConfigHash is the hash of the shared config file's contents when this Config was read from it, used to
+	// detect whether the file was changed by another process or machine before this Config is written back.
+	sharedConfigHash string
 }
 
 // ConfiguredFile represents a file managed by Familiar.sh.
@@ -20,12 +26,14 @@ type ConfiguredFile struct {
 	SourcePath       string                      `yaml:"sourcePath"`
 	DestinationPath  string                      `yaml:"destinationPath,omitempty"`
 	OperatingSystems []ConfiguredOperatingSystem `yaml:"operatingSystems,omitempty"`
+	Tags             []string                    `yaml:"tags,omitempty"`
 }
 
 // ConfiguredScript represents a script managed by Familiar.sh.
 type ConfiguredScript struct {
 	SourcePath       string                      `yaml:"sourcePath"`
 	OperatingSystems []ConfiguredOperatingSystem `yaml:"operatingSystems,omitempty"`
+	Tags             []string                    `yaml:"tags,omitempty"`
 }
 
 // ConfiguredPackageManager represents a package manager installed by Familiar.sh.
@@ -36,8 +44,19 @@ type ConfiguredPackageManager struct {
 
 // ConfiguredPackage represents a package installed by a specific package manager.
 type ConfiguredPackage struct {
-	Name    string `yaml:"name"`
-	Version string `yaml:"version"`
+	Name    string   `yaml:"name"`
+	Version string   `yaml:"version"`
+	Pinned  bool     `yaml:"pinned,omitempty"`
+	Tags    []string `yaml:"tags,omitempty"`
+}
+
+// ConfigRemovals lists entries of earlier layers that a layer of a layered context removes, such as a package that a
+// team baseline config installs but that isn't wanted in a personal config. It is ignored in the first layer, and when
+// no layered context is in use.
+type ConfigRemovals struct {
+	Packages []PackageReference `yaml:"packages,omitempty"`
+	Files    []string           `yaml:"files,omitempty"`
+	Scripts  []string           `yaml:"scripts,omitempty"`
 }
 
 // ConfiguredOperatingSystem represents an OS that a ConfiguredFile or ConfiguredScript is used in.
@@ -46,6 +65,39 @@ type ConfiguredOperatingSystem struct {
 	DestinationPath string `yaml:"destinationPath,omitempty"`
 }
 
+// AppliesToMachine returns whether the file should be synced to a machine with the given tags.
+func (configuredFile *ConfiguredFile) AppliesToMachine(machineTags []string) bool {
+	return tagsMatch(configuredFile.Tags, machineTags)
+}
+
+// AppliesToMachine returns whether the script should be run on a machine with the given tags.
+func (configuredScript *ConfiguredScript) AppliesToMachine(machineTags []string) bool {
+	return tagsMatch(configuredScript.Tags, machineTags)
+}
+
+// AppliesToMachine returns whether the package should be installed on a machine with the given tags.
+func (configuredPackage *ConfiguredPackage) AppliesToMachine(machineTags []string) bool {
+	return tagsMatch(configuredPackage.Tags, machineTags)
+}
+
+// tagsMatch returns whether an entry with the given tags applies to a machine with the given tags. Entries without any
+// tags apply to every machine. Otherwise, the machine must have at least one of the entry's tags.
+func tagsMatch(entryTags []string, machineTags []string) bool {
+	if len(entryTags) == 0 {
+		return true
+	}
+
+	for _, entryTag := range entryTags {
+		for _, machineTag := range machineTags {
+			if entryTag == machineTag {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // YamlString returns the Config contents as a YAML string.
 func (config *Config) YamlString() (string, error) {
 	bytes, err := yaml.Marshal(config)
@@ -59,7 +111,7 @@ func (config *Config) YamlString() (string, error) {
 // NewConfig creates a new instance of Config.
 func NewConfig() *Config {
 	return &Config{
-		Version:         1,
+		Version:         CurrentConfigVersion,
 		Files:           []ConfiguredFile{},
 		Scripts:         []ConfiguredScript{},
 		PackageManagers: []ConfiguredPackageManager{},
@@ -231,3 +283,116 @@ func (config *Config) RemovePackage(packageManagerName string, packageName strin
 	matchingPackageManager.Packages = filteredPackages
 	return nil
 }
+
+// PinPackage updates the Config to pin the given package under the given package manager, so that it is skipped when
+// updating packages. If a version is given, the package's configured version is changed to it as well. If the package
+// is not in the Config yet, it is added at the given version.
+//
+// It throws an error under the following conditions:
+//   - The given package manager is not in the Config.
+//   - The given package is not in the Config under the given package manager, and no version is given.
+//
+// It takes the following parameters:
+//   - packageManagerName: The name of the package manager.
+//   - packageName: The name of the package to pin.
+//   - packageVersion: The version to pin the package at. If nil, the package's configured version is kept.
+func (config *Config) PinPackage(packageManagerName string, packageName string,
+	packageVersion *packagemanagers.Version) error {
+	if !config.HasPackage(packageManagerName, packageName) && packageVersion != nil {
+		if err := config.AddPackage(packageManagerName, packageName, packageVersion); err != nil {
+			return err
+		}
+	}
+
+	matchingPackage, err := config.findPackage(packageManagerName, packageName)
+	if err != nil {
+		return err
+	}
+
+	if packageVersion != nil {
+		matchingPackage.Version = packageVersion.VersionString
+	}
+	matchingPackage.Pinned = true
+	return nil
+}
+
+// UnpinPackage updates the Config to unpin the given package under the given package manager.
+//
+// It throws an error under the following conditions:
+//   - The given package manager is not in the Config.
+//   - The given package is not in the Config under the given package manager.
+//   - The given package is not pinned.
+//
+// It takes the following parameters:
+//   - packageManagerName: The name of the package manager.
+//   - packageName: The name of the package to unpin.
+func (config *Config) UnpinPackage(packageManagerName string, packageName string) error {
+	matchingPackage, err := config.findPackage(packageManagerName, packageName)
+	if err != nil {
+		return err
+	}
+
+	if !matchingPackage.Pinned {
+		return fmt.Errorf("package not pinned")
+	}
+
+	matchingPackage.Pinned = false
+	return nil
+}
+
+// HasPackage returns whether the given package under the given package manager is in the Config.
+//
+// It takes the following parameters:
+//   - packageManagerName: The name of the package manager.
+//   - packageName: The name of the package.
+func (config *Config) HasPackage(packageManagerName string, packageName string) bool {
+	_, err := config.findPackage(packageManagerName, packageName)
+	return err == nil
+}
+
+// IsPackagePinned returns whether the given package under the given package manager is pinned in the Config. If the
+// package is not in the Config, it returns false.
+//
+// It takes the following parameters:
+//   - packageManagerName: The name of the package manager.
+//   - packageName: The name of the package.
+func (config *Config) IsPackagePinned(packageManagerName string, packageName string) bool {
+	matchingPackage, err := config.findPackage(packageManagerName, packageName)
+	if err != nil {
+		return false
+	}
+
+	return matchingPackage.Pinned
+}
+
+// findPackage returns a pointer to the given package under the given package manager, so that it can be modified in
+// place.
+//
+// It throws an error under the following conditions:
+//   - The given package manager is not in the Config.
+//   - The given package is not in the Config under the given package manager.
+//
+// It takes the following parameters:
+//   - packageManagerName: The name of the package manager.
+//   - packageName: The name of the package.
+func (config *Config) findPackage(packageManagerName string, packageName string) (*ConfiguredPackage, error) {
+	var matchingPackageManager *ConfiguredPackageManager
+	for i := range config.PackageManagers {
+		if config.PackageManagers[i].Name == packageManagerName {
+			matchingPackageManager = &config.PackageManagers[i]
+			break
+		}
+	}
+
+	if matchingPackageManager == nil {
+		return nil, fmt.Errorf("package manager not present")
+	}
+
+	for i := range matchingPackageManager.Packages {
+		if matchingPackageManager.Packages[i].Name == packageName {
+			return &matchingPackageManager.Packages[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("package not present")
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// configLockExpiry is how long a lock on the shared config file is valid for. A lock that is older than this is
+// assumed to have been left behind by a process that was interrupted, and is taken over.
+const configLockExpiry = 30 * time.Second
+
+// configLockAttempts is the number of times to try taking a lock on the shared config file before giving up, waiting
+// configLockRetryInterval between attempts.
+const configLockAttempts = 5
+const configLockRetryInterval = 200 * time.Millisecond
+
+// ConfigLock represents a lock on the shared config file, held by a process on one of the machines sharing it. For the
+// file store, it is stored in a lock file next to the shared config file, so that other machines can see it once the
+// file is synced.
+type ConfigLock struct {
+	Host    string    `yaml:"host"`
+	Pid     int       `yaml:"pid"`
+	Expires time.Time `yaml:"expires"`
+
+	release       func() error
+	configService *ConfigService
+	depth         int
+}
+
+// String returns a description of the lock holder, for use in messages.
+func (configLock *ConfigLock) String() string {
+	return fmt.Sprintf("process %d on %q until %s", configLock.Pid, configLock.Host,
+		configLock.Expires.Local().Format(time.RFC3339))
+}
+
+// LockConfig takes a lock on the shared config file, so that other processes and machines don't change it at the same
+// time. The lock must be released by calling Release on the returned ConfigLock. If this ConfigService already holds
+// the lock, the same lock is returned, and it is only released once Release has been called for each call to
+// LockConfig.
+//
+// How the lock is taken depends on the ConfigStore the shared config file is in. For a local file, the lock is a lock
+// file that is synced between machines by whatever syncs the shared config file, so it is only a best effort, and
+// SetConfig also checks whether the file changed since it was read.
+//
+// It throws an error if the lock is held by another process and doesn't expire after waiting briefly.
+func (configService *ConfigService) LockConfig() (*ConfigLock, error) {
+	if configService.heldLock != nil {
+		configService.heldLock.depth++
+		return configService.heldLock, nil
+	}
+
+	location, configStore, err := configService.getConfigStore()
+	if err != nil {
+		return nil, err
+	}
+
+	configLock, err := configStore.Lock(location)
+	if err != nil {
+		return nil, err
+	}
+
+	configLock.configService = configService
+	configLock.depth = 1
+	configService.heldLock = configLock
+	return configLock, nil
+}
+
+// Release releases the lock on the shared config file, once it has been released as many times as it was taken.
+func (configLock *ConfigLock) Release() error {
+	configLock.depth--
+	if configLock.depth > 0 {
+		return nil
+	}
+	if configLock.configService != nil {
+		configLock.configService.heldLock = nil
+	}
+
+	if configLock.release == nil {
+		return nil
+	}
+	return configLock.release()
+}
+
+// acquireLockFile takes a lock by creating the lock file at the given path. If the lock file already exists and hasn't
+// expired, it tries again a few times before giving up. When the returned lock is released, the lock file is only
+// removed if it still belongs to this process, in case it expired and was taken over by another one.
+func acquireLockFile(lockPath string) (*ConfigLock, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	for attempt := 1; ; attempt++ {
+		configLock := &ConfigLock{
+			Host:    host,
+			Pid:     os.Getpid(),
+			Expires: time.Now().Add(configLockExpiry).UTC().Truncate(time.Second),
+		}
+		configLock.release = func() error {
+			return releaseLockFile(configLock, lockPath)
+		}
+
+		if err = os.MkdirAll(filepath.Dir(lockPath), 0700); err != nil {
+			return nil, fmt.Errorf("unable to create lock file directory: %w", err)
+		}
+
+		created, err := createLockFile(configLock, lockPath)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			return configLock, nil
+		}
+
+		existingLock, err := readLockFile(lockPath)
+		if err != nil {
+			return nil, err
+		}
+		if existingLock == nil {
+			continue
+		}
+		if time.Now().After(existingLock.Expires) {
+			if err = os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("unable to remove expired lock file \"%s\": %w", lockPath, err)
+			}
+			continue
+		}
+
+		if attempt >= configLockAttempts {
+			return nil, fmt.Errorf("the config file is locked by %s. If no other Familiar.sh command is running, "+
+				"delete \"%s\" and try again", existingLock, lockPath)
+		}
+		time.Sleep(configLockRetryInterval)
+	}
+}
+
+// releaseLockFile removes the lock file at the given path, if it still belongs to the given lock.
+func releaseLockFile(configLock *ConfigLock, lockPath string) error {
+	existingLock, err := readLockFile(lockPath)
+	if err != nil || existingLock == nil {
+		return err
+	}
+	if existingLock.Host != configLock.Host || existingLock.Pid != configLock.Pid {
+		return nil
+	}
+
+	if err = os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to remove lock file \"%s\": %w", lockPath, err)
+	}
+	return nil
+}
+
+// createLockFile creates the lock file at the given path for the given lock, unless it already exists. It returns
+// whether the file was created.
+func createLockFile(configLock *ConfigLock, lockPath string) (bool, error) {
+	contents, err := yaml.Marshal(configLock)
+	if err != nil {
+		return false, err
+	}
+
+	file, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("unable to create lock file \"%s\": %w", lockPath, err)
+	}
+
+	_, err = file.Write(contents)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(lockPath)
+		return false, fmt.Errorf("unable to write lock file \"%s\": %w", lockPath, err)
+	}
+	return true, nil
+}
+
+// readLockFile reads the lock file at the given path. If the file no longer exists, nil is returned. If the file can't
+// be parsed (for example, because it is only partially synced), it is treated as expiring configLockExpiry after it was
+// last modified.
+func readLockFile(path string) (*ConfigLock, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to read lock file \"%s\": %w", path, err)
+	}
+
+	var configLock ConfigLock
+	if err = yaml.Unmarshal(contents, &configLock); err != nil || configLock.Expires.IsZero() {
+		fileInfo, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return &ConfigLock{Host: "unknown", Expires: fileInfo.ModTime().Add(configLockExpiry)}, nil
+	}
+
+	return &configLock, nil
+}
+
+// configLockPath returns the path of the lock file for the shared config file at the given location. It is a hidden
+// file in the same directory, so it is synced along with the config file.
+func configLockPath(configLocation string) string {
+	return filepath.Join(filepath.Dir(configLocation), "."+filepath.Base(configLocation)+".lock")
+}
@@ -0,0 +1,71 @@
+package config_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/colececil/familiar.sh/internal/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConfigLock", func() {
+	var lockPath string
+	var configService *ConfigService
+
+	BeforeEach(func() {
+		configLocation := useTemporaryConfigLocation()
+		lockPath = filepath.Join(filepath.Dir(configLocation), ".config.yaml.lock")
+		configService = newConfigService()
+	})
+
+	It("should create a lock file with the host and PID, and remove it when released", func() {
+		configLock, err := configService.LockConfig()
+		Expect(err).To(BeNil())
+		Expect(configLock.Pid).To(Equal(os.Getpid()))
+		Expect(configLock.Expires).To(BeTemporally(">", time.Now()))
+
+		contents, err := os.ReadFile(lockPath)
+		Expect(err).To(BeNil())
+		Expect(string(contents)).To(ContainSubstring(fmt.Sprintf("pid: %d", os.Getpid())))
+
+		Expect(configLock.Release()).To(Succeed())
+		Expect(lockPath).ToNot(BeAnExistingFile())
+	})
+
+	It("should only release the lock once it has been released as many times as it was taken", func() {
+		outerLock, err := configService.LockConfig()
+		Expect(err).To(BeNil())
+		innerLock, err := configService.LockConfig()
+		Expect(err).To(BeNil())
+
+		Expect(innerLock.Release()).To(Succeed())
+		Expect(lockPath).To(BeAnExistingFile())
+		Expect(outerLock.Release()).To(Succeed())
+		Expect(lockPath).ToNot(BeAnExistingFile())
+	})
+
+	It("should return an error when the lock is held by another machine", func() {
+		expires := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+		Expect(os.WriteFile(lockPath, []byte("host: other-machine\npid: 1234\nexpires: "+expires+"\n"),
+			0600)).To(Succeed())
+
+		_, err := configService.LockConfig()
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring(`process 1234 on "other-machine"`))
+		Expect(lockPath).To(BeAnExistingFile())
+	})
+
+	It("should take over an expired lock", func() {
+		expires := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+		Expect(os.WriteFile(lockPath, []byte("host: other-machine\npid: 1234\nexpires: "+expires+"\n"),
+			0600)).To(Succeed())
+
+		configLock, err := configService.LockConfig()
+		Expect(err).To(BeNil())
+		Expect(configLock.Pid).To(Equal(os.Getpid()))
+		Expect(configLock.Release()).To(Succeed())
+	})
+})
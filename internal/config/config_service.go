@@ -1,8 +1,13 @@
 package config
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/adrg/xdg"
+	"github.com/colececil/familiar.sh/internal/system"
 	"gopkg.in/yaml.v3"
 	"os"
 	"path/filepath"
@@ -11,65 +16,296 @@ import (
 
 const appDirectoryName = "io.colececil.familiar"
 const configLocationFileName = "config_location"
+const configStoreFileName = "config_store"
 const configLocationNotSetError = "The location of Familiar's shared config file has not yet been set. Please set it " +
 	"using \"familiar config location <path>\", for more details, execute \"familiar help config\"."
 
+// ErrConfigChanged is returned by SetConfig when the shared config file was changed by another process or machine
+// after it was read, so writing it would lose those changes.
+var ErrConfigChanged = errors.New("the config file was changed by another process or machine after it was read, so " +
+	"the changes were not saved. Please try again")
+
 // ConfigService is a service that manages the shared configuration file.
 type ConfigService struct {
+	configStoreRegistry ConfigStoreRegistry
+	messageWriterFunc   system.MessageWriterFunc
+	configOverrideFunc  ConfigOverrideFunc
+	// heldLock is the lock on the shared config file currently held by this ConfigService, if any.
+	heldLock *ConfigLock
 }
 
 // NewConfigService creates a new instance of ConfigService.
-func NewConfigService() *ConfigService {
-	return &ConfigService{}
+func NewConfigService(configStoreRegistry ConfigStoreRegistry, messageWriterFunc system.MessageWriterFunc,
+	configOverrideFunc ConfigOverrideFunc) *ConfigService {
+	return &ConfigService{
+		configStoreRegistry: configStoreRegistry,
+		messageWriterFunc:   messageWriterFunc,
+		configOverrideFunc:  configOverrideFunc,
+	}
 }
 
-// GetConfigLocation returns the location of the shared configuration file, as stored in the "config_location" file in
-// the XDG config directory. If the "config_location" file does not exist or is empty, an empty string is returned.
+// GetConfigLocation returns the local path of the shared configuration file, as resolved by GetConfigStoreLocation.
+// For stores that keep the file somewhere else, this is the path of the local copy.
 func (configService *ConfigService) GetConfigLocation() (string, error) {
-	configDir := xdg.ConfigHome
-
-	configLocationFilePath := configDir + "/" + appDirectoryName + "/" + configLocationFileName
-	bytes, err := os.ReadFile(configLocationFilePath)
+	location, err := configService.GetConfigStoreLocation()
 	if err != nil {
-		return "", fmt.Errorf(configLocationNotSetError)
+		return "", err
 	}
-
-	path := strings.TrimSpace(string(bytes))
-	return path, nil
+	return location.Path, nil
 }
 
-// SetConfigLocation writes the given path to the "config_location" file in the XDG config directory. If the
+// SetConfigLocation sets the shared config file to be the local file at the given path, using the file store. If the
 // directory of the file specified by the given path does not exist, or if the file is not a YAML file, an error is
 // returned.
 func (configService *ConfigService) SetConfigLocation(path string) error {
-	absolutePath, err := filepath.Abs(path)
+	return configService.SetConfigStoreLocation(FileConfigStoreName, path, "")
+}
+
+// SetConfigStoreLocation sets the shared config file to be the one at the given URL in the config store of the given
+// name. The store is attached to the URL, and the location is saved to the current context, or as the default location
+// if no context is in use. It can't be used while the location is overridden by --config or FAMILIAR_CONFIG.
+//
+// It takes the following parameters:
+//   - configStoreName: The name of the config store, such as "file" or "git".
+//   - url: Where the store keeps the shared config file. For the file store, this is the path of the file.
+//   - path: The path of the shared config file within the given URL, for stores where it can hold more than one file.
+//     If empty, the store's default is used.
+func (configService *ConfigService) SetConfigStoreLocation(configStoreName string, url string, path string) error {
+	if override, source := configService.configLocationOverride(); override != "" {
+		return fmt.Errorf("the config file location can't be changed while it is overridden by %s", source)
+	}
+
+	location, err := configService.attachConfigStore(configStoreName, url, path)
 	if err != nil {
-		return fmt.Errorf("unable to parse the given path")
+		return err
 	}
 
-	ext := filepath.Ext(absolutePath)
-	if ext != ".yml" && ext != ".yaml" {
-		return fmt.Errorf("invalid file extension '%s': expected '.yml' or '.yaml'", ext)
+	contexts, err := readConfigContexts()
+	if err != nil {
+		return err
 	}
 
-	dir := filepath.Dir(absolutePath)
-	if _, err := os.Stat(dir); err != nil {
-		if os.IsNotExist(err) {
-			return fmt.Errorf("directory '%s' does not exist", dir)
+	if contexts.Current != "" {
+		configContext := contexts.find(contexts.Current)
+		if configContext == nil {
+			return fmt.Errorf("the current context %q doesn't exist", contexts.Current)
+		}
+		if len(configContext.Layers) > 0 {
+			return fmt.Errorf("the current context %q is layered, so it has no location of its own. Change the "+
+				"location of one of its layers instead", contexts.Current)
 		}
-		return fmt.Errorf("error checking directory '%s': %w", dir, err)
+		configContext.setLocation(location)
+		if err = writeConfigContexts(contexts); err != nil {
+			return err
+		}
+	} else if err = configService.writeDefaultConfigStoreLocation(location); err != nil {
+		return err
 	}
 
-	configDir := xdg.ConfigHome
+	if location.Url == location.Path {
+		_, _ = fmt.Fprintln(configService.messageWriterFunc(), "The config file location has been set to \""+
+			location.Path+"\".")
+	} else {
+		_, _ = fmt.Fprintf(configService.messageWriterFunc(), "The config file location has been set to \"%s\" in "+
+			"%s store \"%s\", with a local copy at \"%s\".\n", pathOrDefault(path), location.Store,
+			location.RedactedUrl(), location.Path)
+	}
+	return nil
+}
 
-	configLocationFilePath := configDir + "/" + appDirectoryName + "/" + configLocationFileName
+// attachConfigStore attaches the config store of the given name to the given URL and path, and returns the resulting
+// location of the shared config file.
+func (configService *ConfigService) attachConfigStore(configStoreName string, url string,
+	path string) (ConfigStoreLocation, error) {
+	configStore, err := configService.configStoreRegistry.GetConfigStore(configStoreName)
+	if err != nil {
+		return ConfigStoreLocation{}, err
+	}
+	return configStore.Attach(url, path)
+}
 
-	err = os.MkdirAll(configDir+"/"+appDirectoryName, 0700)
+// writeDefaultConfigStoreLocation writes the given location as the default location of the shared config file, which is
+// used when no context is in use. The local path of the file is written to the "config_location" file in the XDG
+// config directory, and the store and URL are written to the "config_store" file next to it.
+func (configService *ConfigService) writeDefaultConfigStoreLocation(location ConfigStoreLocation) error {
+	if err := configService.writeConfigLocation(location.Path); err != nil {
+		return err
+	}
+
+	contents, err := yaml.Marshal(location)
 	if err != nil {
 		return err
 	}
+	return os.WriteFile(appDirectoryFilePath(configStoreFileName), contents, 0600)
+}
+
+// GetConfigStoreNames returns the names of the available config stores, sorted alphabetically.
+func (configService *ConfigService) GetConfigStoreNames() []string {
+	return configService.configStoreRegistry.GetConfigStoreNames()
+}
+
+// GetConfigStoreLocation returns where the shared config file is stored. The first of the following that is set is
+// used:
+//  1. The "--config" option, which sets the FAMILIAR_CONFIG environment variable for the command it is given to.
+//  2. The FAMILIAR_CONFIG environment variable, which can be the name of a context or the path of a config file.
+//  3. The current context, as set by UseConfigContext.
+//  4. The default location, as set by SetConfigStoreLocation while no context is in use.
+//
+// If the resulting context is layered, the location of its writable layer is returned, since that is where changes are
+// written.
+func (configService *ConfigService) GetConfigStoreLocation() (ConfigStoreLocation, error) {
+	location, _, err := configService.resolveConfigStoreLocation()
+	return location, err
+}
+
+// GetConfigLocationSource returns a description of where the location of the shared config file comes from, following
+// the order described in GetConfigStoreLocation, for use in messages.
+func (configService *ConfigService) GetConfigLocationSource() (string, error) {
+	_, source, err := configService.resolveConfigStoreLocation()
+	return source, err
+}
 
-	file, err := os.Create(configLocationFilePath)
+// resolveConfigStoreLocation returns where the shared config file is stored, along with a description of where that
+// location comes from, as described in GetConfigStoreLocation.
+func (configService *ConfigService) resolveConfigStoreLocation() (ConfigStoreLocation, string, error) {
+	layers, source, err := configService.resolveConfigLayers()
+	if err != nil {
+		return ConfigStoreLocation{}, "", err
+	}
+
+	writableLayer := writableConfigLayer(layers)
+	if len(layers) > 1 {
+		source = fmt.Sprintf("writable layer %q of %s", writableLayer.name, source)
+	}
+	return writableLayer.location, source, nil
+}
+
+// resolveConfigLayers returns the layers of the shared config in effect, in the order they are applied, along with a
+// description of where they come from. The context or location is chosen as described in GetConfigStoreLocation.
+// Unless a layered context is in use, there is a single, writable layer.
+func (configService *ConfigService) resolveConfigLayers() ([]configLayer, string, error) {
+	contexts, err := readConfigContexts()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if override, source := configService.configLocationOverride(); override != "" {
+		if override == defaultConfigContextName {
+			location, err := configService.GetDefaultConfigStoreLocation()
+			if err != nil {
+				return nil, "", err
+			}
+			return []configLayer{{name: defaultConfigContextName, location: location, writable: true}}, source, nil
+		}
+		if configContext := contexts.find(override); configContext != nil {
+			layers, err := configService.configContextLayers(contexts, configContext)
+			return layers, fmt.Sprintf("context %q, from %s", override, source), err
+		}
+
+		location, err := configService.attachConfigStore(FileConfigStoreName, override, "")
+		if err != nil {
+			return nil, "", fmt.Errorf("%q given by %s is neither a context nor a valid config file path: %w",
+				override, source, err)
+		}
+		return []configLayer{{name: override, location: location, writable: true}}, source, nil
+	}
+
+	if contexts.Current != "" {
+		configContext := contexts.find(contexts.Current)
+		if configContext == nil {
+			return nil, "", fmt.Errorf("the current context %q doesn't exist. Run \"familiar config context use "+
+				"<name>\" to use another one", contexts.Current)
+		}
+		layers, err := configService.configContextLayers(contexts, configContext)
+		return layers, fmt.Sprintf("context %q", contexts.Current), err
+	}
+
+	location, err := configService.GetDefaultConfigStoreLocation()
+	if err != nil {
+		return nil, "", err
+	}
+	return []configLayer{{name: defaultConfigContextName, location: location, writable: true}},
+		"the default location", nil
+}
+
+// configLocationOverride returns the context name or config file path that overrides the location of the shared config
+// file, along with where it was given. The "--config" option takes precedence over the FAMILIAR_CONFIG environment
+// variable. If neither is given, it returns an empty string.
+func (configService *ConfigService) configLocationOverride() (string, string) {
+	if override := configService.configOverrideFunc(); override != "" {
+		return override, "--config"
+	}
+	return os.Getenv(ConfigEnvironmentVariable), ConfigEnvironmentVariable
+}
+
+// GetDefaultConfigStoreLocation returns the default location of the shared config file, which is used when no context
+// is in use. It is read from the "config_location" file in the XDG config directory, and the "config_store" file next
+// to it. If the "config_store" file doesn't exist, the shared config file is a local file using the file store.
+func (configService *ConfigService) GetDefaultConfigStoreLocation() (ConfigStoreLocation, error) {
+	bytes, err := os.ReadFile(appDirectoryFilePath(configLocationFileName))
+	if err != nil {
+		return ConfigStoreLocation{}, fmt.Errorf(configLocationNotSetError)
+	}
+	configLocation := strings.TrimSpace(string(bytes))
+
+	location := ConfigStoreLocation{Store: FileConfigStoreName, Url: configLocation}
+	contents, err := os.ReadFile(appDirectoryFilePath(configStoreFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return ConfigStoreLocation{}, err
+	}
+	if err == nil {
+		if err = yaml.Unmarshal(contents, &location); err != nil {
+			return ConfigStoreLocation{}, fmt.Errorf("unable to parse \"%s\": %w",
+				appDirectoryFilePath(configStoreFileName), err)
+		}
+	}
+
+	location.Path = configLocation
+	return location, nil
+}
+
+// getConfigStore returns where the shared config file is stored, along with the config store that manages it.
+func (configService *ConfigService) getConfigStore() (ConfigStoreLocation, ConfigStore, error) {
+	location, err := configService.GetConfigStoreLocation()
+	if err != nil {
+		return ConfigStoreLocation{}, nil, err
+	}
+
+	configStore, err := configService.configStoreRegistry.GetConfigStore(location.Store)
+	if err != nil {
+		return ConfigStoreLocation{}, nil, err
+	}
+	return location, configStore, nil
+}
+
+// ReadSharedConfigFile returns the current contents of the shared config file, read through the config store that
+// manages it. If the file doesn't exist yet, an error satisfying os.IsNotExist is returned.
+func (configService *ConfigService) ReadSharedConfigFile() ([]byte, error) {
+	location, configStore, err := configService.getConfigStore()
+	if err != nil {
+		return nil, err
+	}
+	return configStore.Read(location)
+}
+
+// WatchSharedConfig calls the given function whenever the shared config file changes, until the returned function is
+// called to stop watching. How changes are noticed depends on the config store that manages the file.
+func (configService *ConfigService) WatchSharedConfig(onChange func()) (func(), error) {
+	location, configStore, err := configService.getConfigStore()
+	if err != nil {
+		return nil, err
+	}
+	return configStore.Watch(location, onChange)
+}
+
+// writeConfigLocation writes the given absolute path to the "config_location" file in the XDG config directory.
+func (configService *ConfigService) writeConfigLocation(absolutePath string) error {
+	err := os.MkdirAll(appDirectoryPath(), 0700)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(appDirectoryFilePath(configLocationFileName))
 	if err != nil {
 		return err
 	}
@@ -78,57 +314,278 @@ func (configService *ConfigService) SetConfigLocation(path string) error {
 	}(file)
 
 	_, err = fmt.Fprintln(file, absolutePath)
-	if err == nil {
-		fmt.Println("The config file location has been set to \"" + absolutePath + "\".")
-	}
 	return err
 }
 
-// GetConfig returns the contents of the config file as a pointer to a Config struct.
+// GetConfig returns the effective configuration for the current machine as a pointer to a Config struct. This is the
+// contents of the shared config file, with any local overrides applied. Since it may contain entries that aren't in the
+// shared config file, it should only be used for reading. To make changes, use GetSharedConfig instead.
 func (configService *ConfigService) GetConfig() (*Config, error) {
+	config, _, err := configService.GetEffectiveConfig()
+	return config, err
+}
+
+// GetEffectiveConfig returns the effective configuration for the current machine, along with a Provenance recording
+// which file each entry came from. The effective configuration is built by taking the shared config file, merging in
+// the files it includes, and then applying the local overrides file. If a layered context is in use, each of its layers
+// is read in the same way and applied on top of the earlier ones with MergeLayer, before the local overrides.
+func (configService *ConfigService) GetEffectiveConfig() (*Config, *Provenance, error) {
+	layers, _, err := configService.resolveConfigLayers()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var config *Config
+	var provenance *Provenance
+	fileDirectories := make(map[string]string)
+	scriptDirectories := make(map[string]string)
+	for _, layer := range layers {
+		layerConfig, layerProvenance, err := configService.readConfigLayer(layer)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if config == nil {
+			config, provenance = layerConfig, layerProvenance
+		} else {
+			MergeLayer(config, layerConfig, provenance, layerProvenance)
+		}
+
+		layerDirectory := filepath.Dir(layer.location.Path)
+		for _, configuredFile := range layerConfig.Files {
+			fileDirectories[configuredFile.SourcePath] = layerDirectory
+		}
+		for _, configuredScript := range layerConfig.Scripts {
+			scriptDirectories[configuredScript.SourcePath] = layerDirectory
+		}
+	}
+
+	config.Remove = ConfigRemovals{}
+	if len(layers) > 1 {
+		resolveLayerSourcePaths(config, provenance, fileDirectories, scriptDirectories,
+			filepath.Dir(writableConfigLayer(layers).location.Path))
+	}
+
+	overrides, err := configService.GetConfigOverrides()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if overrides != nil {
+		provenance.AddSource(ConfigOverridesLocation())
+		if err = overrides.Apply(config, provenance, ConfigOverridesLocation()); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return config, provenance, nil
+}
+
+// GetSharedConfig returns the contents of the shared config file as a pointer to a Config struct. If the file doesn't
+// exist yet, it is created with an empty configuration. The file is read through the config store that manages it.
+func (configService *ConfigService) GetSharedConfig() (*Config, error) {
 	configLocation, err := configService.GetConfigLocation()
 	if err != nil {
 		return nil, err
 	}
 
-	bytes, err := os.ReadFile(configLocation)
+	bytes, err := configService.ReadSharedConfigFile()
 	if err != nil {
 		if os.IsNotExist(err) {
 			newConfig := NewConfig()
+			newConfig.sharedConfigHash = contentHash(nil)
 			if err = configService.SetConfig(newConfig); err != nil {
 				return nil, err
 			}
+			return newConfig, nil
 		} else {
 			return nil, err
 		}
 	}
 
+	migratedBytes, originalVersion, err := MigrateConfig(bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if originalVersion != CurrentConfigVersion {
+		backupLocation := filepath.Join(LocationStateDirectory(configLocation),
+			fmt.Sprintf("%s.v%d.bak", filepath.Base(configLocation), originalVersion))
+		if err = os.MkdirAll(filepath.Dir(backupLocation), 0700); err == nil {
+			err = os.WriteFile(backupLocation, bytes, 0600)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to back up config file before migrating it: %w", err)
+		}
+
+		description := fmt.Sprintf("Familiar.sh: migrate config from version %d to version %d", originalVersion,
+			CurrentConfigVersion)
+		if err = configService.writeSharedConfig(contentHash(bytes), migratedBytes, description); err != nil {
+			return nil, err
+		}
+
+		_, _ = fmt.Fprintf(configService.messageWriterFunc(), "The config file has been migrated from version %d to "+
+			"version %d. A backup of the original was saved to \"%s\".\n", originalVersion, CurrentConfigVersion,
+			backupLocation)
+		bytes = migratedBytes
+	}
+
 	var config Config
 	if err = yaml.Unmarshal(bytes, &config); err != nil {
 		return nil, err
 	}
+	if config.Version == 0 {
+		config.Version = unversionedConfigVersion
+	}
 
+	config.sharedConfigHash = contentHash(bytes)
 	return &config, nil
 }
 
-// SetConfig writes the given configuration to the config file as YAML.
+// SetConfig writes the given configuration to the shared config file as YAML. The given configuration should come from
+// GetSharedConfig, so that local overrides aren't written to the shared config file.
+//
+// If the file already exists, the configuration is merged into its existing contents, so that any comments, the order
+// of keys, and the indentation are kept. The file is replaced atomically, so it is never left partially written, and it
+// isn't written at all if its contents wouldn't change.
+//
+// While writing, a lock is taken on the file using LockConfig. If the file was changed by another process or machine
+// since the given configuration was read by GetSharedConfig, ErrConfigChanged is returned and the file is left as it
+// is.
 //
 // It takes the following parameters:
 //   - config: The configuration to write to the file.
 func (configService *ConfigService) SetConfig(config *Config) error {
-	configLocation, err := configService.GetConfigLocation()
+	configLock, err := configService.LockConfig()
+	if err != nil {
+		return err
+	}
+	defer func(configLock *ConfigLock) {
+		_ = configLock.Release()
+	}(configLock)
+
+	var updatedNode yaml.Node
+	if err = updatedNode.Encode(config); err != nil {
+		return err
+	}
+
+	document := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&updatedNode}}
+	indent := defaultYamlIndent
+
+	existingContents, err := configService.ReadSharedConfigFile()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var existingDocument yaml.Node
+	if len(existingContents) > 0 && yaml.Unmarshal(existingContents, &existingDocument) == nil &&
+		len(existingDocument.Content) > 0 {
+		mergeYamlNode(&existingDocument, document)
+		document = &existingDocument
+		indent = detectYamlIndent(existingContents)
+	}
+
+	contents, err := encodeYamlNode(document, indent)
 	if err != nil {
 		return err
 	}
 
-	file, err := os.Create(configLocation)
+	if existingContents != nil && bytes.Equal(contents, existingContents) {
+		config.sharedConfigHash = contentHash(contents)
+		return nil
+	}
+
+	if err = configService.writeSharedConfig(config.sharedConfigHash, contents, ""); err != nil {
+		return err
+	}
+
+	config.sharedConfigHash = contentHash(contents)
+	return nil
+}
+
+// writeSharedConfig replaces the contents of the shared config file through the config store that manages it, as long
+// as the file hasn't been changed since it was read. Before it is replaced, a snapshot of the current contents is saved
+// to the history directory. If the file already has the given contents, nothing is written and no snapshot is saved.
+//
+// It takes the following parameters:
+//   - expectedHash: The hash of the contents the file is expected to have, as returned by contentHash. If it is empty,
+//     the file is written regardless of its current contents.
+//   - contents: The new contents of the file.
+//   - description: A description of the change, for stores that record one (for example, as a commit message). If it
+//     is empty, a description is generated from the change.
+func (configService *ConfigService) writeSharedConfig(expectedHash string, contents []byte, description string) error {
+	location, configStore, err := configService.getConfigStore()
 	if err != nil {
 		return err
 	}
-	defer func(file *os.File) {
-		_ = file.Close()
-	}(file)
 
-	encoder := yaml.NewEncoder(file)
-	return encoder.Encode(config)
+	configLock, err := configService.LockConfig()
+	if err != nil {
+		return err
+	}
+	defer func(configLock *ConfigLock) {
+		_ = configLock.Release()
+	}(configLock)
+
+	currentContents, err := configStore.Read(location)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if expectedHash != "" && contentHash(currentContents) != expectedHash {
+		return ErrConfigChanged
+	}
+
+	if currentContents != nil && bytes.Equal(currentContents, contents) {
+		return nil
+	}
+
+	if len(currentContents) > 0 {
+		if err = saveSnapshot(location.Path, currentContents); err != nil {
+			return err
+		}
+	}
+
+	if description == "" {
+		description = describeConfigFileChange(currentContents, contents)
+	}
+	return configStore.Write(location, contents, description)
+}
+
+// contentHash returns a hash of the given file contents, used to detect whether a file has changed.
+func contentHash(contents []byte) string {
+	hash := sha256.Sum256(contents)
+	return hex.EncodeToString(hash[:])
+}
+
+// LocationStateDirectory returns the path of the directory where Familiar.sh keeps state about the shared config file
+// at the given location that is local to the current machine, such as the backups made before migrating it to a newer
+// version of the config format. It is in the XDG state directory rather than beside the shared config file, so that
+// each machine's files aren't synced to the others through a cloud drive or committed to a git repository.
+//
+// It takes the following parameters:
+//   - configLocation: The local path of the shared config file.
+func LocationStateDirectory(configLocation string) string {
+	hash := sha256.Sum256([]byte(configLocation))
+	return filepath.Join(xdg.StateHome, appDirectoryName, "locations", hex.EncodeToString(hash[:])[:16])
+}
+
+// appDirectoryPath returns the path of Familiar.sh's directory in the XDG config directory, where settings that are
+// local to the current machine are stored.
+func appDirectoryPath() string {
+	return xdg.ConfigHome + "/" + appDirectoryName
+}
+
+// appDirectoryFilePath returns the path of the file with the given name in Familiar.sh's directory in the XDG config
+// directory.
+func appDirectoryFilePath(fileName string) string {
+	return appDirectoryPath() + "/" + fileName
+}
+
+// pathOrDefault returns the given path within a config store location, or defaultConfigFileName if it is empty.
+func pathOrDefault(path string) string {
+	if path == "" {
+		return defaultConfigFileName
+	}
+	return path
 }
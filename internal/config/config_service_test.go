@@ -0,0 +1,129 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/colececil/familiar.sh/internal/config"
+	"github.com/colececil/familiar.sh/internal/packagemanagers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConfigService", func() {
+	var configLocation string
+	var configService *ConfigService
+
+	BeforeEach(func() {
+		configLocation = useTemporaryConfigLocation()
+		configService = newConfigService()
+	})
+
+	Describe("SetConfig", func() {
+		It("should keep comments, key order, and indentation when changing the config", func() {
+			writeConfigFile(configLocation, `# My shared config.
+version: 1
+packageManagers:
+  # Installed on every machine.
+  - name: scoop
+    packages:
+      - name: package1 # Needed for work.
+        version: 1.0.0
+      # Keep this one around.
+      - name: package2
+        version: 2.0.0
+      - name: package3
+        version: 3.0.0
+files: []
+scripts: []
+`)
+
+			config, err := configService.GetSharedConfig()
+			Expect(err).To(BeNil())
+			Expect(config.UpdatePackage("scoop", "package1", packagemanagers.NewVersion("1.1.0"))).To(Succeed())
+			Expect(config.RemovePackage("scoop", "package3")).To(Succeed())
+			Expect(config.AddPackage("scoop", "package4", packagemanagers.NewVersion("4.0.0"))).To(Succeed())
+			config.Files = append(config.Files, ConfiguredFile{SourcePath: ".bashrc"})
+			Expect(configService.SetConfig(config)).To(Succeed())
+
+			contents, err := os.ReadFile(configLocation)
+			Expect(err).To(BeNil())
+			Expect(string(contents)).To(Equal(`# My shared config.
+version: 1
+packageManagers:
+  # Installed on every machine.
+  - name: scoop
+    packages:
+      - name: package1 # Needed for work.
+        version: 1.1.0
+      # Keep this one around.
+      - name: package2
+        version: 2.0.0
+      - name: package4
+        version: 4.0.0
+files:
+  - sourcePath: .bashrc
+scripts: []
+`))
+		})
+
+		It("should create the file when it doesn't exist yet", func() {
+			Expect(configService.SetConfig(NewConfig())).To(Succeed())
+
+			contents, err := os.ReadFile(configLocation)
+			Expect(err).To(BeNil())
+			Expect(string(contents)).To(Equal("version: 1\nfiles: []\nscripts: []\npackageManagers: []\n"))
+		})
+
+		It("should not overwrite changes made to the file after it was read", func() {
+			writeConfigFile(configLocation, "version: 1\nfiles: []\nscripts: []\npackageManagers: []\n")
+
+			config, err := configService.GetSharedConfig()
+			Expect(err).To(BeNil())
+
+			changedContents := "version: 1\nfiles:\n  - sourcePath: .bashrc\nscripts: []\npackageManagers: []\n"
+			writeConfigFile(configLocation, changedContents)
+
+			config.Scripts = append(config.Scripts, ConfiguredScript{SourcePath: "setup.sh"})
+			Expect(configService.SetConfig(config)).To(MatchError(ErrConfigChanged))
+
+			contents, err := os.ReadFile(configLocation)
+			Expect(err).To(BeNil())
+			Expect(string(contents)).To(Equal(changedContents))
+		})
+
+		It("should add the version to a config file without one when writing it", func() {
+			writeConfigFile(configLocation, "files: []\nscripts: []\npackageManagers: []\n")
+
+			config, err := configService.GetSharedConfig()
+			Expect(err).To(BeNil())
+			Expect(config.Version).To(Equal(CurrentConfigVersion))
+			config.Scripts = append(config.Scripts, ConfiguredScript{SourcePath: "setup.sh"})
+			Expect(configService.SetConfig(config)).To(Succeed())
+
+			contents, err := os.ReadFile(configLocation)
+			Expect(err).To(BeNil())
+			Expect(string(contents)).To(ContainSubstring("version: 1\n"))
+		})
+
+		It("should allow writing the same config more than once", func() {
+			config, err := configService.GetSharedConfig()
+			Expect(err).To(BeNil())
+
+			config.Scripts = append(config.Scripts, ConfiguredScript{SourcePath: "setup.sh"})
+			Expect(configService.SetConfig(config)).To(Succeed())
+			config.Files = append(config.Files, ConfiguredFile{SourcePath: ".bashrc"})
+			Expect(configService.SetConfig(config)).To(Succeed())
+		})
+
+		It("should not leave temporary files behind", func() {
+			Expect(configService.SetConfig(NewConfig())).To(Succeed())
+			Expect(configService.SetConfig(NewConfig())).To(Succeed())
+
+			entries, err := os.ReadDir(filepath.Dir(configLocation))
+			Expect(err).To(BeNil())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Name()).To(Equal(filepath.Base(configLocation)))
+		})
+	})
+})
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"github.com/colececil/familiar.sh/internal/system"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultConfigFileName is the name of the shared config file, for stores where a location can hold more than one file
+// and no path within it is given.
+const defaultConfigFileName = "config.yaml"
+
+// ConfigStore represents a place the shared config file can be stored, such as a local file, a git repository, or an
+// HTTP server.
+//
+// Every store keeps the shared config file at a path on the local machine. For stores that keep the file somewhere
+// else, this is a local copy, which Read brings up to date and Write sends on. This lets the files Familiar.sh keeps
+// beside the shared config file, such as included files, history snapshots, and conflicting copies, be found in the
+// same way for every store.
+type ConfigStore interface {
+	// Name returns the name of the store, as used in "familiar config location <store> <url>".
+	Name() string
+
+	// Attach prepares the store to use the shared config file at the given URL, and returns its location. For stores
+	// that keep a local copy of the file, this is where the copy is first made.
+	//
+	// It takes the following parameters:
+	//   - url: Where the shared config file is stored. For the file store, this is the path of the file.
+	//   - path: The path of the shared config file within the given URL, for stores where it can hold more than one
+	//     file. If empty, "config.yaml" is used.
+	Attach(url string, path string) (ConfigStoreLocation, error)
+
+	// Read returns the current contents of the shared config file at the given location. If the file doesn't exist
+	// yet, an error satisfying os.IsNotExist is returned.
+	Read(location ConfigStoreLocation) ([]byte, error)
+
+	// Write replaces the contents of the shared config file at the given location. The file must never be left
+	// partially written.
+	//
+	// It takes the following parameters:
+	//   - location: The location of the shared config file.
+	//   - contents: The new contents of the file.
+	//   - description: A description of the change, for stores that record one (for example, as a commit message).
+	Write(location ConfigStoreLocation, contents []byte, description string) error
+
+	// Watch calls the given function whenever the shared config file at the given location changes, until the
+	// returned function is called to stop watching.
+	Watch(location ConfigStoreLocation, onChange func()) (func(), error)
+
+	// Lock takes a lock on the shared config file at the given location, so that other processes and machines don't
+	// change it at the same time. It is called by ConfigService.LockConfig, which handles taking the lock more than
+	// once.
+	Lock(location ConfigStoreLocation) (*ConfigLock, error)
+}
+
+// ConfigStoreLocation describes where the shared config file is stored.
+type ConfigStoreLocation struct {
+	// Store is the name of the ConfigStore that manages the shared config file.
+	Store string `yaml:"store"`
+	// Url is where the store keeps the shared config file, as given to ConfigStore.Attach. It can contain credentials,
+	// which are stored as they are, so it should only be shown to the user using RedactedUrl.
+	Url string `yaml:"url"`
+	// Path is the path of the shared config file on the local machine. For stores that keep the file somewhere else,
+	// this is the path of the local copy.
+	Path string `yaml:"-"`
+}
+
+// RedactedUrl returns the location's URL with any password replaced, for showing to the user.
+func (location ConfigStoreLocation) RedactedUrl() string {
+	return system.RedactUrl(location.Url)
+}
+
+// ConfigStoreRegistry holds the available config stores, keyed by name.
+type ConfigStoreRegistry map[string]ConfigStore
+
+// NewConfigStoreRegistry returns a new instance of ConfigStoreRegistry.
+func NewConfigStoreRegistry(fileConfigStore *FileConfigStore, gitConfigStore *GitConfigStore,
+	httpConfigStore *HttpConfigStore) ConfigStoreRegistry {
+	return ConfigStoreRegistry{
+		fileConfigStore.Name(): fileConfigStore,
+		gitConfigStore.Name():  gitConfigStore,
+		httpConfigStore.Name(): httpConfigStore,
+	}
+}
+
+// GetConfigStoreNames returns the names of all config stores, sorted alphabetically.
+func (configStoreRegistry ConfigStoreRegistry) GetConfigStoreNames() []string {
+	var names []string
+	for name := range configStoreRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetConfigStore returns the config store with the given name, if it exists.
+//
+// It takes the following parameters:
+//   - configStoreName: The name of the config store.
+func (configStoreRegistry ConfigStoreRegistry) GetConfigStore(configStoreName string) (ConfigStore, error) {
+	configStore, isPresent := configStoreRegistry[configStoreName]
+	if !isPresent {
+		return nil, fmt.Errorf("config store %q not valid", configStoreName)
+	}
+
+	return configStore, nil
+}
+
+// pollForChanges calls read every interval, and calls onChange whenever the contents it returns change. It returns a
+// function that stops polling. It is used by stores that have no way to be notified of changes.
+//
+// It takes the following parameters:
+//   - interval: How often to call read.
+//   - read: Returns the current contents of the shared config file. If the file doesn't exist, it should return
+//     nil.
+//   - onChange: The function to call when the contents change.
+func pollForChanges(interval time.Duration, read func() []byte, onChange func()) func() {
+	lastHash := contentHash(read())
+	stop := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				hash := contentHash(read())
+				if hash != lastHash {
+					lastHash = hash
+					onChange()
+				}
+			}
+		}
+	}()
+
+	var stopOnce sync.Once
+	return func() {
+		stopOnce.Do(func() {
+			close(stop)
+		})
+	}
+}
+
+// readFileOrNil returns the contents of the file at the given path, or nil if it can't be read.
+func readFileOrNil(path string) []byte {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return contents
+}
@@ -0,0 +1,103 @@
+package config_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	. "github.com/colececil/familiar.sh/internal/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// memoryConfigStore is a ConfigStore that keeps the shared config file in memory, to check that ConfigService goes
+// through the store rather than the local file.
+type memoryConfigStore struct {
+	contents     []byte
+	descriptions []string
+	localPath    string
+}
+
+func (memoryConfigStore *memoryConfigStore) Name() string {
+	return "memory"
+}
+
+func (memoryConfigStore *memoryConfigStore) Attach(url string, path string) (ConfigStoreLocation, error) {
+	return ConfigStoreLocation{Store: "memory", Url: url, Path: memoryConfigStore.localPath}, nil
+}
+
+func (memoryConfigStore *memoryConfigStore) Read(location ConfigStoreLocation) ([]byte, error) {
+	if memoryConfigStore.contents == nil {
+		return nil, os.ErrNotExist
+	}
+	return memoryConfigStore.contents, nil
+}
+
+func (memoryConfigStore *memoryConfigStore) Write(location ConfigStoreLocation, contents []byte,
+	description string) error {
+	memoryConfigStore.contents = contents
+	memoryConfigStore.descriptions = append(memoryConfigStore.descriptions, description)
+	return nil
+}
+
+func (memoryConfigStore *memoryConfigStore) Watch(location ConfigStoreLocation, onChange func()) (func(), error) {
+	return func() {}, nil
+}
+
+func (memoryConfigStore *memoryConfigStore) Lock(location ConfigStoreLocation) (*ConfigLock, error) {
+	return &ConfigLock{Host: "memory", Pid: os.Getpid(), Expires: time.Now().Add(time.Minute)}, nil
+}
+
+var _ = Describe("ConfigStore", func() {
+	var configLocation string
+	var configService *ConfigService
+
+	BeforeEach(func() {
+		configLocation = useTemporaryConfigLocation()
+		configService = newConfigService()
+	})
+
+	It("should use the file store when no other store has been set", func() {
+		storeLocation, err := configService.GetConfigStoreLocation()
+		Expect(err).To(BeNil())
+		Expect(storeLocation).To(Equal(ConfigStoreLocation{Store: "file", Url: configLocation, Path: configLocation}))
+	})
+
+	It("should return an error for an unknown store", func() {
+		err := configService.SetConfigStoreLocation("ftp", "ftp://example.com/config.yaml", "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring(`config store "ftp" not valid`))
+	})
+
+	It("should read and write the shared config file through the store", func() {
+		memoryStore := &memoryConfigStore{localPath: filepath.Join(GinkgoT().TempDir(), "config.yaml")}
+		configService = NewConfigService(ConfigStoreRegistry{"memory": memoryStore},
+			func() io.Writer { return GinkgoWriter }, func() string { return "" })
+		Expect(configService.SetConfigStoreLocation("memory", "memory://config", "")).To(Succeed())
+
+		config, err := configService.GetSharedConfig()
+		Expect(err).To(BeNil())
+		config.Scripts = append(config.Scripts, ConfiguredScript{SourcePath: "setup.sh"})
+		Expect(configService.SetConfig(config)).To(Succeed())
+
+		Expect(string(memoryStore.contents)).To(ContainSubstring("setup.sh"))
+		Expect(memoryStore.descriptions).To(ContainElement(`Familiar.sh: add script "setup.sh"`))
+		Expect(memoryStore.localPath).ToNot(BeAnExistingFile())
+	})
+
+	It("should call the given function when the shared config file changes", func() {
+		writeConfigFile(configLocation, "version: 1\n")
+
+		var changes atomic.Int32
+		stop, err := configService.WatchSharedConfig(func() {
+			changes.Add(1)
+		})
+		Expect(err).To(BeNil())
+		defer stop()
+
+		writeConfigFile(configLocation, "version: 1\nfiles: []\n")
+		Eventually(changes.Load, 5*time.Second, 100*time.Millisecond).Should(BeEquivalentTo(1))
+	})
+})
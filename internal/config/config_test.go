@@ -0,0 +1,125 @@
+package config_test
+
+import (
+	. "github.com/colececil/familiar.sh/internal/config"
+	"github.com/colececil/familiar.sh/internal/packagemanagers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+var _ = Describe("Config", func() {
+	Describe("ConfiguredPackage", func() {
+		Describe("AppliesToMachine", func() {
+			It("should return true for a package without tags, regardless of the machine's tags", func() {
+				configuredPackage := ConfiguredPackage{Name: "package1", Version: "1.0.0"}
+
+				Expect(configuredPackage.AppliesToMachine([]string{})).To(BeTrue())
+				Expect(configuredPackage.AppliesToMachine([]string{"work"})).To(BeTrue())
+			})
+
+			It("should return true when the machine has at least one of the package's tags", func() {
+				configuredPackage := ConfiguredPackage{Name: "package1", Version: "1.0.0",
+					Tags: []string{"work", "server"}}
+
+				Expect(configuredPackage.AppliesToMachine([]string{"gaming", "server"})).To(BeTrue())
+			})
+
+			It("should return false when the machine has none of the package's tags", func() {
+				configuredPackage := ConfiguredPackage{Name: "package1", Version: "1.0.0", Tags: []string{"work"}}
+
+				Expect(configuredPackage.AppliesToMachine([]string{})).To(BeFalse())
+				Expect(configuredPackage.AppliesToMachine([]string{"gaming"})).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("PinPackage", func() {
+		var config *Config
+
+		BeforeEach(func() {
+			config = &Config{PackageManagers: []ConfiguredPackageManager{
+				{Name: "scoop", Packages: []ConfiguredPackage{{Name: "package1", Version: "1.0.0"}}},
+			}}
+		})
+
+		It("should pin a configured package, keeping its version when no version is given", func() {
+			Expect(config.PinPackage("scoop", "package1", nil)).To(Succeed())
+
+			Expect(config.IsPackagePinned("scoop", "package1")).To(BeTrue())
+			Expect(config.PackageManagers[0].Packages[0].Version).To(Equal("1.0.0"))
+		})
+
+		It("should change the version of a configured package when a version is given", func() {
+			Expect(config.PinPackage("scoop", "package1", packagemanagers.NewVersion("1.2.0"))).To(Succeed())
+
+			Expect(config.PackageManagers[0].Packages[0]).To(Equal(
+				ConfiguredPackage{Name: "package1", Version: "1.2.0", Pinned: true}))
+		})
+
+		It("should add an unconfigured package at the given version", func() {
+			Expect(config.PinPackage("scoop", "package2", packagemanagers.NewVersion("2.0.0"))).To(Succeed())
+
+			Expect(config.PackageManagers[0].Packages).To(ContainElement(
+				ConfiguredPackage{Name: "package2", Version: "2.0.0", Pinned: true}))
+		})
+
+		It("should return an error for an unconfigured package when no version is given", func() {
+			Expect(config.PinPackage("scoop", "package2", nil)).To(MatchError("package not present"))
+			Expect(config.HasPackage("scoop", "package2")).To(BeFalse())
+		})
+
+		It("should return an error when the package manager is not configured", func() {
+			Expect(config.PinPackage("winget", "package1", packagemanagers.NewVersion("1.0.0"))).To(
+				MatchError("package manager not present"))
+		})
+	})
+
+	Describe("UnpinPackage", func() {
+		It("should unpin a pinned package", func() {
+			config := &Config{PackageManagers: []ConfiguredPackageManager{
+				{Name: "scoop", Packages: []ConfiguredPackage{{Name: "package1", Version: "1.0.0", Pinned: true}}},
+			}}
+
+			Expect(config.UnpinPackage("scoop", "package1")).To(Succeed())
+			Expect(config.IsPackagePinned("scoop", "package1")).To(BeFalse())
+		})
+
+		It("should return an error for a package that is not pinned", func() {
+			config := &Config{PackageManagers: []ConfiguredPackageManager{
+				{Name: "scoop", Packages: []ConfiguredPackage{{Name: "package1", Version: "1.0.0"}}},
+			}}
+
+			Expect(config.UnpinPackage("scoop", "package1")).To(MatchError("package not pinned"))
+			Expect(config.UnpinPackage("scoop", "package2")).To(MatchError("package not present"))
+		})
+	})
+
+	Describe("IsPackagePinned", func() {
+		It("should return false for a package that is not configured", func() {
+			config := &Config{PackageManagers: []ConfiguredPackageManager{{Name: "scoop"}}}
+
+			Expect(config.IsPackagePinned("scoop", "package1")).To(BeFalse())
+			Expect(config.IsPackagePinned("winget", "package1")).To(BeFalse())
+		})
+	})
+
+	Describe("ConfiguredPackage YAML", func() {
+		It("should round-trip the pinned field, leaving it out for unpinned packages", func() {
+			packages := []ConfiguredPackage{
+				{Name: "package1", Version: "1.0.0", Pinned: true},
+				{Name: "package2", Version: "2.0.0"},
+			}
+
+			data, err := yaml.Marshal(packages)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.Count(string(data), "pinned")).To(Equal(1))
+			Expect(string(data)).To(ContainSubstring("pinned: true"))
+
+			var unmarshalledPackages []ConfiguredPackage
+			Expect(yaml.Unmarshal(data, &unmarshalledPackages)).To(Succeed())
+			Expect(unmarshalledPackages).To(Equal(packages))
+		})
+	})
+})
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"fmt"
+	"github.com/colececil/familiar.sh/internal/collections"
+	"github.com/colececil/familiar.sh/internal/packagemanagers"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// mergedConflictCopySuffix is added to the name of a conflict copy once it has been merged into the shared config file,
+// so that it is no longer detected but can still be recovered.
+const mergedConflictCopySuffix = ".merged"
+
+// ConflictCopyMerge describes the result of merging a conflict copy into the shared config file.
+type ConflictCopyMerge struct {
+	// Path is the location of the conflict copy.
+	Path string
+	// Changes contains a description of each change made to the shared config file.
+	Changes []string
+}
+
+// conflictCopySuffixPattern matches what cloud drives add to the name of a file when they make a conflict copy of it:
+// " (1)" (Google Drive), " (Jane's conflicted copy 2024-01-01)" (Dropbox), " 2" (iCloud Drive), and the computer name,
+// like "-DESKTOP-1234ABC" (OneDrive, using the default Windows computer names). Anything else, such as a sibling file
+// named "config-backup.yaml", is left alone.
+const conflictCopySuffixPattern = `(?: \(\d+\)| \([^()]*(?i:conflicted copy)[^()]*\)| \d+|` +
+	`-(?:DESKTOP|LAPTOP)-[A-Z0-9]+)`
+
+// FindConflictCopies returns the paths of any conflict copies of the shared config file, sorted by path. These are
+// created by cloud drives when the file is changed on more than one machine before the changes are synced. They sit
+// next to the shared config file, with names like "config (1).yaml", "config (Jane's conflicted copy).yaml",
+// "config 2.yaml", or "config-DESKTOP-1234ABC.yaml". Files that are included by the shared config file are never
+// treated as conflict copies.
+//
+// It takes the following parameters:
+//   - configLocation: The location of the shared config file.
+//   - includePaths: The paths of the files included by the shared config file, as returned by ResolveIncludes.
+func FindConflictCopies(configLocation string, includePaths []string) ([]string, error) {
+	configDirectory := filepath.Dir(configLocation)
+	ext := filepath.Ext(configLocation)
+	baseName := strings.TrimSuffix(filepath.Base(configLocation), ext)
+	conflictCopyRegex := regexp.MustCompile(`^` + regexp.QuoteMeta(baseName) + conflictCopySuffixPattern +
+		regexp.QuoteMeta(ext) + `$`)
+
+	entries, err := os.ReadDir(configDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflictCopies []string
+	for _, entry := range entries {
+		path := filepath.Join(configDirectory, entry.Name())
+		if entry.IsDir() || !conflictCopyRegex.MatchString(entry.Name()) || collections.Contains(includePaths, path) {
+			continue
+		}
+		conflictCopies = append(conflictCopies, path)
+	}
+
+	sort.Strings(conflictCopies)
+	return conflictCopies, nil
+}
+
+// MergeConflictCopy merges the contents of a conflict copy into the given Config, and returns a description of each
+// change made. Entries that are only in the conflict copy are added, and if a package has a different version in each,
+// the greater version is kept. Since there is no record of the file before the conflict, entries that were removed on
+// only one machine can't be told apart from entries that were added on the other, so they are added back.
+//
+// It takes the following parameters:
+//   - config: The Config to merge into, read from the shared config file.
+//   - conflictCopy: The Config read from the conflict copy.
+func MergeConflictCopy(config *Config, conflictCopy *Config) []string {
+	var changes []string
+
+	for _, includeEntry := range conflictCopy.Include {
+		if !collections.Contains(config.Include, includeEntry) {
+			config.Include = append(config.Include, includeEntry)
+			changes = append(changes, fmt.Sprintf("added include %q", includeEntry))
+		}
+	}
+
+	for _, conflictFile := range conflictCopy.Files {
+		isPresent := false
+		for _, existingFile := range config.Files {
+			if existingFile.SourcePath == conflictFile.SourcePath {
+				isPresent = true
+				break
+			}
+		}
+
+		if !isPresent {
+			config.Files = append(config.Files, conflictFile)
+			changes = append(changes, fmt.Sprintf("added file %q", conflictFile.SourcePath))
+		}
+	}
+
+	for _, conflictScript := range conflictCopy.Scripts {
+		isPresent := false
+		for _, existingScript := range config.Scripts {
+			if existingScript.SourcePath == conflictScript.SourcePath {
+				isPresent = true
+				break
+			}
+		}
+
+		if !isPresent {
+			config.Scripts = append(config.Scripts, conflictScript)
+			changes = append(changes, fmt.Sprintf("added script %q", conflictScript.SourcePath))
+		}
+	}
+
+	for _, conflictPackageManager := range conflictCopy.PackageManagers {
+		var matchingPackageManager *ConfiguredPackageManager
+		for i := range config.PackageManagers {
+			if config.PackageManagers[i].Name == conflictPackageManager.Name {
+				matchingPackageManager = &config.PackageManagers[i]
+				break
+			}
+		}
+
+		if matchingPackageManager == nil {
+			config.PackageManagers = append(config.PackageManagers, conflictPackageManager)
+			changes = append(changes, fmt.Sprintf("added package manager %q", conflictPackageManager.Name))
+			continue
+		}
+
+		for _, conflictPackage := range conflictPackageManager.Packages {
+			var matchingPackage *ConfiguredPackage
+			for i := range matchingPackageManager.Packages {
+				if matchingPackageManager.Packages[i].Name == conflictPackage.Name {
+					matchingPackage = &matchingPackageManager.Packages[i]
+					break
+				}
+			}
+
+			if matchingPackage == nil {
+				matchingPackageManager.Packages = append(matchingPackageManager.Packages, conflictPackage)
+				changes = append(changes, fmt.Sprintf("added package %q under package manager %q",
+					conflictPackage.Name, conflictPackageManager.Name))
+			} else if packagemanagers.NewVersion(conflictPackage.Version).IsGreaterThan(
+				packagemanagers.NewVersion(matchingPackage.Version)) {
+				changes = append(changes, fmt.Sprintf("updated package %q under package manager %q from version "+
+					"%s to %s", conflictPackage.Name, conflictPackageManager.Name, matchingPackage.Version,
+					conflictPackage.Version))
+				matchingPackage.Version = conflictPackage.Version
+			}
+		}
+	}
+
+	return changes
+}
+
+// MergeConflictCopies merges each conflict copy of the shared config file into it, as described by MergeConflictCopy.
+// Once the shared config file has been written, each conflict copy is renamed with a ".merged" suffix, so it is no
+// longer detected.
+func (configService *ConfigService) MergeConflictCopies() ([]ConflictCopyMerge, error) {
+	configLocation, err := configService.GetConfigLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	configLock, err := configService.LockConfig()
+	if err != nil {
+		return nil, err
+	}
+	defer func(configLock *ConfigLock) {
+		_ = configLock.Release()
+	}(configLock)
+
+	config, err := configService.GetSharedConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	includePaths, err := ResolveIncludes(config, configLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	conflictCopies, err := FindConflictCopies(configLocation, includePaths)
+	if err != nil {
+		return nil, err
+	}
+
+	var merges []ConflictCopyMerge
+	for _, conflictCopyPath := range conflictCopies {
+		conflictCopy, err := readConflictCopy(conflictCopyPath)
+		if err != nil {
+			return nil, err
+		}
+		merges = append(merges, ConflictCopyMerge{
+			Path:    conflictCopyPath,
+			Changes: MergeConflictCopy(config, conflictCopy),
+		})
+	}
+
+	if len(merges) == 0 {
+		return nil, nil
+	}
+
+	if err = configService.SetConfig(config); err != nil {
+		return nil, err
+	}
+
+	for _, merge := range merges {
+		if err = os.Rename(merge.Path, merge.Path+mergedConflictCopySuffix); err != nil {
+			return nil, fmt.Errorf("unable to rename merged conflict copy %q: %w", merge.Path, err)
+		}
+	}
+
+	return merges, nil
+}
+
+// readConflictCopy reads the conflict copy at the given path, migrating it to the current config version if needed.
+func readConflictCopy(path string) (*Config, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	migratedBytes, _, err := MigrateConfig(bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error reading conflict copy %q: %w", path, err)
+	}
+
+	var conflictCopy Config
+	if err = yaml.Unmarshal(migratedBytes, &conflictCopy); err != nil {
+		return nil, fmt.Errorf("error reading conflict copy %q: %w", path, err)
+	}
+
+	return &conflictCopy, nil
+}
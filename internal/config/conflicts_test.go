@@ -0,0 +1,109 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/colececil/familiar.sh/internal/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FindConflictCopies", func() {
+	It("should find the conflict copies made by cloud drives, but not included files", func() {
+		configDirectory := GinkgoT().TempDir()
+		fileNames := []string{"config.yaml", "config (1).yaml", "config (Jane's conflicted copy 2024-01-01).yaml",
+			"config 2.yaml", "config-DESKTOP-1234ABC.yaml", "config-DESKTOP-5678DEF.yaml", "config (1).yaml.merged",
+			"other.yaml"}
+		for _, fileName := range fileNames {
+			Expect(os.WriteFile(filepath.Join(configDirectory, fileName), []byte{}, 0600)).To(Succeed())
+		}
+
+		conflictCopies, err := FindConflictCopies(filepath.Join(configDirectory, "config.yaml"),
+			[]string{filepath.Join(configDirectory, "config-DESKTOP-5678DEF.yaml")})
+		Expect(err).To(BeNil())
+		Expect(conflictCopies).To(Equal([]string{
+			filepath.Join(configDirectory, "config (1).yaml"),
+			filepath.Join(configDirectory, "config (Jane's conflicted copy 2024-01-01).yaml"),
+			filepath.Join(configDirectory, "config 2.yaml"),
+			filepath.Join(configDirectory, "config-DESKTOP-1234ABC.yaml"),
+		}))
+	})
+
+	It("should not treat ordinary sibling files with similar names as conflict copies", func() {
+		configDirectory := GinkgoT().TempDir()
+		fileNames := []string{"config.yaml", "config-backup.yaml", "config-work.yaml", "config (old).yaml",
+			"config v2.yaml", "config-desktop-notes.yaml"}
+		for _, fileName := range fileNames {
+			Expect(os.WriteFile(filepath.Join(configDirectory, fileName), []byte{}, 0600)).To(Succeed())
+		}
+
+		conflictCopies, err := FindConflictCopies(filepath.Join(configDirectory, "config.yaml"), nil)
+		Expect(err).To(BeNil())
+		Expect(conflictCopies).To(BeEmpty())
+	})
+})
+
+var _ = Describe("MergeConflictCopy", func() {
+	It("should add missing entries and keep the greater package version", func() {
+		config := &Config{
+			Files:   []ConfiguredFile{{SourcePath: ".bashrc"}},
+			Scripts: []ConfiguredScript{},
+			PackageManagers: []ConfiguredPackageManager{
+				{Name: "scoop", Packages: []ConfiguredPackage{
+					{Name: "package1", Version: "1.0.0"},
+					{Name: "package2", Version: "2.1.0"},
+				}},
+			},
+		}
+		conflictCopy := &Config{
+			Files:   []ConfiguredFile{{SourcePath: ".bashrc"}, {SourcePath: ".vimrc"}},
+			Scripts: []ConfiguredScript{{SourcePath: "setup.sh"}},
+			PackageManagers: []ConfiguredPackageManager{
+				{Name: "scoop", Packages: []ConfiguredPackage{
+					{Name: "package1", Version: "1.2.0"},
+					{Name: "package2", Version: "2.0.0"},
+					{Name: "package3", Version: "3.0.0"},
+				}},
+			},
+		}
+
+		changes := MergeConflictCopy(config, conflictCopy)
+		Expect(changes).To(Equal([]string{
+			`added file ".vimrc"`,
+			`added script "setup.sh"`,
+			`updated package "package1" under package manager "scoop" from version 1.0.0 to 1.2.0`,
+			`added package "package3" under package manager "scoop"`,
+		}))
+		Expect(config.Files).To(Equal([]ConfiguredFile{{SourcePath: ".bashrc"}, {SourcePath: ".vimrc"}}))
+		Expect(config.Scripts).To(Equal([]ConfiguredScript{{SourcePath: "setup.sh"}}))
+		Expect(config.PackageManagers[0].Packages).To(Equal([]ConfiguredPackage{
+			{Name: "package1", Version: "1.2.0"},
+			{Name: "package2", Version: "2.1.0"},
+			{Name: "package3", Version: "3.0.0"},
+		}))
+	})
+})
+
+var _ = Describe("ConfigService MergeConflictCopies", func() {
+	It("should merge the conflict copies into the shared config file and rename them", func() {
+		configLocation := useTemporaryConfigLocation()
+		writeConfigFile(configLocation, "version: 1\nfiles: []\nscripts: []\npackageManagers: []\n")
+		conflictCopyPath := filepath.Join(filepath.Dir(configLocation), "config (1).yaml")
+		writeConfigFile(conflictCopyPath, "version: 1\nfiles: []\nscripts: []\npackageManagers:\n  - name: scoop\n"+
+			"    packages: []\n")
+
+		configService := newConfigService()
+		merges, err := configService.MergeConflictCopies()
+		Expect(err).To(BeNil())
+		Expect(merges).To(Equal([]ConflictCopyMerge{
+			{Path: conflictCopyPath, Changes: []string{`added package manager "scoop"`}},
+		}))
+
+		config, err := configService.GetSharedConfig()
+		Expect(err).To(BeNil())
+		Expect(config.PackageManagers).To(HaveLen(1))
+		Expect(conflictCopyPath).ToNot(BeAnExistingFile())
+		Expect(conflictCopyPath + ".merged").To(BeAnExistingFile())
+	})
+})
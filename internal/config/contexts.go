@@ -0,0 +1,212 @@
+package config
+
+import (
+	"fmt"
+	"github.com/colececil/familiar.sh/internal/collections"
+	"gopkg.in/yaml.v3"
+	"os"
+	"regexp"
+)
+
+// ConfigEnvironmentVariable is the environment variable that overrides the location of the shared config file. It can
+// be set to the name of a context or the path of a config file. The "--config" option takes precedence over it.
+const ConfigEnvironmentVariable = "FAMILIAR_CONFIG"
+
+// ConfigOverrideFunc is a function for getting the context name or config file path given with the "--config" option,
+// which overrides the location of the shared config file for a single command. It returns an empty string if the
+// option wasn't given.
+type ConfigOverrideFunc func() string
+
+const configContextsFileName = "contexts.yaml"
+
+// defaultConfigContextName is the name that refers to the default location of the shared config file, which is used
+// when no context is in use.
+const defaultConfigContextName = "default"
+
+// configContextNamePattern matches valid context names.
+var configContextNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ConfigContext is a named location of a shared config file, so that a machine can switch between more than one shared
+// config file (for example, a personal one and a team one). A layered context has no location of its own. Instead, it
+// merges the config files of other contexts, so that they can be used together (for example, a team baseline with
+// personal additions on top).
+type ConfigContext struct {
+	Name  string `yaml:"name"`
+	Store string `yaml:"store,omitempty"`
+	Url   string `yaml:"url,omitempty"`
+	Path  string `yaml:"path,omitempty"`
+
+	// Layers are the names of the contexts a layered context merges, in the order they are applied.
+	Layers []string `yaml:"layers,omitempty"`
+	// WritableLayer is the name of the layer that changes are written to. If it is empty, the last layer is used.
+	WritableLayer string `yaml:"writableLayer,omitempty"`
+}
+
+// ConfigContexts holds the contexts set up on the current machine, and which of them is in use. It is stored in the
+// "contexts.yaml" file in the XDG config directory.
+type ConfigContexts struct {
+	// Current is the name of the context in use. If it is empty, the default location is used.
+	Current  string          `yaml:"current,omitempty"`
+	Contexts []ConfigContext `yaml:"contexts"`
+}
+
+// GetConfigContexts returns the contexts set up on the current machine, and which of them is in use.
+func (configService *ConfigService) GetConfigContexts() (*ConfigContexts, error) {
+	return readConfigContexts()
+}
+
+// AddConfigContext adds a context with the given name, for the shared config file at the given URL in the config store
+// of the given name. The store is attached to the URL, as with SetConfigStoreLocation, but the new context isn't used
+// until UseConfigContext is called.
+//
+// It takes the following parameters:
+//   - name: The name of the context. It must start with a letter or digit, and can only contain letters, digits, "_",
+//     ".", and "-".
+//   - configStoreName: The name of the config store, such as "file" or "git".
+//   - url: Where the store keeps the shared config file. For the file store, this is the path of the file.
+//   - path: The path of the shared config file within the given URL, for stores where it can hold more than one file.
+//     If empty, the store's default is used.
+func (configService *ConfigService) AddConfigContext(name string, configStoreName string, url string,
+	path string) error {
+	if err := validateConfigContextName(name); err != nil {
+		return err
+	}
+
+	contexts, err := readConfigContexts()
+	if err != nil {
+		return err
+	}
+	if contexts.find(name) != nil {
+		return fmt.Errorf("context %q already exists", name)
+	}
+
+	location, err := configService.attachConfigStore(configStoreName, url, path)
+	if err != nil {
+		return err
+	}
+
+	configContext := ConfigContext{Name: name}
+	configContext.setLocation(location)
+	contexts.Contexts = append(contexts.Contexts, configContext)
+	return writeConfigContexts(contexts)
+}
+
+// UseConfigContext sets the context of the given name as the one in use. If the name is "default", no context is used,
+// and the default location is used instead.
+func (configService *ConfigService) UseConfigContext(name string) error {
+	contexts, err := readConfigContexts()
+	if err != nil {
+		return err
+	}
+
+	if name == defaultConfigContextName {
+		contexts.Current = ""
+	} else if contexts.find(name) == nil {
+		return fmt.Errorf("context %q doesn't exist", name)
+	} else {
+		contexts.Current = name
+	}
+
+	return writeConfigContexts(contexts)
+}
+
+// RemoveConfigContext removes the context of the given name. If it is in use, the default location is used instead.
+// The shared config file itself isn't changed. A context can't be removed while it is a layer of a layered context.
+func (configService *ConfigService) RemoveConfigContext(name string) error {
+	contexts, err := readConfigContexts()
+	if err != nil {
+		return err
+	}
+
+	for _, configContext := range contexts.Contexts {
+		if collections.Contains(configContext.Layers, name) {
+			return fmt.Errorf("context %q is a layer of context %q, so it can't be removed", name, configContext.Name)
+		}
+	}
+
+	for i, configContext := range contexts.Contexts {
+		if configContext.Name == name {
+			contexts.Contexts = append(contexts.Contexts[:i], contexts.Contexts[i+1:]...)
+			if contexts.Current == name {
+				contexts.Current = ""
+			}
+			return writeConfigContexts(contexts)
+		}
+	}
+
+	return fmt.Errorf("context %q doesn't exist", name)
+}
+
+// location returns the location of the shared config file the context refers to.
+func (configContext *ConfigContext) location() ConfigStoreLocation {
+	return ConfigStoreLocation{Store: configContext.Store, Url: configContext.Url, Path: configContext.Path}
+}
+
+// setLocation sets the location of the shared config file the context refers to.
+func (configContext *ConfigContext) setLocation(location ConfigStoreLocation) {
+	configContext.Store = location.Store
+	configContext.Url = location.Url
+	configContext.Path = location.Path
+}
+
+// WritableLayerName returns the name of the layer of a layered context that changes are written to.
+func (configContext *ConfigContext) WritableLayerName() string {
+	if configContext.WritableLayer != "" {
+		return configContext.WritableLayer
+	}
+	return configContext.Layers[len(configContext.Layers)-1]
+}
+
+// find returns the context with the given name, or nil if there isn't one.
+func (configContexts *ConfigContexts) find(name string) *ConfigContext {
+	for i := range configContexts.Contexts {
+		if configContexts.Contexts[i].Name == name {
+			return &configContexts.Contexts[i]
+		}
+	}
+	return nil
+}
+
+// validateConfigContextName returns an error if the given name can't be used for a new context.
+func validateConfigContextName(name string) error {
+	if !configContextNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid context name %q: it must start with a letter or digit, and can only contain "+
+			"letters, digits, \"_\", \".\", and \"-\"", name)
+	}
+	if name == defaultConfigContextName {
+		return fmt.Errorf("the context name %q is reserved for the default location", defaultConfigContextName)
+	}
+	return nil
+}
+
+// readConfigContexts reads the "contexts.yaml" file in the XDG config directory. If it doesn't exist, no contexts are
+// returned.
+func readConfigContexts() (*ConfigContexts, error) {
+	contexts := &ConfigContexts{}
+
+	contents, err := os.ReadFile(appDirectoryFilePath(configContextsFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return contexts, nil
+		}
+		return nil, err
+	}
+
+	if err = yaml.Unmarshal(contents, contexts); err != nil {
+		return nil, fmt.Errorf("unable to parse \"%s\": %w", appDirectoryFilePath(configContextsFileName), err)
+	}
+	return contexts, nil
+}
+
+// writeConfigContexts writes the given contexts to the "contexts.yaml" file in the XDG config directory.
+func writeConfigContexts(contexts *ConfigContexts) error {
+	if err := os.MkdirAll(appDirectoryPath(), 0700); err != nil {
+		return err
+	}
+
+	contents, err := yaml.Marshal(contexts)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(appDirectoryFilePath(configContextsFileName), contents, 0600)
+}
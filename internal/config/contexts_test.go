@@ -0,0 +1,104 @@
+package config_test
+
+import (
+	"path/filepath"
+
+	. "github.com/colececil/familiar.sh/internal/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConfigService contexts", func() {
+	var defaultLocation string
+	var teamLocation string
+	var configService *ConfigService
+
+	// configLocation returns the resolved location of the shared config file.
+	configLocation := func() string {
+		location, err := configService.GetConfigLocation()
+		Expect(err).To(BeNil())
+		return location
+	}
+
+	BeforeEach(func() {
+		defaultLocation = useTemporaryConfigLocation()
+		teamLocation = filepath.Join(GinkgoT().TempDir(), "team.yaml")
+		configService = newConfigService()
+		Expect(configService.AddConfigContext("team", "file", teamLocation, "")).To(Succeed())
+	})
+
+	It("should use the default location until a context is used", func() {
+		Expect(configLocation()).To(Equal(defaultLocation))
+
+		Expect(configService.UseConfigContext("team")).To(Succeed())
+		Expect(configLocation()).To(Equal(teamLocation))
+
+		source, err := configService.GetConfigLocationSource()
+		Expect(err).To(BeNil())
+		Expect(source).To(Equal(`context "team"`))
+
+		Expect(configService.UseConfigContext("default")).To(Succeed())
+		Expect(configLocation()).To(Equal(defaultLocation))
+	})
+
+	It("should list the contexts and the one in use", func() {
+		Expect(configService.UseConfigContext("team")).To(Succeed())
+
+		contexts, err := configService.GetConfigContexts()
+		Expect(err).To(BeNil())
+		Expect(contexts.Current).To(Equal("team"))
+		Expect(contexts.Contexts).To(Equal([]ConfigContext{
+			{Name: "team", Store: "file", Url: teamLocation, Path: teamLocation},
+		}))
+	})
+
+	It("should let FAMILIAR_CONFIG override the context in use, with a context name or a path", func() {
+		Expect(configService.UseConfigContext("team")).To(Succeed())
+
+		GinkgoT().Setenv(ConfigEnvironmentVariable, "default")
+		Expect(configLocation()).To(Equal(defaultLocation))
+
+		otherLocation := filepath.Join(GinkgoT().TempDir(), "other.yaml")
+		GinkgoT().Setenv(ConfigEnvironmentVariable, otherLocation)
+		Expect(configLocation()).To(Equal(otherLocation))
+
+		Expect(configService.SetConfigLocation(defaultLocation)).ToNot(Succeed())
+	})
+
+	It("should let --config override FAMILIAR_CONFIG and the context in use", func() {
+		Expect(configService.UseConfigContext("team")).To(Succeed())
+		GinkgoT().Setenv(ConfigEnvironmentVariable, "default")
+
+		otherLocation := filepath.Join(GinkgoT().TempDir(), "other.yaml")
+		configService = newConfigServiceWithOverride(func() string { return otherLocation })
+		Expect(configLocation()).To(Equal(otherLocation))
+
+		err := configService.SetConfigLocation(defaultLocation)
+		Expect(err).To(MatchError("the config file location can't be changed while it is overridden by --config"))
+	})
+
+	It("should change the location of the context in use", func() {
+		Expect(configService.UseConfigContext("team")).To(Succeed())
+
+		newTeamLocation := filepath.Join(GinkgoT().TempDir(), "new-team.yaml")
+		Expect(configService.SetConfigLocation(newTeamLocation)).To(Succeed())
+		Expect(configLocation()).To(Equal(newTeamLocation))
+
+		Expect(configService.UseConfigContext("default")).To(Succeed())
+		Expect(configLocation()).To(Equal(defaultLocation))
+	})
+
+	It("should use the default location after the context in use is removed", func() {
+		Expect(configService.UseConfigContext("team")).To(Succeed())
+		Expect(configService.RemoveConfigContext("team")).To(Succeed())
+		Expect(configLocation()).To(Equal(defaultLocation))
+	})
+
+	It("should return an error for invalid, reserved, duplicate, or unknown context names", func() {
+		Expect(configService.AddConfigContext("my team", "file", teamLocation, "")).ToNot(Succeed())
+		Expect(configService.AddConfigContext("default", "file", teamLocation, "")).ToNot(Succeed())
+		Expect(configService.AddConfigContext("team", "file", teamLocation, "")).ToNot(Succeed())
+		Expect(configService.UseConfigContext("personal")).ToNot(Succeed())
+		Expect(configService.RemoveConfigContext("personal")).ToNot(Succeed())
+	})
+})
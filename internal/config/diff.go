@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change in a diff.
+const diffContextLines = 3
+
+// diffLine represents a single line in a diff, prefixed with ' ' if it is unchanged, '-' if it was removed, or '+' if
+// it was added.
+type diffLine struct {
+	prefix byte
+	text   string
+	// fromLine and toLine are the 1-based line numbers of the line in the old and new contents, or 0 if it isn't there.
+	fromLine int
+	toLine   int
+}
+
+// UnifiedDiff returns the differences between two versions of a file in unified diff format, or an empty string if
+// they are the same.
+//
+// It takes the following parameters:
+//   - fromName: The name of the old version, shown in the diff header.
+//   - toName: The name of the new version, shown in the diff header.
+//   - from: The contents of the old version.
+//   - to: The contents of the new version.
+func UnifiedDiff(fromName string, toName string, from string, to string) string {
+	lines := diffLines(splitLines(from), splitLines(to))
+
+	var hunks [][]diffLine
+	var hunk []diffLine
+	lastChange := -1
+	for i, line := range lines {
+		if line.prefix == ' ' {
+			continue
+		}
+
+		start := i - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		if hunk != nil && start <= lastChange+diffContextLines+1 {
+			hunk = append(hunk, lines[lastChange+1:i+1]...)
+		} else {
+			if hunk != nil {
+				hunks = append(hunks, append(hunk, contextAfter(lines, lastChange)...))
+			}
+			hunk = append([]diffLine{}, lines[start:i+1]...)
+		}
+		lastChange = i
+	}
+
+	if hunk == nil {
+		return ""
+	}
+	hunks = append(hunks, append(hunk, contextAfter(lines, lastChange)...))
+
+	var diffStringBuilder strings.Builder
+	diffStringBuilder.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", fromName, toName))
+	for _, hunk := range hunks {
+		diffStringBuilder.WriteString(hunkHeader(hunk))
+		for _, line := range hunk {
+			diffStringBuilder.WriteString(fmt.Sprintf("%c%s\n", line.prefix, line.text))
+		}
+	}
+
+	return diffStringBuilder.String()
+}
+
+// diffLines returns the lines of a diff between the given old and new lines, using the longest common subsequence of
+// lines to decide which are unchanged.
+func diffLines(fromLines []string, toLines []string) []diffLine {
+	// commonLengths[i][j] is the length of the longest common subsequence of fromLines[i:] and toLines[j:].
+	commonLengths := make([][]int, len(fromLines)+1)
+	for i := range commonLengths {
+		commonLengths[i] = make([]int, len(toLines)+1)
+	}
+	for i := len(fromLines) - 1; i >= 0; i-- {
+		for j := len(toLines) - 1; j >= 0; j-- {
+			if fromLines[i] == toLines[j] {
+				commonLengths[i][j] = commonLengths[i+1][j+1] + 1
+			} else if commonLengths[i+1][j] >= commonLengths[i][j+1] {
+				commonLengths[i][j] = commonLengths[i+1][j]
+			} else {
+				commonLengths[i][j] = commonLengths[i][j+1]
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(fromLines) || j < len(toLines) {
+		if i < len(fromLines) && j < len(toLines) && fromLines[i] == toLines[j] {
+			lines = append(lines, diffLine{prefix: ' ', text: fromLines[i], fromLine: i + 1, toLine: j + 1})
+			i++
+			j++
+		} else if j >= len(toLines) || (i < len(fromLines) && commonLengths[i+1][j] >= commonLengths[i][j+1]) {
+			lines = append(lines, diffLine{prefix: '-', text: fromLines[i], fromLine: i + 1})
+			i++
+		} else {
+			lines = append(lines, diffLine{prefix: '+', text: toLines[j], toLine: j + 1})
+			j++
+		}
+	}
+
+	return lines
+}
+
+// contextAfter returns the unchanged lines to show after the change at the given index.
+func contextAfter(lines []diffLine, lastChange int) []diffLine {
+	end := lastChange + 1 + diffContextLines
+	if end > len(lines) {
+		end = len(lines)
+	}
+	return lines[lastChange+1 : end]
+}
+
+// hunkHeader returns the "@@ -a,b +c,d @@" header line for the given hunk.
+func hunkHeader(hunk []diffLine) string {
+	fromStart, fromCount, toStart, toCount := 0, 0, 0, 0
+	for _, line := range hunk {
+		if line.fromLine > 0 {
+			if fromCount == 0 {
+				fromStart = line.fromLine
+			}
+			fromCount++
+		}
+		if line.toLine > 0 {
+			if toCount == 0 {
+				toStart = line.toLine
+			}
+			toCount++
+		}
+	}
+
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", fromStart, fromCount, toStart, toCount)
+}
+
+// splitLines splits the given contents into lines, ignoring a trailing newline.
+func splitLines(contents string) []string {
+	if contents == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(contents, "\n"), "\n")
+}
@@ -0,0 +1,40 @@
+package config_test
+
+import (
+	. "github.com/colececil/familiar.sh/internal/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UnifiedDiff", func() {
+	It("should return an empty string when the contents are the same", func() {
+		Expect(UnifiedDiff("a", "b", "line1\nline2\n", "line1\nline2\n")).To(BeEmpty())
+	})
+
+	It("should show changed lines with surrounding context", func() {
+		from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n"
+		to := "1\n2\n3\n4\n5\nsix\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n"
+
+		Expect(UnifiedDiff("old", "new", from, to)).To(Equal(`--- old
++++ new
+@@ -3,7 +3,7 @@
+ 3
+ 4
+ 5
+-6
++six
+ 7
+ 8
+ 9
+@@ -13,3 +13,4 @@
+ 13
+ 14
+ 15
++16
+`))
+	})
+
+	It("should handle an empty old version", func() {
+		Expect(UnifiedDiff("old", "new", "", "line1\n")).To(Equal("--- old\n+++ new\n@@ -0,0 +1,1 @@\n+line1\n"))
+	})
+})
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileConfigStoreName is the name of the FileConfigStore, which keeps the shared config file in a local folder.
+const FileConfigStoreName = "file"
+
+// fileWatchInterval is how often FileConfigStore checks the shared config file for changes while watching it.
+const fileWatchInterval = time.Second
+
+// FileConfigStore is a ConfigStore that keeps the shared config file at a path on the local machine. This is the
+// default store, and works with any tool that syncs files between machines, such as a cloud drive.
+type FileConfigStore struct {
+}
+
+// NewFileConfigStore creates a new instance of FileConfigStore.
+func NewFileConfigStore() *FileConfigStore {
+	return &FileConfigStore{}
+}
+
+// Name returns the name of the store.
+func (fileConfigStore *FileConfigStore) Name() string {
+	return FileConfigStoreName
+}
+
+// Attach checks that the given path can be used for the shared config file, and returns its location. If the
+// directory of the file does not exist, or if the file is not a YAML file, an error is returned.
+//
+// It takes the following parameters:
+//   - url: The path of the shared config file.
+//   - path: Not used by this store, and must be empty.
+func (fileConfigStore *FileConfigStore) Attach(url string, path string) (ConfigStoreLocation, error) {
+	if path != "" {
+		return ConfigStoreLocation{}, fmt.Errorf("the file store doesn't take a path within the given location")
+	}
+
+	absolutePath, err := filepath.Abs(url)
+	if err != nil {
+		return ConfigStoreLocation{}, fmt.Errorf("unable to parse the given path")
+	}
+
+	ext := filepath.Ext(absolutePath)
+	if ext != ".yml" && ext != ".yaml" {
+		return ConfigStoreLocation{}, fmt.Errorf("invalid file extension '%s': expected '.yml' or '.yaml'", ext)
+	}
+
+	dir := filepath.Dir(absolutePath)
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return ConfigStoreLocation{}, fmt.Errorf("directory '%s' does not exist", dir)
+		}
+		return ConfigStoreLocation{}, fmt.Errorf("error checking directory '%s': %w", dir, err)
+	}
+
+	return ConfigStoreLocation{Store: FileConfigStoreName, Url: absolutePath, Path: absolutePath}, nil
+}
+
+// Read returns the contents of the shared config file at the given location.
+func (fileConfigStore *FileConfigStore) Read(location ConfigStoreLocation) ([]byte, error) {
+	return os.ReadFile(location.Path)
+}
+
+// Write atomically replaces the contents of the shared config file at the given location. The description of the
+// change isn't used.
+func (fileConfigStore *FileConfigStore) Write(location ConfigStoreLocation, contents []byte, description string) error {
+	return writeFileAtomically(location.Path, contents, 0600)
+}
+
+// Watch checks the shared config file at the given location for changes every fileWatchInterval, and calls the given
+// function when it changes.
+func (fileConfigStore *FileConfigStore) Watch(location ConfigStoreLocation, onChange func()) (func(), error) {
+	return pollForChanges(fileWatchInterval, func() []byte {
+		return readFileOrNil(location.Path)
+	}, onChange), nil
+}
+
+// Lock takes a lock on the shared config file at the given location, using a lock file next to it.
+func (fileConfigStore *FileConfigStore) Lock(location ConfigStoreLocation) (*ConfigLock, error) {
+	return acquireLockFile(configLockPath(location.Path))
+}
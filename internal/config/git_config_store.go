@@ -0,0 +1,252 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/adrg/xdg"
+	"github.com/colececil/familiar.sh/internal/collections"
+	"github.com/colececil/familiar.sh/internal/system"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const gitConfigStoreName = "git"
+
+// gitWatchInterval is how often GitConfigStore pulls the latest changes to the repository while watching the shared
+// config file.
+const gitWatchInterval = 30 * time.Second
+
+// gitPushAttempts is the number of times to try pushing a commit to the config repository. If a push is rejected
+// because another machine pushed first, the other machine's commits are pulled and rebased on before trying again.
+const gitPushAttempts = 3
+
+// gitExcludePatterns are added to the config repository's ".git/info/exclude" file, so that the lock file and the
+// temporary files Familiar.sh writes beside the shared config file aren't shown as untracked. The history directory
+// isn't excluded, since it is committed along with the shared config file.
+var gitExcludePatterns = []string{".*.lock", ".*.tmp-*"}
+
+// GitConfigStore is a ConfigStore that keeps the shared config file in a git repository. The repository is cloned into
+// the XDG data directory, the latest changes are pulled before the config file is read, and each change Familiar.sh
+// makes to the config file is committed and pushed.
+type GitConfigStore struct {
+	shellCommandService *system.ShellCommandService
+	fileConfigStore     *FileConfigStore
+	messageWriterFunc   system.MessageWriterFunc
+	// pulledRepositories holds the URLs of the repositories whose latest changes have been pulled, so a command doesn't
+	// pull more than once.
+	pulledRepositories map[string]bool
+}
+
+// NewGitConfigStore creates a new instance of GitConfigStore.
+func NewGitConfigStore(shellCommandService *system.ShellCommandService, fileConfigStore *FileConfigStore,
+	messageWriterFunc system.MessageWriterFunc) *GitConfigStore {
+	return &GitConfigStore{
+		shellCommandService: shellCommandService,
+		fileConfigStore:     fileConfigStore,
+		messageWriterFunc:   messageWriterFunc,
+		pulledRepositories:  make(map[string]bool),
+	}
+}
+
+// Name returns the name of the store.
+func (gitConfigStore *GitConfigStore) Name() string {
+	return gitConfigStoreName
+}
+
+// Attach clones the given git repository into the XDG data directory (or pulls it, if it has already been cloned), and
+// returns the location of the shared config file in it.
+//
+// It takes the following parameters:
+//   - url: The URL of the git repository, as passed to "git clone".
+//   - path: The path of the shared config file, relative to the root of the repository. If empty, "config.yaml" is
+//     used.
+func (gitConfigStore *GitConfigStore) Attach(url string, path string) (ConfigStoreLocation, error) {
+	if path == "" {
+		path = defaultConfigFileName
+	}
+
+	ext := filepath.Ext(path)
+	if ext != ".yml" && ext != ".yaml" {
+		return ConfigStoreLocation{}, fmt.Errorf("invalid file extension '%s': expected '.yml' or '.yaml'", ext)
+	}
+	if filepath.IsAbs(path) || strings.HasPrefix(filepath.Clean(path), "..") {
+		return ConfigStoreLocation{}, fmt.Errorf("the config file path must be relative to the root of the repository")
+	}
+
+	repositoryDirectory := gitRepositoryDirectory(url)
+	if _, err := os.Stat(repositoryDirectory); os.IsNotExist(err) {
+		if err = os.MkdirAll(filepath.Dir(repositoryDirectory), 0700); err != nil {
+			return ConfigStoreLocation{}, err
+		}
+		if err = gitConfigStore.runGit("", "clone", "-q", url, repositoryDirectory); err != nil {
+			return ConfigStoreLocation{}, fmt.Errorf("unable to clone git repository %q: %w", system.RedactUrl(url),
+				err)
+		}
+	} else if err != nil {
+		return ConfigStoreLocation{}, err
+	} else if err = gitConfigStore.runGit(repositoryDirectory, "pull", "-q", "--rebase", "--autostash"); err != nil {
+		return ConfigStoreLocation{}, fmt.Errorf("unable to pull from git repository %q: %w", system.RedactUrl(url),
+			err)
+	}
+	gitConfigStore.pulledRepositories[url] = true
+
+	if err := addGitExcludePatterns(repositoryDirectory); err != nil {
+		return ConfigStoreLocation{}, err
+	}
+
+	configLocation := filepath.Join(repositoryDirectory, path)
+	if err := os.MkdirAll(filepath.Dir(configLocation), 0700); err != nil {
+		return ConfigStoreLocation{}, err
+	}
+
+	return ConfigStoreLocation{Store: gitConfigStoreName, Url: url, Path: configLocation}, nil
+}
+
+// Read pulls the latest changes to the repository, and then returns the contents of the shared config file in it. This
+// is only done once per repository, so a command doesn't pull more than once. If the pull fails (for example, because
+// the machine is offline), a warning is printed and the local copy is used.
+func (gitConfigStore *GitConfigStore) Read(location ConfigStoreLocation) ([]byte, error) {
+	if !gitConfigStore.pulledRepositories[location.Url] {
+		gitConfigStore.pulledRepositories[location.Url] = true
+		if err := gitConfigStore.pull(location); err != nil {
+			_, _ = fmt.Fprintf(gitConfigStore.messageWriterFunc(), "Warning: Unable to pull the latest changes from "+
+				"git repository \"%s\", so the local copy of the config file will be used.\n", location.RedactedUrl())
+		}
+	}
+
+	return gitConfigStore.fileConfigStore.Read(location)
+}
+
+// Write replaces the contents of the shared config file in the local clone of the repository, and then commits the
+// change with the given description as the commit message and pushes it. The history directory beside the shared
+// config file is committed along with it, so that every machine sharing the repository has the same history. If the
+// push is rejected because the remote has new commits, they are pulled with a rebase and the push is tried again. If
+// the remote can't be reached (for example, because the machine is offline), a warning is printed and the commit is
+// left to be pushed along with the next change, since it is already safe in the local clone.
+func (gitConfigStore *GitConfigStore) Write(location ConfigStoreLocation, contents []byte, description string) error {
+	if err := gitConfigStore.fileConfigStore.Write(location, contents, description); err != nil {
+		return err
+	}
+
+	repositoryDirectory := gitRepositoryDirectory(location.Url)
+	addArgs := []string{"add", "-A", "--", location.Path}
+	historyDirectory := ConfigHistoryDirectory(location.Path)
+	if _, err := os.Stat(historyDirectory); err == nil {
+		addArgs = append(addArgs, historyDirectory)
+	}
+	if err := gitConfigStore.runGit(repositoryDirectory, addArgs...); err != nil {
+		return fmt.Errorf("unable to stage the config file in git repository %q: %w", location.RedactedUrl(), err)
+	}
+
+	if err := gitConfigStore.runGit(repositoryDirectory, "diff", "--cached", "--quiet"); err == nil {
+		return nil
+	}
+
+	if err := gitConfigStore.runGit(repositoryDirectory, "commit", "-q", "-m", description); err != nil {
+		return fmt.Errorf("unable to commit the config file to git repository %q: %w", location.RedactedUrl(), err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := gitConfigStore.runGit(repositoryDirectory, "push", "-q")
+		if err == nil {
+			return nil
+		}
+
+		// Fetching tells a push that was rejected because the remote has new commits apart from one that failed
+		// because the remote couldn't be reached, or for some other reason.
+		if fetchErr := gitConfigStore.runGit(repositoryDirectory, "fetch", "-q"); fetchErr != nil {
+			_, _ = fmt.Fprintf(gitConfigStore.messageWriterFunc(), "Warning: Unable to reach git repository \"%s\", "+
+				"so the config file change was committed locally but not pushed. It will be pushed along with the "+
+				"next change to the config file.\n", location.RedactedUrl())
+			return nil
+		}
+		if gitConfigStore.runGit(repositoryDirectory, "merge-base", "--is-ancestor", "@{upstream}", "HEAD") == nil {
+			return fmt.Errorf("the config file change was committed, but could not be pushed to git repository %q: "+
+				"%w", location.RedactedUrl(), err)
+		}
+		if attempt >= gitPushAttempts {
+			return fmt.Errorf("the config file change was committed, but could not be pushed to git repository %q. "+
+				"It will be pushed the next time the config file is changed: %w", location.RedactedUrl(), err)
+		}
+
+		if err = gitConfigStore.runGit(repositoryDirectory, "pull", "-q", "--rebase"); err != nil {
+			_ = gitConfigStore.runGit(repositoryDirectory, "rebase", "--abort")
+			return fmt.Errorf("the config file was also changed in git repository %q, and the changes could not be "+
+				"merged automatically. Resolve the conflict in \"%s\" and push it by hand", location.RedactedUrl(),
+				repositoryDirectory)
+		}
+	}
+}
+
+// Watch pulls the latest changes to the repository every gitWatchInterval, and calls the given function when the
+// shared config file changes.
+func (gitConfigStore *GitConfigStore) Watch(location ConfigStoreLocation, onChange func()) (func(), error) {
+	return pollForChanges(gitWatchInterval, func() []byte {
+		_ = gitConfigStore.pull(location)
+		return readFileOrNil(location.Path)
+	}, onChange), nil
+}
+
+// Lock takes a lock on the shared config file using a lock file beside it in the local clone of the repository. Since
+// the lock file isn't committed, it only keeps other processes on the current machine out. Other machines are kept from
+// overwriting changes by the push being rejected until they pull.
+func (gitConfigStore *GitConfigStore) Lock(location ConfigStoreLocation) (*ConfigLock, error) {
+	return gitConfigStore.fileConfigStore.Lock(location)
+}
+
+// pull pulls the latest changes to the repository containing the shared config file at the given location.
+func (gitConfigStore *GitConfigStore) pull(location ConfigStoreLocation) error {
+	return gitConfigStore.runGit(gitRepositoryDirectory(location.Url), "pull", "-q", "--rebase", "--autostash")
+}
+
+// runGit runs git with the given arguments in the given directory. If the directory is empty, the current directory is
+// used.
+func (gitConfigStore *GitConfigStore) runGit(directory string, args ...string) error {
+	if directory != "" {
+		args = append([]string{"-C", directory}, args...)
+	}
+	_, err := gitConfigStore.shellCommandService.RunShellCommand("git", false, nil, args...)
+	return err
+}
+
+// addGitExcludePatterns adds gitExcludePatterns to the given repository's ".git/info/exclude" file, if they aren't
+// there already.
+func addGitExcludePatterns(repositoryDirectory string) error {
+	excludeFilePath := filepath.Join(repositoryDirectory, ".git", "info", "exclude")
+	contents, err := os.ReadFile(excludeFilePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	existingPatterns := strings.Split(string(contents), "\n")
+	var missingPatterns []string
+	for _, pattern := range gitExcludePatterns {
+		if !collections.Contains(existingPatterns, pattern) {
+			missingPatterns = append(missingPatterns, pattern)
+		}
+	}
+
+	if len(missingPatterns) == 0 {
+		return nil
+	}
+
+	if len(contents) > 0 && !strings.HasSuffix(string(contents), "\n") {
+		contents = append(contents, '\n')
+	}
+	contents = append(contents, []byte(strings.Join(missingPatterns, "\n")+"\n")...)
+
+	if err = os.MkdirAll(filepath.Dir(excludeFilePath), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(excludeFilePath, contents, 0600)
+}
+
+// gitRepositoryDirectory returns the directory the git repository with the given URL is cloned into. Each repository
+// URL gets its own directory, so switching between repositories doesn't lose unpushed changes.
+func gitRepositoryDirectory(repositoryUrl string) string {
+	hash := sha256.Sum256([]byte(repositoryUrl))
+	return filepath.Join(xdg.DataHome, appDirectoryName, "repositories", hex.EncodeToString(hash[:])[:16])
+}
@@ -0,0 +1,164 @@
+package config_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+	. "github.com/colececil/familiar.sh/internal/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GitConfigStore", func() {
+	const initialContents = "version: 1\nfiles: []\nscripts: []\npackageManagers: []\n"
+
+	var remoteDirectory string
+	var configService *ConfigService
+
+	// runGit runs git with the given arguments in the given directory, and returns its trimmed output.
+	runGit := func(directory string, args ...string) string {
+		output, err := exec.Command("git", append([]string{"-C", directory}, args...)...).CombinedOutput()
+		Expect(err).To(BeNil(), string(output))
+		return strings.TrimSpace(string(output))
+	}
+
+	// pushFromOtherMachine clones the remote repository, writes the given file, and pushes it, like another machine
+	// sharing the repository would.
+	pushFromOtherMachine := func(fileName string, contents string) {
+		otherDirectory := filepath.Join(GinkgoT().TempDir(), "other")
+		runGit(".", "clone", "-q", remoteDirectory, otherDirectory)
+		Expect(os.WriteFile(filepath.Join(otherDirectory, fileName), []byte(contents), 0600)).To(Succeed())
+		runGit(otherDirectory, "add", fileName)
+		runGit(otherDirectory, "commit", "-q", "-m", "Change from another machine")
+		runGit(otherDirectory, "push", "-q")
+	}
+
+	BeforeEach(func() {
+		if _, err := exec.LookPath("git"); err != nil {
+			Skip("git is not installed")
+		}
+
+		useTemporaryConfigLocation()
+		GinkgoT().Setenv("XDG_DATA_HOME", GinkgoT().TempDir())
+		xdg.Reload()
+		for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+			GinkgoT().Setenv(name, "Test")
+		}
+		for _, name := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+			GinkgoT().Setenv(name, "test@example.com")
+		}
+
+		remoteDirectory = filepath.Join(GinkgoT().TempDir(), "remote.git")
+		runGit(".", "init", "-q", "--bare", "-b", "main", remoteDirectory)
+		pushFromOtherMachine("config.yaml", initialContents)
+
+		configService = newConfigService()
+		Expect(configService.SetConfigStoreLocation("git", remoteDirectory, "")).To(Succeed())
+	})
+
+	It("should read the config file from a clone of the repository", func() {
+		storeLocation, err := configService.GetConfigStoreLocation()
+		Expect(err).To(BeNil())
+		Expect(storeLocation.Store).To(Equal("git"))
+		Expect(storeLocation.Url).To(Equal(remoteDirectory))
+
+		configLocation, err := configService.GetConfigLocation()
+		Expect(err).To(BeNil())
+		Expect(configLocation).To(Equal(storeLocation.Path))
+		Expect(configLocation).To(HavePrefix(xdg.DataHome))
+
+		contents, err := os.ReadFile(configLocation)
+		Expect(err).To(BeNil())
+		Expect(string(contents)).To(Equal(initialContents))
+	})
+
+	It("should commit and push changes with a descriptive message", func() {
+		config, err := configService.GetSharedConfig()
+		Expect(err).To(BeNil())
+		config.Scripts = append(config.Scripts, ConfiguredScript{SourcePath: "setup.sh"})
+		Expect(configService.SetConfig(config)).To(Succeed())
+
+		Expect(runGit(remoteDirectory, "log", "-1", "--format=%s")).To(Equal(`Familiar.sh: add script "setup.sh"`))
+		Expect(runGit(remoteDirectory, "show", "main:config.yaml")).To(ContainSubstring("setup.sh"))
+	})
+
+	It("should commit the history along with the config file, so other machines can roll back changes", func() {
+		config, err := configService.GetSharedConfig()
+		Expect(err).To(BeNil())
+		config.Scripts = append(config.Scripts, ConfiguredScript{SourcePath: "setup.sh"})
+		Expect(configService.SetConfig(config)).To(Succeed())
+
+		snapshots, err := configService.GetConfigHistory()
+		Expect(err).To(BeNil())
+		Expect(snapshots).To(HaveLen(1))
+		Expect(runGit(remoteDirectory, "show", "main:.familiar-history/"+snapshots[0].Id+".yaml")).To(
+			Equal(strings.TrimSpace(initialContents)))
+	})
+
+	It("should pull the latest changes before reading the config file", func() {
+		pushFromOtherMachine("config.yaml", strings.Replace(initialContents, "files: []",
+			"files:\n  - sourcePath: .bashrc", 1))
+
+		config, err := newConfigService().GetSharedConfig()
+		Expect(err).To(BeNil())
+		Expect(config.Files).To(Equal([]ConfiguredFile{{SourcePath: ".bashrc"}}))
+	})
+
+	It("should rebase and push again when another machine pushed first", func() {
+		config, err := configService.GetSharedConfig()
+		Expect(err).To(BeNil())
+
+		pushFromOtherMachine("notes.txt", "Some notes.\n")
+
+		config.Scripts = append(config.Scripts, ConfiguredScript{SourcePath: "setup.sh"})
+		Expect(configService.SetConfig(config)).To(Succeed())
+
+		Expect(runGit(remoteDirectory, "log", "--format=%s")).To(Equal("Familiar.sh: add script \"setup.sh\"\n" +
+			"Change from another machine\nChange from another machine"))
+	})
+
+	It("should keep the commit locally and succeed when the repository can't be reached", func() {
+		config, err := configService.GetSharedConfig()
+		Expect(err).To(BeNil())
+		configLocation, err := configService.GetConfigLocation()
+		Expect(err).To(BeNil())
+
+		unreachableDirectory := remoteDirectory + ".unreachable"
+		Expect(os.Rename(remoteDirectory, unreachableDirectory)).To(Succeed())
+
+		config.Scripts = append(config.Scripts, ConfiguredScript{SourcePath: "setup.sh"})
+		Expect(configService.SetConfig(config)).To(Succeed())
+		Expect(runGit(filepath.Dir(configLocation), "log", "-1", "--format=%s")).To(
+			Equal(`Familiar.sh: add script "setup.sh"`))
+
+		Expect(os.Rename(unreachableDirectory, remoteDirectory)).To(Succeed())
+		config.Files = append(config.Files, ConfiguredFile{SourcePath: ".bashrc"})
+		Expect(configService.SetConfig(config)).To(Succeed())
+		Expect(runGit(remoteDirectory, "log", "-2", "--format=%s")).To(Equal("Familiar.sh: add file \".bashrc\"\n" +
+			"Familiar.sh: add script \"setup.sh\""))
+	})
+
+	It("should return an error, rather than report a conflict, when the push fails for another reason", func() {
+		hookPath := filepath.Join(remoteDirectory, "hooks", "pre-receive")
+		Expect(os.WriteFile(hookPath, []byte("#!/bin/sh\nexit 1\n"), 0700)).To(Succeed())
+
+		config, err := configService.GetSharedConfig()
+		Expect(err).To(BeNil())
+		config.Scripts = append(config.Scripts, ConfiguredScript{SourcePath: "setup.sh"})
+
+		err = configService.SetConfig(config)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("was committed, but could not be pushed"))
+	})
+
+	It("should stop using the repository when the location is set to a plain path", func() {
+		Expect(configService.SetConfigLocation(filepath.Join(GinkgoT().TempDir(), "config.yaml"))).To(Succeed())
+
+		storeLocation, err := configService.GetConfigStoreLocation()
+		Expect(err).To(BeNil())
+		Expect(storeLocation.Store).To(Equal("file"))
+	})
+})
@@ -0,0 +1,48 @@
+package config_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	. "github.com/colececil/familiar.sh/internal/config"
+	"github.com/colececil/familiar.sh/internal/system"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// newConfigService creates a ConfigService with the real config stores, which run real shell commands.
+func newConfigService() *ConfigService {
+	return newConfigServiceWithOverride(func() string { return "" })
+}
+
+// newConfigServiceWithOverride creates a ConfigService like newConfigService, which gets the value of the "--config"
+// flag from the given function.
+func newConfigServiceWithOverride(configOverrideFunc ConfigOverrideFunc) *ConfigService {
+	fileConfigStore := NewFileConfigStore()
+	messageWriterFunc := func() io.Writer { return GinkgoWriter }
+	shellCommandService := system.NewShellCommandService(system.NewRunShellCommandFunc(), func() bool { return false },
+		messageWriterFunc)
+	gitConfigStore := NewGitConfigStore(shellCommandService, fileConfigStore, messageWriterFunc)
+	return NewConfigService(NewConfigStoreRegistry(fileConfigStore, gitConfigStore,
+		NewHttpConfigStore(messageWriterFunc)), messageWriterFunc, configOverrideFunc)
+}
+
+// useTemporaryConfigLocation points the XDG config and state directories at new temporary directories, and sets the
+// shared config location to a file in another temporary directory. It returns the shared config location.
+func useTemporaryConfigLocation() string {
+	GinkgoT().Setenv("XDG_CONFIG_HOME", GinkgoT().TempDir())
+	GinkgoT().Setenv("XDG_STATE_HOME", GinkgoT().TempDir())
+	xdg.Reload()
+	DeferCleanup(xdg.Reload)
+
+	configLocation := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+	Expect(newConfigService().SetConfigLocation(configLocation)).To(Succeed())
+	return configLocation
+}
+
+// writeConfigFile writes the given contents to the config file at the given location.
+func writeConfigFile(configLocation string, contents string) {
+	Expect(os.WriteFile(configLocation, []byte(contents), 0600)).To(Succeed())
+}
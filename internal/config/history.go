@@ -0,0 +1,193 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// historyDirectoryName is the name of the directory, beside the shared config file, where snapshots of it are kept.
+const historyDirectoryName = ".familiar-history"
+
+// maxHistorySnapshots is the number of snapshots to keep. Once there are more, the oldest ones are deleted.
+const maxHistorySnapshots = 50
+
+// maxHistorySnapshotAge is how long to keep snapshots for. Older snapshots are deleted, as long as it wouldn't leave
+// the history empty.
+const maxHistorySnapshotAge = 90 * 24 * time.Hour
+
+// snapshotIdFormat is the time format used for snapshot IDs, which are also the names of the snapshot files. It sorts
+// in chronological order.
+const snapshotIdFormat = "20060102-150405.000"
+
+// ConfigSnapshot represents a copy of the shared config file, saved just before Familiar.sh changed it.
+type ConfigSnapshot struct {
+	// Id identifies the snapshot in commands such as "familiar config rollback".
+	Id string `yaml:"id"`
+	// Time is when the snapshot was saved.
+	Time time.Time `yaml:"time"`
+	// Path is the location of the snapshot file.
+	Path string `yaml:"path"`
+}
+
+// GetConfigHistory returns the snapshots of the shared config file that have been saved, newest first.
+func (configService *ConfigService) GetConfigHistory() ([]ConfigSnapshot, error) {
+	configLocation, err := configService.GetConfigLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	return listSnapshots(configLocation)
+}
+
+// ReadSnapshot returns the snapshot with the given ID, along with its contents.
+//
+// It throws an error if there is no snapshot with the given ID.
+func (configService *ConfigService) ReadSnapshot(snapshotId string) (*ConfigSnapshot, []byte, error) {
+	snapshots, err := configService.GetConfigHistory()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range snapshots {
+		if snapshots[i].Id == snapshotId {
+			contents, err := os.ReadFile(snapshots[i].Path)
+			if err != nil {
+				return nil, nil, err
+			}
+			return &snapshots[i], contents, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("snapshot %q not found. Run \"familiar config history\" to list the available "+
+		"snapshots", snapshotId)
+}
+
+// RollBackConfig replaces the contents of the shared config file with the snapshot with the given ID. Like any other
+// change, a snapshot of the current contents is saved first, so the rollback can be undone.
+//
+// It throws an error under the following conditions:
+//   - There is no snapshot with the given ID.
+//   - The snapshot isn't a valid config file for this version of Familiar.sh.
+func (configService *ConfigService) RollBackConfig(snapshotId string) error {
+	_, contents, err := configService.ReadSnapshot(snapshotId)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err = MigrateConfig(contents); err != nil {
+		return fmt.Errorf("snapshot %q can't be restored: %w", snapshotId, err)
+	}
+
+	description := fmt.Sprintf("Familiar.sh: roll back config to snapshot %s", snapshotId)
+	return configService.writeSharedConfig("", contents, description)
+}
+
+// saveSnapshot saves the given contents of the shared config file to the history directory, and then deletes any
+// snapshots that are past the retention limits. If the contents are the same as the newest snapshot, no new snapshot
+// is saved.
+//
+// It takes the following parameters:
+//   - configLocation: The location of the shared config file.
+//   - contents: The contents to save.
+func saveSnapshot(configLocation string, contents []byte) error {
+	snapshots, err := listSnapshots(configLocation)
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) > 0 {
+		newestContents, err := os.ReadFile(snapshots[0].Path)
+		if err == nil && bytes.Equal(newestContents, contents) {
+			return nil
+		}
+	}
+
+	historyDirectory := ConfigHistoryDirectory(configLocation)
+	if err = os.MkdirAll(historyDirectory, 0700); err != nil {
+		return fmt.Errorf("unable to create history directory \"%s\": %w", historyDirectory, err)
+	}
+
+	// Snapshot IDs must be unique and increasing, even when two snapshots are saved within the same millisecond.
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	if len(snapshots) > 0 && !now.After(snapshots[0].Time) {
+		now = snapshots[0].Time.Add(time.Millisecond)
+	}
+	snapshot := ConfigSnapshot{
+		Id:   now.Format(snapshotIdFormat),
+		Time: now,
+		Path: filepath.Join(historyDirectory, now.Format(snapshotIdFormat)+filepath.Ext(configLocation)),
+	}
+	if err = writeFileAtomically(snapshot.Path, contents, 0600); err != nil {
+		return fmt.Errorf("unable to save snapshot of config file: %w", err)
+	}
+
+	return pruneSnapshots(append([]ConfigSnapshot{snapshot}, snapshots...), now)
+}
+
+// pruneSnapshots deletes the snapshots that are past the retention limits. The newest snapshot is always kept.
+//
+// It takes the following parameters:
+//   - snapshots: All snapshots, newest first.
+//   - now: The current time.
+func pruneSnapshots(snapshots []ConfigSnapshot, now time.Time) error {
+	for i, snapshot := range snapshots {
+		if i == 0 || (i < maxHistorySnapshots && now.Sub(snapshot.Time) <= maxHistorySnapshotAge) {
+			continue
+		}
+
+		if err := os.Remove(snapshot.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("unable to delete old snapshot \"%s\": %w", snapshot.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// listSnapshots returns the snapshots of the shared config file at the given location, newest first. Files in the
+// history directory that aren't snapshots are ignored.
+func listSnapshots(configLocation string) ([]ConfigSnapshot, error) {
+	historyDirectory := ConfigHistoryDirectory(configLocation)
+	entries, err := os.ReadDir(historyDirectory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []ConfigSnapshot{}, nil
+		}
+		return nil, err
+	}
+
+	snapshots := []ConfigSnapshot{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != filepath.Ext(configLocation) {
+			continue
+		}
+
+		snapshotId := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		snapshotTime, err := time.Parse(snapshotIdFormat, snapshotId)
+		if err != nil {
+			continue
+		}
+
+		snapshots = append(snapshots, ConfigSnapshot{
+			Id:   snapshotId,
+			Time: snapshotTime,
+			Path: filepath.Join(historyDirectory, entry.Name()),
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Id > snapshots[j].Id
+	})
+	return snapshots, nil
+}
+
+// ConfigHistoryDirectory returns the path of the directory where snapshots of the shared config file at the given
+// location are kept. It is beside the shared config file, so that the history is shared along with it, and a change
+// made on one machine can be rolled back from another.
+func ConfigHistoryDirectory(configLocation string) string {
+	return filepath.Join(filepath.Dir(configLocation), historyDirectoryName)
+}
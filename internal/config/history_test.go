@@ -0,0 +1,133 @@
+package config_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/colececil/familiar.sh/internal/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConfigService history", func() {
+	const firstContents = "version: 1\nfiles: []\nscripts: []\npackageManagers: []\n"
+
+	var configLocation string
+	var configService *ConfigService
+
+	BeforeEach(func() {
+		configLocation = useTemporaryConfigLocation()
+		writeConfigFile(configLocation, firstContents)
+		configService = newConfigService()
+	})
+
+	addScript := func(sourcePath string) {
+		config, err := configService.GetSharedConfig()
+		Expect(err).To(BeNil())
+		config.Scripts = append(config.Scripts, ConfiguredScript{SourcePath: sourcePath})
+		Expect(configService.SetConfig(config)).To(Succeed())
+	}
+
+	It("should save a snapshot of the previous contents on every write", func() {
+		addScript("setup1.sh")
+		addScript("setup2.sh")
+
+		snapshots, err := configService.GetConfigHistory()
+		Expect(err).To(BeNil())
+		Expect(snapshots).To(HaveLen(2))
+		Expect(filepath.Dir(snapshots[0].Path)).To(Equal(ConfigHistoryDirectory(configLocation)))
+
+		_, oldestContents, err := configService.ReadSnapshot(snapshots[1].Id)
+		Expect(err).To(BeNil())
+		Expect(string(oldestContents)).To(Equal(firstContents))
+
+		_, newestContents, err := configService.ReadSnapshot(snapshots[0].Id)
+		Expect(err).To(BeNil())
+		Expect(string(newestContents)).To(ContainSubstring("setup1.sh"))
+		Expect(string(newestContents)).ToNot(ContainSubstring("setup2.sh"))
+	})
+
+	It("should keep the history beside the shared config file, without leaving the lock file behind", func() {
+		addScript("setup.sh")
+
+		entries, err := os.ReadDir(filepath.Dir(configLocation))
+		Expect(err).To(BeNil())
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Name()).To(Equal(".familiar-history"))
+		Expect(entries[1].Name()).To(Equal("config.yaml"))
+	})
+
+	It("should not save a snapshot when a write doesn't change the file", func() {
+		config, err := configService.GetSharedConfig()
+		Expect(err).To(BeNil())
+		Expect(configService.SetConfig(config)).To(Succeed())
+
+		Expect(configService.GetConfigHistory()).To(BeEmpty())
+
+		addScript("setup.sh")
+		snapshots, err := configService.GetConfigHistory()
+		Expect(err).To(BeNil())
+		Expect(configService.RollBackConfig(snapshots[0].Id)).To(Succeed())
+		Expect(configService.RollBackConfig(snapshots[0].Id)).To(Succeed())
+		Expect(configService.GetConfigHistory()).To(HaveLen(2))
+	})
+
+	It("should delete the oldest snapshots once there are more than 50", func() {
+		historyDirectory := ConfigHistoryDirectory(configLocation)
+		Expect(os.MkdirAll(historyDirectory, 0700)).To(Succeed())
+
+		var snapshotPaths []string
+		for i := 0; i < 60; i++ {
+			snapshotId := time.Now().Add(-time.Hour).Add(time.Duration(i) * time.Second).UTC().
+				Format("20060102-150405.000")
+			snapshotPath := filepath.Join(historyDirectory, snapshotId+".yaml")
+			Expect(os.WriteFile(snapshotPath, []byte(fmt.Sprintf("# %d\n", i)), 0600)).To(Succeed())
+			snapshotPaths = append(snapshotPaths, snapshotPath)
+		}
+
+		addScript("setup.sh")
+
+		snapshots, err := configService.GetConfigHistory()
+		Expect(err).To(BeNil())
+		Expect(snapshots).To(HaveLen(50))
+		Expect(snapshotPaths[10]).ToNot(BeAnExistingFile())
+		Expect(snapshotPaths[11]).To(BeAnExistingFile())
+	})
+
+	It("should delete snapshots that are older than 90 days", func() {
+		historyDirectory := ConfigHistoryDirectory(configLocation)
+		Expect(os.MkdirAll(historyDirectory, 0700)).To(Succeed())
+		oldSnapshotPath := filepath.Join(historyDirectory,
+			time.Now().Add(-91*24*time.Hour).UTC().Format("20060102-150405.000")+".yaml")
+		Expect(os.WriteFile(oldSnapshotPath, []byte("# old\n"), 0600)).To(Succeed())
+
+		addScript("setup.sh")
+
+		snapshots, err := configService.GetConfigHistory()
+		Expect(err).To(BeNil())
+		Expect(snapshots).To(HaveLen(1))
+		Expect(oldSnapshotPath).ToNot(BeAnExistingFile())
+	})
+
+	It("should roll back to a snapshot, saving a snapshot of the current contents first", func() {
+		addScript("setup.sh")
+
+		snapshots, err := configService.GetConfigHistory()
+		Expect(err).To(BeNil())
+		Expect(configService.RollBackConfig(snapshots[0].Id)).To(Succeed())
+
+		contents, err := os.ReadFile(configLocation)
+		Expect(err).To(BeNil())
+		Expect(string(contents)).To(Equal(firstContents))
+
+		snapshots, err = configService.GetConfigHistory()
+		Expect(err).To(BeNil())
+		Expect(snapshots).To(HaveLen(2))
+	})
+
+	It("should return an error for an unknown snapshot", func() {
+		Expect(configService.RollBackConfig("20000101-000000.000")).ToNot(Succeed())
+	})
+})
@@ -0,0 +1,188 @@
+package config_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/adrg/xdg"
+	. "github.com/colececil/familiar.sh/internal/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeHttpServer is a minimal HTTP file server that supports GET and PUT with ETag-based conditional requests, like a
+// WebDAV server.
+type fakeHttpServer struct {
+	mutex sync.Mutex
+	files map[string]string
+	// fullResponses counts the GET requests that returned the file's contents, keyed by path.
+	fullResponses map[string]int
+}
+
+func (fakeHttpServer *fakeHttpServer) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	fakeHttpServer.mutex.Lock()
+	defer fakeHttpServer.mutex.Unlock()
+
+	path := strings.TrimPrefix(request.URL.Path, "/")
+	contents, exists := fakeHttpServer.files[path]
+	etag := ""
+	if exists {
+		hash := sha256.Sum256([]byte(contents))
+		etag = `"` + hex.EncodeToString(hash[:8]) + `"`
+	}
+
+	switch request.Method {
+	case http.MethodGet, http.MethodHead:
+		if !exists {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writer.Header().Set("ETag", etag)
+		if request.Header.Get("If-None-Match") == etag {
+			writer.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fakeHttpServer.fullResponses[path]++
+		_, _ = io.WriteString(writer, contents)
+	case http.MethodPut:
+		ifMatch := request.Header.Get("If-Match")
+		if (ifMatch != "" && ifMatch != etag) || (request.Header.Get("If-None-Match") == "*" && exists) {
+			writer.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		body, _ := io.ReadAll(request.Body)
+		fakeHttpServer.files[path] = string(body)
+		writer.WriteHeader(http.StatusNoContent)
+	default:
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// setFile sets the contents of the file at the given path, like another machine writing it would.
+func (fakeHttpServer *fakeHttpServer) setFile(path string, contents string) {
+	fakeHttpServer.mutex.Lock()
+	defer fakeHttpServer.mutex.Unlock()
+	fakeHttpServer.files[path] = contents
+}
+
+// getFile returns the contents of the file at the given path.
+func (fakeHttpServer *fakeHttpServer) getFile(path string) string {
+	fakeHttpServer.mutex.Lock()
+	defer fakeHttpServer.mutex.Unlock()
+	return fakeHttpServer.files[path]
+}
+
+var _ = Describe("HttpConfigStore", func() {
+	const initialContents = "version: 1\ninclude:\n  - packages.yaml\nfiles: []\nscripts:\n  - sourcePath: setup.sh\n" +
+		"packageManagers: []\n"
+
+	var server *fakeHttpServer
+	var httpServer *httptest.Server
+	var configService *ConfigService
+
+	BeforeEach(func() {
+		useTemporaryConfigLocation()
+		GinkgoT().Setenv("XDG_DATA_HOME", GinkgoT().TempDir())
+		xdg.Reload()
+
+		server = &fakeHttpServer{
+			files: map[string]string{
+				"familiar/config.yaml":   initialContents,
+				"familiar/packages.yaml": "files:\n  - sourcePath: .bashrc\n",
+				"familiar/setup.sh":      "echo setup\n",
+				"familiar/.bashrc":       "alias ll='ls -l'\n",
+			},
+			fullResponses: make(map[string]int),
+		}
+		httpServer = httptest.NewServer(server)
+		DeferCleanup(httpServer.Close)
+
+		configService = newConfigService()
+		Expect(configService.SetConfigStoreLocation("http", httpServer.URL+"/familiar/", "")).To(Succeed())
+	})
+
+	It("should cache the config file and the files it refers to", func() {
+		configLocation, err := configService.GetConfigLocation()
+		Expect(err).To(BeNil())
+		Expect(configLocation).To(HavePrefix(xdg.DataHome))
+
+		contents, err := os.ReadFile(configLocation)
+		Expect(err).To(BeNil())
+		Expect(string(contents)).To(Equal(initialContents))
+
+		configDirectory := filepath.Dir(configLocation)
+		for _, name := range []string{"packages.yaml", "setup.sh", ".bashrc"} {
+			Expect(filepath.Join(configDirectory, name)).To(BeAnExistingFile())
+		}
+
+		config, err := configService.GetConfig()
+		Expect(err).To(BeNil())
+		Expect(config.Files).To(Equal([]ConfiguredFile{{SourcePath: ".bashrc"}}))
+	})
+
+	It("should only download files again when they have changed", func() {
+		_, err := newConfigService().GetSharedConfig()
+		Expect(err).To(BeNil())
+		Expect(server.fullResponses["familiar/config.yaml"]).To(Equal(1))
+
+		server.setFile("familiar/config.yaml", strings.Replace(initialContents, "files: []",
+			"files:\n  - sourcePath: .vimrc", 1))
+		config, err := newConfigService().GetSharedConfig()
+		Expect(err).To(BeNil())
+		Expect(server.fullResponses["familiar/config.yaml"]).To(Equal(2))
+		Expect(config.Files).To(Equal([]ConfiguredFile{{SourcePath: ".vimrc"}}))
+	})
+
+	It("should write changes to the server", func() {
+		config, err := configService.GetSharedConfig()
+		Expect(err).To(BeNil())
+		config.Scripts = append(config.Scripts, ConfiguredScript{SourcePath: "cleanup.sh"})
+		Expect(configService.SetConfig(config)).To(Succeed())
+
+		Expect(server.getFile("familiar/config.yaml")).To(ContainSubstring("cleanup.sh"))
+
+		config, err = configService.GetSharedConfig()
+		Expect(err).To(BeNil())
+		config.Scripts = config.Scripts[:1]
+		Expect(configService.SetConfig(config)).To(Succeed())
+		Expect(server.getFile("familiar/config.yaml")).ToNot(ContainSubstring("cleanup.sh"))
+	})
+
+	It("should not overwrite changes made on the server after the config file was fetched", func() {
+		config, err := configService.GetSharedConfig()
+		Expect(err).To(BeNil())
+
+		server.setFile("familiar/config.yaml", initialContents+"# Changed on another machine.\n")
+
+		config.Scripts = append(config.Scripts, ConfiguredScript{SourcePath: "cleanup.sh"})
+		Expect(errors.Is(configService.SetConfig(config), ErrConfigChanged)).To(BeTrue())
+		Expect(server.getFile("familiar/config.yaml")).ToNot(ContainSubstring("cleanup.sh"))
+
+		config, err = configService.GetSharedConfig()
+		Expect(err).To(BeNil())
+		config.Scripts = append(config.Scripts, ConfiguredScript{SourcePath: "cleanup.sh"})
+		Expect(configService.SetConfig(config)).To(Succeed())
+		Expect(server.getFile("familiar/config.yaml")).To(And(ContainSubstring("cleanup.sh"),
+			ContainSubstring("# Changed on another machine.")))
+	})
+
+	It("should use the cached copy when the server can't be reached", func() {
+		httpServer.Close()
+
+		config, err := newConfigService().GetConfig()
+		Expect(err).To(BeNil())
+		Expect(config.Scripts).To(Equal([]ConfiguredScript{{SourcePath: "setup.sh"}}))
+	})
+
+	It("should return an error for a URL that isn't HTTP", func() {
+		Expect(configService.SetConfigStoreLocation("http", "ftp://example.com/", "")).ToNot(Succeed())
+	})
+})
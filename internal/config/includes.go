@@ -0,0 +1,166 @@
+package config
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ResolveIncludes returns the paths of the files matched by the given Config's include entries, in the order they
+// should be merged. Each entry is either a path or a glob pattern, relative to the directory of the shared config file
+// unless it is absolute. The files matched by a single glob pattern are sorted by path. A path without any glob
+// characters that doesn't exist results in an error, while a glob pattern that doesn't match anything is allowed.
+//
+// It takes the following parameters:
+//   - config: The Config containing the include entries.
+//   - configLocation: The location of the shared config file.
+func ResolveIncludes(config *Config, configLocation string) ([]string, error) {
+	configDirectory := filepath.Dir(configLocation)
+
+	var includePaths []string
+	seenPaths := map[string]bool{configLocation: true}
+	for _, includeEntry := range config.Include {
+		pattern := includeEntry
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(configDirectory, pattern)
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern %q: %w", includeEntry, err)
+		}
+
+		if len(matches) == 0 && !strings.ContainsAny(includeEntry, "*?[") {
+			return nil, fmt.Errorf("included file %q does not exist", pattern)
+		}
+
+		sort.Strings(matches)
+		for _, match := range matches {
+			if !seenPaths[match] {
+				seenPaths[match] = true
+				includePaths = append(includePaths, match)
+			}
+		}
+	}
+
+	return includePaths, nil
+}
+
+// MergeInclude merges the given included Config into the given Config, recording where each merged entry came from in
+// the given Provenance. Files and scripts are merged by source path, package managers are merged by name, and packages
+// are merged by name within their package manager. If an entry is already present, the existing definition is kept and
+// the duplicate is recorded in the Provenance. The included Config's version and include entries are ignored, since
+// includes are not nested.
+//
+// It takes the following parameters:
+//   - config: The Config to merge into.
+//   - includedConfig: The Config read from the included file.
+//   - provenance: The Provenance to record the merged entries in.
+//   - source: The location of the included file.
+func MergeInclude(config *Config, includedConfig *Config, provenance *Provenance, source string) {
+	provenance.AddSource(source)
+
+	for _, includedFile := range includedConfig.Files {
+		isDuplicate := false
+		for _, existingFile := range config.Files {
+			if existingFile.SourcePath == includedFile.SourcePath {
+				provenance.AddDuplicate(DuplicateEntry{
+					Description: fmt.Sprintf("file %q", includedFile.SourcePath),
+					Source:      source,
+					KeptSource:  provenance.FileSource(existingFile.SourcePath),
+				})
+				isDuplicate = true
+				break
+			}
+		}
+
+		if !isDuplicate {
+			config.Files = append(config.Files, includedFile)
+			provenance.SetFileSource(includedFile.SourcePath, source)
+		}
+	}
+
+	for _, includedScript := range includedConfig.Scripts {
+		isDuplicate := false
+		for _, existingScript := range config.Scripts {
+			if existingScript.SourcePath == includedScript.SourcePath {
+				provenance.AddDuplicate(DuplicateEntry{
+					Description: fmt.Sprintf("script %q", includedScript.SourcePath),
+					Source:      source,
+					KeptSource:  provenance.ScriptSource(existingScript.SourcePath),
+				})
+				isDuplicate = true
+				break
+			}
+		}
+
+		if !isDuplicate {
+			config.Scripts = append(config.Scripts, includedScript)
+			provenance.SetScriptSource(includedScript.SourcePath, source)
+		}
+	}
+
+	for _, includedPackageManager := range includedConfig.PackageManagers {
+		var matchingPackageManager *ConfiguredPackageManager
+		for i := range config.PackageManagers {
+			if config.PackageManagers[i].Name == includedPackageManager.Name {
+				matchingPackageManager = &config.PackageManagers[i]
+				break
+			}
+		}
+
+		if matchingPackageManager == nil {
+			config.PackageManagers = append(config.PackageManagers, ConfiguredPackageManager{
+				Name:     includedPackageManager.Name,
+				Packages: []ConfiguredPackage{},
+			})
+			matchingPackageManager = &config.PackageManagers[len(config.PackageManagers)-1]
+			provenance.SetPackageManagerSource(includedPackageManager.Name, source)
+		}
+
+		for _, includedPackage := range includedPackageManager.Packages {
+			isDuplicate := false
+			for _, existingPackage := range matchingPackageManager.Packages {
+				if existingPackage.Name == includedPackage.Name {
+					provenance.AddDuplicate(DuplicateEntry{
+						Description: fmt.Sprintf("package %q under package manager %q", includedPackage.Name,
+							includedPackageManager.Name),
+						Source:     source,
+						KeptSource: provenance.PackageSource(includedPackageManager.Name, existingPackage.Name),
+					})
+					isDuplicate = true
+					break
+				}
+			}
+
+			if !isDuplicate {
+				matchingPackageManager.Packages = append(matchingPackageManager.Packages, includedPackage)
+				provenance.SetPackageSource(includedPackageManager.Name, includedPackage.Name, source)
+			}
+		}
+	}
+}
+
+// readIncludedConfig reads the included config file at the given path. If it uses an older version of the config
+// format, it is migrated in memory, leaving the file itself unchanged.
+func readIncludedConfig(path string) (*Config, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	migratedBytes, _, err := MigrateConfig(bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read included file %q: %w", path, err)
+	}
+
+	var includedConfig Config
+	if err = yaml.Unmarshal(migratedBytes, &includedConfig); err != nil {
+		return nil, fmt.Errorf("error reading included file %q: %w", path, err)
+	}
+
+	return &includedConfig, nil
+}
@@ -0,0 +1,120 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/colececil/familiar.sh/internal/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Includes", func() {
+	Describe("ResolveIncludes", func() {
+		var configDirectory string
+		var configLocation string
+
+		BeforeEach(func() {
+			configDirectory = GinkgoT().TempDir()
+			configLocation = filepath.Join(configDirectory, "config.yaml")
+			Expect(os.MkdirAll(filepath.Join(configDirectory, "packages"), 0700)).To(Succeed())
+			for _, name := range []string{"packages/b.yaml", "packages/a.yaml", "files.yaml"} {
+				Expect(os.WriteFile(filepath.Join(configDirectory, name), []byte{}, 0600)).To(Succeed())
+			}
+		})
+
+		It("should resolve paths and glob patterns relative to the config file, sorting the matches of each pattern",
+			func() {
+				config := &Config{Include: []string{"files.yaml", "packages/*.yaml"}}
+
+				includePaths, err := ResolveIncludes(config, configLocation)
+				Expect(err).To(BeNil())
+				Expect(includePaths).To(Equal([]string{
+					filepath.Join(configDirectory, "files.yaml"),
+					filepath.Join(configDirectory, "packages", "a.yaml"),
+					filepath.Join(configDirectory, "packages", "b.yaml"),
+				}))
+			})
+
+		It("should return an error when an included path doesn't exist", func() {
+			config := &Config{Include: []string{"missing.yaml"}}
+
+			_, err := ResolveIncludes(config, configLocation)
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("should allow a glob pattern that doesn't match anything", func() {
+			config := &Config{Include: []string{"scripts/*.yaml"}}
+
+			includePaths, err := ResolveIncludes(config, configLocation)
+			Expect(err).To(BeNil())
+			Expect(includePaths).To(BeEmpty())
+		})
+	})
+
+	Describe("MergeInclude", func() {
+		It("should add new entries and keep the first definition of duplicate entries", func() {
+			config := &Config{
+				Files: []ConfiguredFile{{SourcePath: ".bashrc"}},
+				PackageManagers: []ConfiguredPackageManager{
+					{Name: "scoop", Packages: []ConfiguredPackage{{Name: "package1", Version: "1.0.0"}}},
+				},
+			}
+			includedConfig := &Config{
+				Files: []ConfiguredFile{{SourcePath: ".bashrc"}, {SourcePath: ".vimrc"}},
+				PackageManagers: []ConfiguredPackageManager{
+					{
+						Name: "scoop",
+						Packages: []ConfiguredPackage{
+							{Name: "package1", Version: "0.9.0"},
+							{Name: "package2", Version: "2.0.0"},
+						},
+					},
+				},
+			}
+			provenance := NewProvenance("config.yaml")
+
+			MergeInclude(config, includedConfig, provenance, "included.yaml")
+			Expect(config.Files).To(Equal([]ConfiguredFile{{SourcePath: ".bashrc"}, {SourcePath: ".vimrc"}}))
+			Expect(config.PackageManagers[0].Packages).To(Equal([]ConfiguredPackage{
+				{Name: "package1", Version: "1.0.0"},
+				{Name: "package2", Version: "2.0.0"},
+			}))
+			Expect(provenance.FileSource(".vimrc")).To(Equal("included.yaml"))
+			Expect(provenance.PackageSource("scoop", "package1")).To(Equal("config.yaml"))
+			Expect(provenance.Duplicates()).To(HaveLen(2))
+			Expect(provenance.Sources()).To(Equal([]string{"config.yaml", "included.yaml"}))
+		})
+	})
+
+	Describe("reading included files", func() {
+		var configLocation string
+
+		BeforeEach(func() {
+			configLocation = useTemporaryConfigLocation()
+			writeConfigFile(configLocation, "version: 1\ninclude:\n  - packages.yaml\nfiles: []\nscripts: []\n"+
+				"packageManagers: []\n")
+		})
+
+		It("should read an included file without a version as the current version, without rewriting it", func() {
+			includedLocation := filepath.Join(filepath.Dir(configLocation), "packages.yaml")
+			includedContents := "packageManagers:\n  - name: scoop\n    packages:\n      - name: package1\n" +
+				"        version: 1.0.0\n"
+			writeConfigFile(includedLocation, includedContents)
+
+			config, _, err := newConfigService().GetEffectiveConfig()
+			Expect(err).To(BeNil())
+			Expect(config.PackageManagers).To(Equal([]ConfiguredPackageManager{
+				{Name: "scoop", Packages: []ConfiguredPackage{{Name: "package1", Version: "1.0.0"}}},
+			}))
+			Expect(os.ReadFile(includedLocation)).To(Equal([]byte(includedContents)))
+		})
+
+		It("should return an error for an included file that uses a newer version of the config format", func() {
+			writeConfigFile(filepath.Join(filepath.Dir(configLocation), "packages.yaml"), "version: 99\n")
+
+			_, _, err := newConfigService().GetEffectiveConfig()
+			Expect(err).To(MatchError(ContainSubstring("uses version 99 of the config format")))
+		})
+	})
+})
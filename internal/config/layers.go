@@ -0,0 +1,351 @@
+package config
+
+import (
+	"fmt"
+	"github.com/colececil/familiar.sh/internal/collections"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+)
+
+// configLayer is one of the shared config files merged into the effective configuration.
+type configLayer struct {
+	// name is the name of the context the layer comes from, or the config file location when it isn't a context.
+	name     string
+	location ConfigStoreLocation
+	// writable is whether the layer is the one that changes are written to. Exactly one layer is writable.
+	writable bool
+}
+
+// writableConfigLayer returns the layer that changes are written to.
+func writableConfigLayer(layers []configLayer) configLayer {
+	for _, layer := range layers {
+		if layer.writable {
+			return layer
+		}
+	}
+	return layers[len(layers)-1]
+}
+
+// SetConfigContextLayers creates or replaces the layered context of the given name, which merges the config files of
+// the given contexts. Later layers are applied on top of earlier ones, so they can add entries, replace them (for
+// example, to change the version of a package), or remove them with a "remove" section. Changes are written to the
+// last layer, unless another one is chosen with SetConfigContextWritableLayer.
+//
+// It takes the following parameters:
+//   - name: The name of the layered context.
+//   - layerNames: The names of the contexts to use as layers, in the order they are applied. They can't be layered
+//     contexts themselves, and "default" refers to the default location.
+func (configService *ConfigService) SetConfigContextLayers(name string, layerNames []string) error {
+	if err := validateConfigContextName(name); err != nil {
+		return err
+	}
+	if len(layerNames) == 0 {
+		return fmt.Errorf("a layered context needs at least one layer")
+	}
+
+	contexts, err := readConfigContexts()
+	if err != nil {
+		return err
+	}
+
+	configContext := contexts.find(name)
+	if configContext != nil && len(configContext.Layers) == 0 {
+		return fmt.Errorf("context %q already exists and isn't layered", name)
+	}
+
+	for i, layerName := range layerNames {
+		if collections.Contains(layerNames[:i], layerName) {
+			return fmt.Errorf("layer %q is given more than once", layerName)
+		}
+		if layerName == name {
+			return fmt.Errorf("context %q can't be a layer of itself", name)
+		}
+		if layerName == defaultConfigContextName {
+			continue
+		}
+
+		layerContext := contexts.find(layerName)
+		if layerContext == nil {
+			return fmt.Errorf("context %q doesn't exist", layerName)
+		}
+		if len(layerContext.Layers) > 0 {
+			return fmt.Errorf("context %q is layered, so it can't be used as a layer", layerName)
+		}
+	}
+
+	if configContext == nil {
+		contexts.Contexts = append(contexts.Contexts, ConfigContext{Name: name, Layers: layerNames})
+	} else {
+		configContext.Layers = layerNames
+		if !collections.Contains(layerNames, configContext.WritableLayer) {
+			configContext.WritableLayer = ""
+		}
+	}
+
+	return writeConfigContexts(contexts)
+}
+
+// SetConfigContextWritableLayer sets which layer of the layered context of the given name changes are written to, such
+// as packages added with "familiar package add".
+//
+// It takes the following parameters:
+//   - name: The name of the layered context.
+//   - layerName: The name of the layer to write changes to. It must be one of the context's layers.
+func (configService *ConfigService) SetConfigContextWritableLayer(name string, layerName string) error {
+	contexts, err := readConfigContexts()
+	if err != nil {
+		return err
+	}
+
+	configContext := contexts.find(name)
+	if configContext == nil {
+		return fmt.Errorf("context %q doesn't exist", name)
+	}
+	if len(configContext.Layers) == 0 {
+		return fmt.Errorf("context %q isn't layered", name)
+	}
+	if !collections.Contains(configContext.Layers, layerName) {
+		return fmt.Errorf("%q isn't a layer of context %q", layerName, name)
+	}
+
+	configContext.WritableLayer = layerName
+	return writeConfigContexts(contexts)
+}
+
+// configContextLayers returns the layers of the given context, in the order they are applied. A context that isn't
+// layered has a single, writable layer.
+func (configService *ConfigService) configContextLayers(contexts *ConfigContexts,
+	configContext *ConfigContext) ([]configLayer, error) {
+	if len(configContext.Layers) == 0 {
+		return []configLayer{{name: configContext.Name, location: configContext.location(), writable: true}}, nil
+	}
+
+	writableLayerName := configContext.WritableLayerName()
+	var layers []configLayer
+	for _, layerName := range configContext.Layers {
+		layer := configLayer{name: layerName, writable: layerName == writableLayerName}
+		if layerName == defaultConfigContextName {
+			location, err := configService.GetDefaultConfigStoreLocation()
+			if err != nil {
+				return nil, err
+			}
+			layer.location = location
+		} else {
+			layerContext := contexts.find(layerName)
+			if layerContext == nil {
+				return nil, fmt.Errorf("layer %q of context %q doesn't exist", layerName, configContext.Name)
+			}
+			layer.location = layerContext.location()
+		}
+		layers = append(layers, layer)
+	}
+
+	return layers, nil
+}
+
+// readConfigLayer returns the configuration of the given layer, with the files it includes merged in, along with a
+// Provenance recording which file each entry came from. The writable layer is read with GetSharedConfig, so it is
+// created or migrated if needed. Other layers are only read, and are migrated in memory.
+func (configService *ConfigService) readConfigLayer(layer configLayer) (*Config, *Provenance, error) {
+	var config *Config
+	var err error
+	if layer.writable {
+		config, err = configService.GetSharedConfig()
+	} else {
+		config, err = configService.readReadOnlyConfigLayer(layer)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	provenance := NewProvenance(layer.location.Path)
+
+	includePaths, err := ResolveIncludes(config, layer.location.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, includePath := range includePaths {
+		includedConfig, err := readIncludedConfig(includePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		MergeInclude(config, includedConfig, provenance, includePath)
+	}
+
+	return config, provenance, nil
+}
+
+// readConfigLayerFile returns the current contents of the config file of the given layer, read through the config
+// store that manages it. If the file doesn't exist, an error satisfying os.IsNotExist is returned.
+func (configService *ConfigService) readConfigLayerFile(layer configLayer) ([]byte, error) {
+	configStore, err := configService.configStoreRegistry.GetConfigStore(layer.location.Store)
+	if err != nil {
+		return nil, err
+	}
+	return configStore.Read(layer.location)
+}
+
+// readReadOnlyConfigLayer reads the config file of the given layer through the config store that manages it, without
+// writing anything back to it.
+func (configService *ConfigService) readReadOnlyConfigLayer(layer configLayer) (*Config, error) {
+	bytes, err := configService.readConfigLayerFile(layer)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("the config file of layer %q doesn't exist", layer.name)
+		}
+		return nil, fmt.Errorf("unable to read layer %q: %w", layer.name, err)
+	}
+
+	migratedBytes, _, err := MigrateConfig(bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read layer %q: %w", layer.name, err)
+	}
+
+	var config Config
+	if err = yaml.Unmarshal(migratedBytes, &config); err != nil {
+		return nil, fmt.Errorf("error reading layer %q: %w", layer.name, err)
+	}
+	return &config, nil
+}
+
+// MergeLayer applies the given layer Config on top of the given Config, which holds the earlier layers merged together,
+// recording where each changed entry came from in the given Provenance. The layer is applied in the following order:
+//   - The entries listed in the layer's "remove" section are removed. Entries that aren't present are ignored, so that
+//     a layer doesn't break when an earlier one stops defining them.
+//   - The layer's files and scripts are added. If one with the same source path is already present, it is replaced.
+//   - The layer's package managers and packages are added. If a package is already present under the same package
+//     manager, it is replaced, so its version can be changed.
+//
+// The layer's version and include entries are ignored, since its includes should already be merged into it.
+//
+// It takes the following parameters:
+//   - config: The Config to apply the layer to.
+//   - layerConfig: The Config of the layer, with its includes merged in.
+//   - provenance: The Provenance to record the changes in.
+//   - layerProvenance: The Provenance recording where the layer's entries came from.
+func MergeLayer(config *Config, layerConfig *Config, provenance *Provenance, layerProvenance *Provenance) {
+	for _, source := range layerProvenance.Sources() {
+		provenance.AddSource(source)
+	}
+
+	for _, removedPackage := range layerConfig.Remove.Packages {
+		_ = config.RemovePackage(removedPackage.PackageManager, removedPackage.Name)
+	}
+
+	keptFiles := []ConfiguredFile{}
+	for _, configuredFile := range config.Files {
+		if !collections.Contains(layerConfig.Remove.Files, configuredFile.SourcePath) {
+			keptFiles = append(keptFiles, configuredFile)
+		}
+	}
+	config.Files = keptFiles
+
+	keptScripts := []ConfiguredScript{}
+	for _, configuredScript := range config.Scripts {
+		if !collections.Contains(layerConfig.Remove.Scripts, configuredScript.SourcePath) {
+			keptScripts = append(keptScripts, configuredScript)
+		}
+	}
+	config.Scripts = keptScripts
+
+	for _, layerFile := range layerConfig.Files {
+		replaced := false
+		for i := range config.Files {
+			if config.Files[i].SourcePath == layerFile.SourcePath {
+				config.Files[i] = layerFile
+				replaced = true
+				break
+			}
+		}
+
+		if !replaced {
+			config.Files = append(config.Files, layerFile)
+		}
+		provenance.SetFileSource(layerFile.SourcePath, layerProvenance.FileSource(layerFile.SourcePath))
+	}
+
+	for _, layerScript := range layerConfig.Scripts {
+		replaced := false
+		for i := range config.Scripts {
+			if config.Scripts[i].SourcePath == layerScript.SourcePath {
+				config.Scripts[i] = layerScript
+				replaced = true
+				break
+			}
+		}
+
+		if !replaced {
+			config.Scripts = append(config.Scripts, layerScript)
+		}
+		provenance.SetScriptSource(layerScript.SourcePath, layerProvenance.ScriptSource(layerScript.SourcePath))
+	}
+
+	for _, layerPackageManager := range layerConfig.PackageManagers {
+		var matchingPackageManager *ConfiguredPackageManager
+		for i := range config.PackageManagers {
+			if config.PackageManagers[i].Name == layerPackageManager.Name {
+				matchingPackageManager = &config.PackageManagers[i]
+				break
+			}
+		}
+
+		if matchingPackageManager == nil {
+			config.PackageManagers = append(config.PackageManagers, ConfiguredPackageManager{
+				Name:     layerPackageManager.Name,
+				Packages: []ConfiguredPackage{},
+			})
+			matchingPackageManager = &config.PackageManagers[len(config.PackageManagers)-1]
+			provenance.SetPackageManagerSource(layerPackageManager.Name,
+				layerProvenance.PackageManagerSource(layerPackageManager.Name))
+		}
+
+		for _, layerPackage := range layerPackageManager.Packages {
+			replaced := false
+			for i := range matchingPackageManager.Packages {
+				if matchingPackageManager.Packages[i].Name == layerPackage.Name {
+					matchingPackageManager.Packages[i] = layerPackage
+					replaced = true
+					break
+				}
+			}
+
+			if !replaced {
+				matchingPackageManager.Packages = append(matchingPackageManager.Packages, layerPackage)
+			}
+			provenance.SetPackageSource(layerPackageManager.Name, layerPackage.Name,
+				layerProvenance.PackageSource(layerPackageManager.Name, layerPackage.Name))
+		}
+	}
+}
+
+// resolveLayerSourcePaths makes the source paths of the given Config's files and scripts absolute when they come from a
+// layer other than the writable one, since relative source paths are otherwise resolved against the directory of the
+// writable layer's config file.
+//
+// It takes the following parameters:
+//   - config: The Config holding the merged layers.
+//   - provenance: The Provenance of the merged layers, which is updated to use the new source paths.
+//   - fileDirectories: The directory of the layer that each file came from, keyed by source path.
+//   - scriptDirectories: The directory of the layer that each script came from, keyed by source path.
+//   - writableDirectory: The directory of the writable layer's config file.
+func resolveLayerSourcePaths(config *Config, provenance *Provenance, fileDirectories map[string]string,
+	scriptDirectories map[string]string, writableDirectory string) {
+	for i, configuredFile := range config.Files {
+		directory := fileDirectories[configuredFile.SourcePath]
+		if directory != writableDirectory && !filepath.IsAbs(configuredFile.SourcePath) {
+			config.Files[i].SourcePath = filepath.Join(directory, configuredFile.SourcePath)
+			provenance.SetFileSource(config.Files[i].SourcePath, provenance.FileSource(configuredFile.SourcePath))
+		}
+	}
+
+	for i, configuredScript := range config.Scripts {
+		directory := scriptDirectories[configuredScript.SourcePath]
+		if directory != writableDirectory && !filepath.IsAbs(configuredScript.SourcePath) {
+			config.Scripts[i].SourcePath = filepath.Join(directory, configuredScript.SourcePath)
+			provenance.SetScriptSource(config.Scripts[i].SourcePath,
+				provenance.ScriptSource(configuredScript.SourcePath))
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/colececil/familiar.sh/internal/config"
+	"github.com/colececil/familiar.sh/internal/packagemanagers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MergeLayer", func() {
+	It("should remove, add, and replace entries, recording where they came from", func() {
+		config := &Config{
+			Files:   []ConfiguredFile{{SourcePath: ".bashrc"}, {SourcePath: ".vimrc"}},
+			Scripts: []ConfiguredScript{{SourcePath: "setup.sh"}},
+			PackageManagers: []ConfiguredPackageManager{
+				{
+					Name: "scoop",
+					Packages: []ConfiguredPackage{
+						{Name: "package1", Version: "1.0.0"},
+						{Name: "package2", Version: "2.0.0"},
+					},
+				},
+			},
+		}
+		provenance := NewProvenance("team.yaml")
+
+		layerConfig := &Config{
+			Files: []ConfiguredFile{{SourcePath: ".vimrc", DestinationPath: "~/custom/.vimrc"}},
+			PackageManagers: []ConfiguredPackageManager{
+				{
+					Name: "scoop",
+					Packages: []ConfiguredPackage{
+						{Name: "package1", Version: "1.5.0"},
+						{Name: "package3", Version: "3.0.0"},
+					},
+				},
+				{Name: "winget", Packages: []ConfiguredPackage{{Name: "package4", Version: "4.0.0"}}},
+			},
+			Remove: ConfigRemovals{
+				Packages: []PackageReference{
+					{PackageManager: "scoop", Name: "package2"},
+					{PackageManager: "scoop", Name: "missing"},
+				},
+				Files:   []string{".bashrc"},
+				Scripts: []string{"setup.sh"},
+			},
+		}
+
+		MergeLayer(config, layerConfig, provenance, NewProvenance("personal.yaml"))
+
+		Expect(config.Files).To(Equal([]ConfiguredFile{{SourcePath: ".vimrc", DestinationPath: "~/custom/.vimrc"}}))
+		Expect(config.Scripts).To(BeEmpty())
+		Expect(config.PackageManagers).To(Equal([]ConfiguredPackageManager{
+			{
+				Name: "scoop",
+				Packages: []ConfiguredPackage{
+					{Name: "package1", Version: "1.5.0"},
+					{Name: "package3", Version: "3.0.0"},
+				},
+			},
+			{Name: "winget", Packages: []ConfiguredPackage{{Name: "package4", Version: "4.0.0"}}},
+		}))
+
+		Expect(provenance.Sources()).To(Equal([]string{"team.yaml", "personal.yaml"}))
+		Expect(provenance.PackageSource("scoop", "package1")).To(Equal("personal.yaml"))
+		Expect(provenance.PackageManagerSource("scoop")).To(Equal("team.yaml"))
+		Expect(provenance.PackageManagerSource("winget")).To(Equal("personal.yaml"))
+		Expect(provenance.FileSource(".vimrc")).To(Equal("personal.yaml"))
+	})
+})
+
+var _ = Describe("ConfigService layered contexts", func() {
+	var personalLocation string
+	var teamLocation string
+	var configService *ConfigService
+
+	BeforeEach(func() {
+		personalLocation = useTemporaryConfigLocation()
+		teamLocation = filepath.Join(GinkgoT().TempDir(), "team.yaml")
+		writeConfigFile(teamLocation, "version: 1\nfiles:\n  - sourcePath: .bashrc\nscripts:\n"+
+			"  - sourcePath: setup.sh\npackageManagers:\n  - name: scoop\n    packages:\n      - name: package1\n"+
+			"        version: 1.0.0\n      - name: package2\n        version: 2.0.0\n")
+		writeConfigFile(personalLocation, "version: 1\nfiles: []\nscripts: []\npackageManagers:\n  - name: scoop\n"+
+			"    packages:\n      - name: package1\n        version: 1.5.0\nremove:\n  scripts:\n    - setup.sh\n")
+
+		configService = newConfigService()
+		Expect(configService.AddConfigContext("team", "file", teamLocation, "")).To(Succeed())
+		Expect(configService.SetConfigContextLayers("work", []string{"team", "default"})).To(Succeed())
+		Expect(configService.UseConfigContext("work")).To(Succeed())
+	})
+
+	It("should merge the layers, with later layers taking precedence", func() {
+		config, provenance, err := configService.GetEffectiveConfig()
+		Expect(err).To(BeNil())
+
+		teamBashrc := filepath.Join(filepath.Dir(teamLocation), ".bashrc")
+		Expect(config.Files).To(Equal([]ConfiguredFile{{SourcePath: teamBashrc}}))
+		Expect(config.Scripts).To(BeEmpty())
+		Expect(config.PackageManagers[0].Packages).To(Equal([]ConfiguredPackage{
+			{Name: "package1", Version: "1.5.0"},
+			{Name: "package2", Version: "2.0.0"},
+		}))
+		Expect(config.Remove).To(Equal(ConfigRemovals{}))
+
+		Expect(provenance.PackageSource("scoop", "package1")).To(Equal(personalLocation))
+		Expect(provenance.PackageSource("scoop", "package2")).To(Equal(teamLocation))
+		Expect(provenance.FileSource(teamBashrc)).To(Equal(teamLocation))
+	})
+
+	It("should write changes to the writable layer only", func() {
+		location, err := configService.GetConfigLocation()
+		Expect(err).To(BeNil())
+		Expect(location).To(Equal(personalLocation))
+
+		source, err := configService.GetConfigLocationSource()
+		Expect(err).To(BeNil())
+		Expect(source).To(Equal(`writable layer "default" of context "work"`))
+
+		teamContents, err := os.ReadFile(teamLocation)
+		Expect(err).To(BeNil())
+
+		config, err := configService.GetSharedConfig()
+		Expect(err).To(BeNil())
+		Expect(config.AddPackage("scoop", "package3",
+			packagemanagers.NewVersion("3.0.0"))).To(Succeed())
+		Expect(configService.SetConfig(config)).To(Succeed())
+
+		Expect(os.ReadFile(personalLocation)).To(ContainSubstring("package3"))
+		Expect(os.ReadFile(teamLocation)).To(Equal(teamContents))
+
+		Expect(configService.SetConfigContextWritableLayer("work", "team")).To(Succeed())
+		Expect(configService.GetConfigLocation()).To(Equal(teamLocation))
+	})
+
+	It("should return an error for invalid layers", func() {
+		Expect(configService.SetConfigContextLayers("team", []string{"default"})).ToNot(Succeed())
+		Expect(configService.SetConfigContextLayers("other", []string{"work"})).ToNot(Succeed())
+		Expect(configService.SetConfigContextLayers("other", []string{"team", "team"})).ToNot(Succeed())
+		Expect(configService.SetConfigContextLayers("other", []string{"personal"})).ToNot(Succeed())
+		Expect(configService.SetConfigContextWritableLayer("work", "personal")).ToNot(Succeed())
+		Expect(configService.SetConfigContextWritableLayer("team", "default")).ToNot(Succeed())
+		Expect(configService.RemoveConfigContext("team")).ToNot(Succeed())
+		Expect(configService.SetConfigLocation(personalLocation)).ToNot(Succeed())
+	})
+})
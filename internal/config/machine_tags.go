@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"github.com/colececil/familiar.sh/internal/collections"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const machineTagsFileName = "machine_tags"
+
+var validTagRegex = regexp.MustCompile("^[a-zA-Z0-9_-]+$")
+
+// GetMachineTags returns the tags of the current machine, as stored in the "machine_tags" file in the XDG config
+// directory. The tags are local to the machine, so they are not part of the shared config file. If the "machine_tags"
+// file does not exist, an empty slice is returned.
+func (configService *ConfigService) GetMachineTags() ([]string, error) {
+	bytes, err := os.ReadFile(machineTagsFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	tags := []string{}
+	for _, line := range strings.Split(string(bytes), "\n") {
+		if tag := strings.TrimSpace(line); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags, nil
+}
+
+// AddMachineTag adds the given tag to the current machine.
+//
+// It throws an error under the following conditions:
+//   - The given tag contains characters other than letters, numbers, hyphens, and underscores.
+//   - The current machine already has the given tag.
+//
+// It takes the following parameters:
+//   - tag: The tag to add.
+func (configService *ConfigService) AddMachineTag(tag string) error {
+	if !validTagRegex.MatchString(tag) {
+		return fmt.Errorf("invalid tag %q: tags may only contain letters, numbers, hyphens, and underscores", tag)
+	}
+
+	tags, err := configService.GetMachineTags()
+	if err != nil {
+		return err
+	}
+
+	if collections.Contains(tags, tag) {
+		return fmt.Errorf("tag already present")
+	}
+
+	return configService.setMachineTags(append(tags, tag))
+}
+
+// RemoveMachineTag removes the given tag from the current machine. If the current machine doesn't have the given tag,
+// it throws an error.
+//
+// It takes the following parameters:
+//   - tag: The tag to remove.
+func (configService *ConfigService) RemoveMachineTag(tag string) error {
+	tags, err := configService.GetMachineTags()
+	if err != nil {
+		return err
+	}
+
+	var filteredTags []string
+	for _, existingTag := range tags {
+		if existingTag != tag {
+			filteredTags = append(filteredTags, existingTag)
+		}
+	}
+
+	if len(filteredTags) == len(tags) {
+		return fmt.Errorf("tag not present")
+	}
+
+	return configService.setMachineTags(filteredTags)
+}
+
+// setMachineTags writes the given tags to the "machine_tags" file in the XDG config directory, sorted and one per line.
+//
+// It takes the following parameters:
+//   - tags: The tags to write.
+func (configService *ConfigService) setMachineTags(tags []string) error {
+	if err := os.MkdirAll(appDirectoryPath(), 0700); err != nil {
+		return err
+	}
+
+	sort.Strings(tags)
+	contents := ""
+	for _, tag := range tags {
+		contents += tag + "\n"
+	}
+
+	return os.WriteFile(machineTagsFilePath(), []byte(contents), 0600)
+}
+
+// machineTagsFilePath returns the path of the "machine_tags" file in the XDG config directory.
+func machineTagsFilePath() string {
+	return appDirectoryFilePath(machineTagsFileName)
+}
@@ -0,0 +1,67 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	. "github.com/colececil/familiar.sh/internal/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConfigService machine tags", func() {
+	var configService *ConfigService
+
+	BeforeEach(func() {
+		useTemporaryConfigLocation()
+		configService = newConfigService()
+	})
+
+	It("should return no tags when none have been added", func() {
+		Expect(configService.GetMachineTags()).To(BeEmpty())
+	})
+
+	It("should list the added tags in sorted order", func() {
+		Expect(configService.AddMachineTag("work")).To(Succeed())
+		Expect(configService.AddMachineTag("laptop")).To(Succeed())
+
+		Expect(configService.GetMachineTags()).To(Equal([]string{"laptop", "work"}))
+	})
+
+	It("should not add a tag the machine already has", func() {
+		Expect(configService.AddMachineTag("work")).To(Succeed())
+
+		Expect(configService.AddMachineTag("work")).To(MatchError("tag already present"))
+		Expect(configService.GetMachineTags()).To(Equal([]string{"work"}))
+	})
+
+	It("should return an error for a tag with invalid characters", func() {
+		Expect(configService.AddMachineTag("my tag")).To(MatchError(ContainSubstring("invalid tag")))
+		Expect(configService.GetMachineTags()).To(BeEmpty())
+	})
+
+	It("should remove a tag", func() {
+		Expect(configService.AddMachineTag("work")).To(Succeed())
+		Expect(configService.AddMachineTag("laptop")).To(Succeed())
+
+		Expect(configService.RemoveMachineTag("work")).To(Succeed())
+		Expect(configService.GetMachineTags()).To(Equal([]string{"laptop"}))
+	})
+
+	It("should return an error when removing a tag the machine doesn't have", func() {
+		Expect(configService.AddMachineTag("work")).To(Succeed())
+
+		Expect(configService.RemoveMachineTag("laptop")).To(MatchError("tag not present"))
+		Expect(configService.GetMachineTags()).To(Equal([]string{"work"}))
+	})
+
+	It("should keep the tags in the XDG config directory, rather than in the shared config file", func() {
+		Expect(configService.AddMachineTag("work")).To(Succeed())
+		Expect(configService.AddMachineTag("laptop")).To(Succeed())
+
+		Expect(newConfigService().GetMachineTags()).To(Equal([]string{"laptop", "work"}))
+		Expect(os.ReadFile(filepath.Join(xdg.ConfigHome, "io.colececil.familiar", "machine_tags"))).To(
+			Equal([]byte("laptop\nwork\n")))
+	})
+})
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"strconv"
+)
+
+// CurrentConfigVersion is the version of the config file format written by this version of Familiar.sh. It must be
+// incremented whenever the format changes in a way that requires existing config files to be migrated, and a migration
+// from the previous version must be added to configMigrations.
+const CurrentConfigVersion = 1
+
+// unversionedConfigVersion is the version a config file without a version field is treated as. Every config file
+// written by Familiar.sh has a version, but hand-written files (especially included files) often leave it out. Since
+// version 1 is the first version of the format, they are treated as using it.
+const unversionedConfigVersion = 1
+
+// configMigration upgrades a config document by one version, editing the given document node in place. It doesn't need
+// to update the version field, since that is done after each migration is run.
+type configMigration func(document *yaml.Node) error
+
+// configMigrations maps each old version of the config file format to the migration that upgrades it to the next
+// version. It is empty while version 1 is the only version of the format.
+var configMigrations = map[int]configMigration{}
+
+// MigrateConfig checks the version of the given config file contents, and if it is older than CurrentConfigVersion,
+// runs each migration needed to bring it up to date. A config file without a version is treated as version 1.
+//
+// It throws an error under the following conditions:
+//   - The contents are not valid YAML, or the version is not a number.
+//   - The version is newer than CurrentConfigVersion, meaning the config file was written by a newer version of
+//     Familiar.sh.
+//   - A migration fails.
+//
+// It takes the following parameters:
+//   - contents: The contents of the config file.
+//
+// It returns the migrated contents and the version the contents were migrated from. If no migration was needed, the
+// original contents are returned unchanged.
+func MigrateConfig(contents []byte) ([]byte, int, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return nil, 0, err
+	}
+
+	if len(document.Content) == 0 {
+		document.Kind = yaml.DocumentNode
+		document.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, 0, fmt.Errorf("the config file must contain a mapping at the top level")
+	}
+
+	version := unversionedConfigVersion
+	if versionNode := mappingValue(root, "version"); versionNode != nil {
+		parsedVersion, err := strconv.Atoi(versionNode.Value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid config file version %q", versionNode.Value)
+		}
+		version = parsedVersion
+	}
+
+	if err := checkConfigVersion(version); err != nil {
+		return nil, version, err
+	}
+
+	if version == CurrentConfigVersion {
+		return contents, version, nil
+	}
+
+	for currentVersion := version; currentVersion < CurrentConfigVersion; currentVersion++ {
+		migration, isPresent := configMigrations[currentVersion]
+		if !isPresent {
+			return nil, version, fmt.Errorf("no migration is available from config version %d", currentVersion)
+		}
+
+		if err := migration(&document); err != nil {
+			return nil, version, fmt.Errorf("error migrating config from version %d: %w", currentVersion, err)
+		}
+		setMappingValue(root, "version", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int",
+			Value: strconv.Itoa(currentVersion + 1)})
+	}
+
+	migratedContents, err := encodeYamlNode(&document, detectYamlIndent(contents))
+	if err != nil {
+		return nil, version, err
+	}
+
+	return migratedContents, version, nil
+}
+
+// checkConfigVersion returns an error if the given version of the config format is newer than CurrentConfigVersion,
+// meaning the file using it was written by a newer version of Familiar.sh.
+func checkConfigVersion(version int) error {
+	if version > CurrentConfigVersion {
+		return fmt.Errorf("the config file uses version %d of the config format, but this version of Familiar.sh "+
+			"only supports up to version %d. Please upgrade Familiar.sh to use this config file", version,
+			CurrentConfigVersion)
+	}
+	return nil
+}
+
+// setMappingValue sets the value for the given key in the given mapping node. If the key isn't present, the "version"
+// key is placed first and any other key is placed last.
+//
+// It takes the following parameters:
+//   - mapping: The mapping node to change.
+//   - key: The key to set.
+//   - value: The new value node.
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	if key == "version" {
+		mapping.Content = append([]*yaml.Node{keyNode, value}, mapping.Content...)
+	} else {
+		mapping.Content = append(mapping.Content, keyNode, value)
+	}
+}
@@ -0,0 +1,47 @@
+package config_test
+
+import (
+	. "github.com/colececil/familiar.sh/internal/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MigrateConfig", func() {
+	It("should return the contents unchanged when they are already at the current version", func() {
+		contents := []byte("version: 1\nfiles: []\nscripts: []\npackageManagers: []\n")
+
+		migratedContents, originalVersion, err := MigrateConfig(contents)
+		Expect(err).To(BeNil())
+		Expect(originalVersion).To(Equal(CurrentConfigVersion))
+		Expect(migratedContents).To(Equal(contents))
+	})
+
+	It("should treat a config without a version as version 1, returning it unchanged", func() {
+		contents := []byte("packageManagers:\n  - name: scoop\n    packages:\n      - name: package1\n" +
+			"        version: 1.0.0\n")
+
+		migratedContents, originalVersion, err := MigrateConfig(contents)
+		Expect(err).To(BeNil())
+		Expect(originalVersion).To(Equal(1))
+		Expect(migratedContents).To(Equal(contents))
+	})
+
+	It("should return an error when there is no migration from the config's version", func() {
+		_, _, err := MigrateConfig([]byte("version: 0\n"))
+		Expect(err).To(MatchError(ContainSubstring("no migration is available from config version 0")))
+	})
+
+	It("should return an error when the config is newer than the current version", func() {
+		contents := []byte("version: 999\n")
+
+		_, originalVersion, err := MigrateConfig(contents)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("upgrade Familiar.sh"))
+		Expect(originalVersion).To(Equal(999))
+	})
+
+	It("should return an error when the version is not a number", func() {
+		_, _, err := MigrateConfig([]byte("version: one\n"))
+		Expect(err).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+)
+
+const configOverridesFileName = "config_overrides.yaml"
+
+// ConfigOverrides represents the contents of the local overrides file, which adjusts the shared config for the current
+// machine only. It is stored in the XDG config directory, so it is never synced to other machines.
+type ConfigOverrides struct {
+	// Version is the version of the config format the overrides file uses. It is optional, since the overrides file
+	// hasn't changed format yet, but an overrides file written by a newer version of Familiar.sh is rejected.
+	Version          int                        `yaml:"version,omitempty"`
+	PackageManagers  []ConfiguredPackageManager `yaml:"packageManagers,omitempty"`
+	ExcludedPackages []PackageReference         `yaml:"excludedPackages,omitempty"`
+	Files            []FileOverride             `yaml:"files,omitempty"`
+	DisabledScripts  []string                   `yaml:"disabledScripts,omitempty"`
+}
+
+// PackageReference identifies a package installed by a specific package manager.
+type PackageReference struct {
+	PackageManager string `yaml:"packageManager"`
+	Name           string `yaml:"name"`
+}
+
+// FileOverride changes the destination of a file from the shared config on the current machine.
+type FileOverride struct {
+	SourcePath      string `yaml:"sourcePath"`
+	DestinationPath string `yaml:"destinationPath"`
+}
+
+// Apply applies the overrides to the given Config, recording the changes it makes in the given Provenance. The
+// overrides are applied in the following order:
+//   - Packages listed under the overrides' package managers are added to the Config. If a package is already present,
+//     it is replaced, so its version can be changed.
+//   - Excluded packages are removed from the Config.
+//   - Files have their destination paths changed.
+//   - Disabled scripts are removed from the Config.
+//
+// It throws an error if a file or script in the overrides is not in the Config, since that most likely means the
+// overrides file is out of date.
+//
+// It takes the following parameters:
+//   - config: The Config to apply the overrides to.
+//   - provenance: The Provenance to record the changes in.
+//   - source: A description of where the overrides came from, used in the Provenance.
+func (overrides *ConfigOverrides) Apply(config *Config, provenance *Provenance, source string) error {
+	for _, overridePackageManager := range overrides.PackageManagers {
+		var matchingPackageManager *ConfiguredPackageManager
+		for i := range config.PackageManagers {
+			if config.PackageManagers[i].Name == overridePackageManager.Name {
+				matchingPackageManager = &config.PackageManagers[i]
+				break
+			}
+		}
+
+		if matchingPackageManager == nil {
+			config.PackageManagers = append(config.PackageManagers, ConfiguredPackageManager{
+				Name:     overridePackageManager.Name,
+				Packages: []ConfiguredPackage{},
+			})
+			matchingPackageManager = &config.PackageManagers[len(config.PackageManagers)-1]
+			provenance.SetPackageManagerSource(overridePackageManager.Name, source)
+		}
+
+		for _, overridePackage := range overridePackageManager.Packages {
+			replaced := false
+			for i := range matchingPackageManager.Packages {
+				if matchingPackageManager.Packages[i].Name == overridePackage.Name {
+					matchingPackageManager.Packages[i] = overridePackage
+					replaced = true
+					break
+				}
+			}
+
+			if !replaced {
+				matchingPackageManager.Packages = append(matchingPackageManager.Packages, overridePackage)
+			}
+			provenance.SetPackageSource(overridePackageManager.Name, overridePackage.Name, source)
+		}
+	}
+
+	for _, excludedPackage := range overrides.ExcludedPackages {
+		// Excluding a package that isn't in the shared config is harmless, so the error is ignored.
+		_ = config.RemovePackage(excludedPackage.PackageManager, excludedPackage.Name)
+	}
+
+	for _, fileOverride := range overrides.Files {
+		found := false
+		for i := range config.Files {
+			if config.Files[i].SourcePath == fileOverride.SourcePath {
+				config.Files[i].DestinationPath = fileOverride.DestinationPath
+				config.Files[i].OperatingSystems = nil
+				provenance.SetFileSource(fileOverride.SourcePath, source)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("file %q in %s is not in the shared config", fileOverride.SourcePath, source)
+		}
+	}
+
+	for _, disabledScript := range overrides.DisabledScripts {
+		var filteredScripts []ConfiguredScript
+		for _, configuredScript := range config.Scripts {
+			if configuredScript.SourcePath != disabledScript {
+				filteredScripts = append(filteredScripts, configuredScript)
+			}
+		}
+
+		if len(filteredScripts) == len(config.Scripts) {
+			return fmt.Errorf("script %q in %s is not in the shared config", disabledScript, source)
+		}
+		config.Scripts = filteredScripts
+	}
+
+	return nil
+}
+
+// GetConfigOverrides returns the contents of the local overrides file in the XDG config directory. If the file does not
+// exist, nil is returned.
+func (configService *ConfigService) GetConfigOverrides() (*ConfigOverrides, error) {
+	bytes, err := os.ReadFile(ConfigOverridesLocation())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var overrides ConfigOverrides
+	if err = yaml.Unmarshal(bytes, &overrides); err != nil {
+		return nil, fmt.Errorf("error reading local overrides file: %w", err)
+	}
+
+	if err = checkConfigVersion(overrides.Version); err != nil {
+		return nil, fmt.Errorf("unable to read local overrides file: %w", err)
+	}
+
+	return &overrides, nil
+}
+
+// ConfigOverridesLocation returns the location of the local overrides file in the XDG config directory.
+func ConfigOverridesLocation() string {
+	return appDirectoryFilePath(configOverridesFileName)
+}
@@ -0,0 +1,111 @@
+package config_test
+
+import (
+	. "github.com/colececil/familiar.sh/internal/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConfigOverrides", func() {
+	var config *Config
+	var provenance *Provenance
+
+	BeforeEach(func() {
+		config = &Config{
+			Version: 1,
+			Files: []ConfiguredFile{
+				{SourcePath: ".bashrc", DestinationPath: "~/.bashrc"},
+			},
+			Scripts: []ConfiguredScript{
+				{SourcePath: "setup.sh"},
+			},
+			PackageManagers: []ConfiguredPackageManager{
+				{
+					Name: "scoop",
+					Packages: []ConfiguredPackage{
+						{Name: "package1", Version: "1.0.0"},
+						{Name: "package2", Version: "2.0.0"},
+					},
+				},
+			},
+		}
+		provenance = NewProvenance("shared.yaml")
+	})
+
+	Describe("Apply", func() {
+		It("should add and replace packages, exclude packages, change file destinations, and disable scripts",
+			func() {
+				overrides := &ConfigOverrides{
+					PackageManagers: []ConfiguredPackageManager{
+						{
+							Name: "scoop",
+							Packages: []ConfiguredPackage{
+								{Name: "package1", Version: "0.9.0"},
+								{Name: "package3", Version: "3.0.0"},
+							},
+						},
+					},
+					ExcludedPackages: []PackageReference{{PackageManager: "scoop", Name: "package2"}},
+					Files:            []FileOverride{{SourcePath: ".bashrc", DestinationPath: "~/custom/.bashrc"}},
+					DisabledScripts:  []string{"setup.sh"},
+				}
+
+				err := overrides.Apply(config, provenance, "overrides.yaml")
+				Expect(err).To(BeNil())
+				Expect(config.PackageManagers[0].Packages).To(Equal([]ConfiguredPackage{
+					{Name: "package1", Version: "0.9.0"},
+					{Name: "package3", Version: "3.0.0"},
+				}))
+				Expect(config.Files[0].DestinationPath).To(Equal("~/custom/.bashrc"))
+				Expect(config.Scripts).To(BeEmpty())
+			})
+
+		It("should return an error when a file in the overrides is not in the config", func() {
+			overrides := &ConfigOverrides{
+				Files: []FileOverride{{SourcePath: ".vimrc", DestinationPath: "~/.vimrc"}},
+			}
+
+			err := overrides.Apply(config, provenance, "overrides.yaml")
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("should record which entries came from the overrides", func() {
+			overrides := &ConfigOverrides{
+				PackageManagers: []ConfiguredPackageManager{
+					{Name: "scoop", Packages: []ConfiguredPackage{{Name: "package3", Version: "3.0.0"}}},
+				},
+			}
+
+			err := overrides.Apply(config, provenance, "overrides.yaml")
+			Expect(err).To(BeNil())
+			Expect(provenance.PackageSource("scoop", "package1")).To(Equal("shared.yaml"))
+			Expect(provenance.PackageSource("scoop", "package3")).To(Equal("overrides.yaml"))
+
+			annotatedYaml, err := provenance.AnnotatedYamlString(config)
+			Expect(err).To(BeNil())
+			Expect(annotatedYaml).To(ContainSubstring("name: package1 # from shared.yaml"))
+			Expect(annotatedYaml).To(ContainSubstring("name: package3 # from overrides.yaml"))
+		})
+	})
+
+	Describe("GetConfigOverrides", func() {
+		BeforeEach(func() {
+			useTemporaryConfigLocation()
+		})
+
+		It("should return an error for an overrides file written by a newer version of Familiar.sh", func() {
+			writeConfigFile(ConfigOverridesLocation(), "version: 99\nexcludedPackages: []\n")
+
+			_, err := newConfigService().GetConfigOverrides()
+			Expect(err).To(MatchError(ContainSubstring("uses version 99 of the config format")))
+		})
+
+		It("should accept an overrides file without a version", func() {
+			writeConfigFile(ConfigOverridesLocation(), "disabledScripts:\n  - setup.sh\n")
+
+			overrides, err := newConfigService().GetConfigOverrides()
+			Expect(err).To(BeNil())
+			Expect(overrides.DisabledScripts).To(Equal([]string{"setup.sh"}))
+		})
+	})
+})
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+// Provenance records where the entries of an effective Config came from, when it is assembled from more than one
+// source. Entries that haven't been given a source are assumed to come from the default source.
+type Provenance struct {
+	defaultSource         string
+	sources               []string
+	packageManagerSources map[string]string
+	packageSources        map[PackageReference]string
+	fileSources           map[string]string
+	scriptSources         map[string]string
+	duplicates            []DuplicateEntry
+}
+
+// DuplicateEntry describes an entry that was defined in more than one source. Only the first definition is used.
+type DuplicateEntry struct {
+	// Description describes the entry, for example `package "git" under package manager "scoop"`.
+	Description string
+
+	// Source is the source containing the definition that was ignored.
+	Source string
+
+	// KeptSource is the source containing the definition that was used.
+	KeptSource string
+}
+
+// String returns a description of the duplicate entry that can be displayed to the user.
+func (duplicateEntry DuplicateEntry) String() string {
+	return fmt.Sprintf("%s in %s is ignored, because it is already defined in %s", duplicateEntry.Description,
+		duplicateEntry.Source, duplicateEntry.KeptSource)
+}
+
+// NewProvenance creates a new instance of Provenance.
+//
+// It takes the following parameters:
+//   - defaultSource: A description of the source that entries come from unless recorded otherwise.
+func NewProvenance(defaultSource string) *Provenance {
+	return &Provenance{
+		defaultSource:         defaultSource,
+		sources:               []string{defaultSource},
+		packageManagerSources: make(map[string]string),
+		packageSources:        make(map[PackageReference]string),
+		fileSources:           make(map[string]string),
+		scriptSources:         make(map[string]string),
+	}
+}
+
+// AddSource records that the given source contributed to the effective Config.
+func (provenance *Provenance) AddSource(source string) {
+	provenance.sources = append(provenance.sources, source)
+}
+
+// Sources returns all sources that contributed to the effective Config, in the order they were applied.
+func (provenance *Provenance) Sources() []string {
+	return provenance.sources
+}
+
+// AddDuplicate records that an entry was defined in more than one source.
+func (provenance *Provenance) AddDuplicate(duplicateEntry DuplicateEntry) {
+	provenance.duplicates = append(provenance.duplicates, duplicateEntry)
+}
+
+// Duplicates returns all entries that were defined in more than one source.
+func (provenance *Provenance) Duplicates() []DuplicateEntry {
+	return provenance.duplicates
+}
+
+// SetPackageManagerSource records the source of the given package manager.
+func (provenance *Provenance) SetPackageManagerSource(packageManagerName string, source string) {
+	provenance.packageManagerSources[packageManagerName] = source
+}
+
+// SetPackageSource records the source of the given package under the given package manager.
+func (provenance *Provenance) SetPackageSource(packageManagerName string, packageName string, source string) {
+	provenance.packageSources[PackageReference{PackageManager: packageManagerName, Name: packageName}] = source
+}
+
+// SetFileSource records the source of the file with the given source path.
+func (provenance *Provenance) SetFileSource(sourcePath string, source string) {
+	provenance.fileSources[sourcePath] = source
+}
+
+// SetScriptSource records the source of the script with the given source path.
+func (provenance *Provenance) SetScriptSource(sourcePath string, source string) {
+	provenance.scriptSources[sourcePath] = source
+}
+
+// PackageManagerSource returns the source of the given package manager.
+func (provenance *Provenance) PackageManagerSource(packageManagerName string) string {
+	return provenance.sourceOrDefault(provenance.packageManagerSources[packageManagerName])
+}
+
+// PackageSource returns the source of the given package under the given package manager.
+func (provenance *Provenance) PackageSource(packageManagerName string, packageName string) string {
+	reference := PackageReference{PackageManager: packageManagerName, Name: packageName}
+	return provenance.sourceOrDefault(provenance.packageSources[reference])
+}
+
+// FileSource returns the source of the file with the given source path.
+func (provenance *Provenance) FileSource(sourcePath string) string {
+	return provenance.sourceOrDefault(provenance.fileSources[sourcePath])
+}
+
+// ScriptSource returns the source of the script with the given source path.
+func (provenance *Provenance) ScriptSource(sourcePath string) string {
+	return provenance.sourceOrDefault(provenance.scriptSources[sourcePath])
+}
+
+// sourceOrDefault returns the given source, or the default source if the given source is empty.
+func (provenance *Provenance) sourceOrDefault(source string) string {
+	if source == "" {
+		return provenance.defaultSource
+	}
+	return source
+}
+
+// AnnotatedYamlString returns the given Config as a YAML string, with a comment on each package manager, package,
+// file, and script saying where it came from.
+//
+// It takes the following parameters:
+//   - config: The Config to convert to YAML. It should be the effective Config the Provenance was recorded for.
+func (provenance *Provenance) AnnotatedYamlString(config *Config) (string, error) {
+	var document yaml.Node
+	if err := document.Encode(config); err != nil {
+		return "", err
+	}
+
+	annotateSequence(mappingValue(&document, "files"), "sourcePath", provenance.FileSource)
+	annotateSequence(mappingValue(&document, "scripts"), "sourcePath", provenance.ScriptSource)
+
+	packageManagers := mappingValue(&document, "packageManagers")
+	annotateSequence(packageManagers, "name", provenance.PackageManagerSource)
+	if packageManagers != nil {
+		for _, packageManager := range packageManagers.Content {
+			packageManagerName := mappingValue(packageManager, "name")
+			if packageManagerName == nil {
+				continue
+			}
+
+			annotateSequence(mappingValue(packageManager, "packages"), "name", func(packageName string) string {
+				return provenance.PackageSource(packageManagerName.Value, packageName)
+			})
+		}
+	}
+
+	bytes, err := yaml.Marshal(&document)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(bytes)), nil
+}
+
+// annotateSequence adds a line comment with the source of each mapping in the given sequence node. The comment is
+// placed on the value of the given identifying key.
+//
+// It takes the following parameters:
+//   - sequence: The sequence node to annotate. If nil, nothing is done.
+//   - key: The key that identifies each mapping in the sequence.
+//   - sourceFunc: A function that returns the source for the given identifying value.
+func annotateSequence(sequence *yaml.Node, key string, sourceFunc func(string) string) {
+	if sequence == nil {
+		return
+	}
+
+	for _, item := range sequence.Content {
+		if identifier := mappingValue(item, key); identifier != nil {
+			identifier.LineComment = "from " + sourceFunc(identifier.Value)
+		}
+	}
+}
+
+// mappingValue returns the value node for the given key in the given mapping node, or nil if the key isn't present. If
+// the given node is a document node, its root mapping is used.
+//
+// It takes the following parameters:
+//   - node: The mapping or document node to look in.
+//   - key: The key to look up.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node != nil && node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
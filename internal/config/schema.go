@@ -0,0 +1,124 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/colececil/familiar.sh/internal/collections"
+	"github.com/colececil/familiar.sh/internal/system"
+	"reflect"
+	"strings"
+)
+
+// jsonSchemaDraft is the JSON Schema dialect used by the generated schema.
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// GenerateJsonSchema returns a JSON Schema describing the format of the config file, generated from the Config type
+// and the types it contains.
+//
+// It takes the following parameters:
+//   - packageManagerNames: The names of the supported package managers, which are used as the allowed values of a
+//     package manager's name.
+func GenerateJsonSchema(packageManagerNames []string) ([]byte, error) {
+	generator := schemaGenerator{
+		definitions: map[string]any{},
+		enums: map[string][]string{
+			"ConfiguredPackageManager.name":  packageManagerNames,
+			"ConfiguredOperatingSystem.name": system.OperatingSystemNames,
+		},
+	}
+
+	schema, err := generator.objectSchema(reflect.TypeOf(Config{}))
+	if err != nil {
+		return nil, fmt.Errorf("error generating config schema: %w", err)
+	}
+	schema["$schema"] = jsonSchemaDraft
+	schema["title"] = "Familiar.sh config"
+	schema["$defs"] = generator.definitions
+
+	schemaJson, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error generating config schema: %w", err)
+	}
+	return append(schemaJson, '\n'), nil
+}
+
+// schemaGenerator builds a JSON Schema from Go types, using their yaml struct tags for the property names.
+type schemaGenerator struct {
+	// definitions contains the schemas of the struct types referenced from other types, keyed by type name.
+	definitions map[string]any
+	// enums contains the allowed values of specific struct fields, keyed by "<type name>.<property name>".
+	enums map[string][]string
+}
+
+// typeSchema returns the schema for a value of the given type. Struct types are added to the definitions and
+// referenced from the returned schema. It returns an error if the type, or a type it contains, has a kind that the
+// schema can't describe yet.
+func (generator *schemaGenerator) typeSchema(valueType reflect.Type) (map[string]any, error) {
+	switch valueType.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Slice:
+		itemSchema, err := generator.typeSchema(valueType.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"type":  "array",
+			"items": itemSchema,
+		}, nil
+	case reflect.Struct:
+		if _, isPresent := generator.definitions[valueType.Name()]; !isPresent {
+			definition, err := generator.objectSchema(valueType)
+			if err != nil {
+				return nil, err
+			}
+			generator.definitions[valueType.Name()] = definition
+		}
+		return map[string]any{"$ref": "#/$defs/" + valueType.Name()}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", valueType)
+	}
+}
+
+// objectSchema returns the schema for the properties of the given struct type. Fields without "omitempty" in their
+// yaml tag are required, since they are always written to the config file.
+func (generator *schemaGenerator) objectSchema(structType reflect.Type) (map[string]any, error) {
+	properties := map[string]any{}
+	required := []string{}
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tagParts := strings.Split(field.Tag.Get("yaml"), ",")
+		propertyName := tagParts[0]
+		if propertyName == "-" || !field.IsExported() {
+			continue
+		}
+		if propertyName == "" {
+			propertyName = strings.ToLower(field.Name)
+		}
+
+		propertySchema, err := generator.typeSchema(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s.%s: %w", structType.Name(), field.Name, err)
+		}
+		if enum, isPresent := generator.enums[structType.Name()+"."+propertyName]; isPresent {
+			propertySchema["enum"] = enum
+		}
+		properties[propertyName] = propertySchema
+
+		if !collections.Contains(tagParts[1:], "omitempty") {
+			required = append(required, propertyName)
+		}
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}, nil
+}
@@ -0,0 +1,46 @@
+package config_test
+
+import (
+	"os"
+
+	. "github.com/colececil/familiar.sh/internal/config"
+	"github.com/colececil/familiar.sh/internal/packagemanagers"
+	"github.com/colececil/familiar.sh/internal/test"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// schemaFileLocation is the location of the published config schema, relative to this package.
+const schemaFileLocation = "../../schema/config.schema.json"
+
+var _ = Describe("GenerateJsonSchema", func() {
+	It("should match the published schema file", func() {
+		scoopPackageManager := packagemanagers.NewScoopPackageManager(
+			test.NewOperatingSystemServiceDouble().OperatingSystemService,
+			test.NewShellCommandServiceDouble().ShellCommandService, test.NewMessageWriterFuncDouble())
+		packageManagerRegistry := packagemanagers.NewPackageManagerRegistry(scoopPackageManager)
+
+		schema, err := GenerateJsonSchema(packageManagerRegistry.GetPackageManagerNames())
+		Expect(err).To(BeNil())
+
+		publishedSchema, err := os.ReadFile(schemaFileLocation)
+		Expect(err).To(BeNil())
+		Expect(string(schema)).To(Equal(string(publishedSchema)),
+			"the config schema is out of date; regenerate it with \"familiar config schema > "+
+				"schema/config.schema.json\"")
+	})
+
+	It("should list the allowed package manager and operating system names", func() {
+		schema, err := GenerateJsonSchema([]string{"packageManager1", "packageManager2"})
+		Expect(err).To(BeNil())
+		Expect(string(schema)).To(ContainSubstring(`"enum": [
+            "packageManager1",
+            "packageManager2"
+          ]`))
+		Expect(string(schema)).To(ContainSubstring(`"enum": [
+            "windows",
+            "macos",
+            "linux"
+          ]`))
+	})
+})
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ConfigTransaction batches changes to the shared config file, so that a command writes the file at most once no matter
+// how many changes it makes. This keeps cloud drives from having to sync the file over and over.
+//
+// Changes are made by passing mutation functions to Mutate. They are applied to an in-memory copy of the shared config
+// file right away, and written when Commit is called. If the file was changed by another process or machine in the
+// meantime, it is read again and the mutations are reapplied before writing, so neither set of changes is lost.
+type ConfigTransaction struct {
+	configService *ConfigService
+	config        *Config
+	mutations     []func(config *Config) error
+	finished      bool
+}
+
+// BeginTransaction starts a new transaction for changing the shared config file. The file isn't read until the
+// transaction's Config or Mutate method is first called.
+func (configService *ConfigService) BeginTransaction() *ConfigTransaction {
+	return &ConfigTransaction{configService: configService}
+}
+
+// Config returns the shared configuration as changed by the transaction so far. It should only be used for reading,
+// since changes made to it directly aren't reapplied if the file has to be read again when committing. Use Mutate to
+// make changes.
+func (transaction *ConfigTransaction) Config() (*Config, error) {
+	if transaction.config == nil {
+		config, err := transaction.configService.GetSharedConfig()
+		if err != nil {
+			return nil, err
+		}
+		transaction.config = config
+	}
+
+	return transaction.config, nil
+}
+
+// Mutate applies the given change to the shared configuration, to be written when the transaction is committed. If the
+// mutation returns an error, it is assumed to have left the configuration unchanged, and it isn't recorded.
+//
+// It takes the following parameters:
+//   - mutation: A function that changes the given Config.
+func (transaction *ConfigTransaction) Mutate(mutation func(config *Config) error) error {
+	if transaction.finished {
+		return fmt.Errorf("the config transaction has already been committed")
+	}
+
+	config, err := transaction.Config()
+	if err != nil {
+		return err
+	}
+
+	if err = mutation(config); err != nil {
+		return err
+	}
+
+	transaction.mutations = append(transaction.mutations, mutation)
+	return nil
+}
+
+// HasChanges returns whether any mutations have been applied in the transaction.
+func (transaction *ConfigTransaction) HasChanges() bool {
+	return len(transaction.mutations) > 0
+}
+
+// Commit writes the changes made in the transaction to the shared config file. If no changes were made, the file isn't
+// written. If the file was changed by another process or machine since it was read, it is read again and the
+// transaction's mutations are reapplied, which fails if one of them no longer applies (for example, because the other
+// change removed the same package).
+func (transaction *ConfigTransaction) Commit() error {
+	if transaction.finished {
+		return nil
+	}
+	transaction.finished = true
+
+	if !transaction.HasChanges() {
+		return nil
+	}
+
+	err := transaction.configService.SetConfig(transaction.config)
+	if !errors.Is(err, ErrConfigChanged) {
+		return err
+	}
+
+	config, err := transaction.configService.GetSharedConfig()
+	if err != nil {
+		return err
+	}
+
+	for _, mutation := range transaction.mutations {
+		if err = mutation(config); err != nil {
+			return fmt.Errorf("the config file was changed by another process or machine, and the changes could "+
+				"not be reapplied to it: %w", err)
+		}
+	}
+
+	transaction.config = config
+	return transaction.configService.SetConfig(config)
+}
+
+// Finish commits the transaction at the end of a command, even if the command failed partway through, so that the
+// changes made before the failure are still saved. If the given error isn't nil, it is returned, noting whether
+// committing failed as well. Otherwise, the result of committing is returned.
+//
+// It takes the following parameters:
+//   - err: The error returned by the command, if any.
+func (transaction *ConfigTransaction) Finish(err error) error {
+	commitErr := transaction.Commit()
+	if err != nil && commitErr != nil {
+		return fmt.Errorf("%w (the changes made before this error could not be saved either: %v)", err, commitErr)
+	} else if err != nil {
+		return err
+	}
+	return commitErr
+}
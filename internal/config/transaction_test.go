@@ -0,0 +1,94 @@
+package config_test
+
+import (
+	"fmt"
+	"os"
+
+	. "github.com/colececil/familiar.sh/internal/config"
+	"github.com/colececil/familiar.sh/internal/packagemanagers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConfigTransaction", func() {
+	const initialContents = "version: 1\nfiles: []\nscripts: []\npackageManagers:\n  - name: scoop\n    packages: []\n"
+
+	var configLocation string
+	var configService *ConfigService
+
+	BeforeEach(func() {
+		configLocation = useTemporaryConfigLocation()
+		writeConfigFile(configLocation, initialContents)
+		configService = newConfigService()
+	})
+
+	addPackage := func(packageName string, version string) func(config *Config) error {
+		return func(config *Config) error {
+			return config.AddPackage("scoop", packageName, packagemanagers.NewVersion(version))
+		}
+	}
+
+	readConfigFile := func() string {
+		contents, err := os.ReadFile(configLocation)
+		Expect(err).To(BeNil())
+		return string(contents)
+	}
+
+	It("should write all the mutations at once when committed", func() {
+		transaction := configService.BeginTransaction()
+		Expect(transaction.Mutate(addPackage("package1", "1.0.0"))).To(Succeed())
+		Expect(transaction.Mutate(addPackage("package2", "2.0.0"))).To(Succeed())
+		Expect(readConfigFile()).To(Equal(initialContents))
+
+		Expect(transaction.Commit()).To(Succeed())
+		Expect(readConfigFile()).To(Equal("version: 1\nfiles: []\nscripts: []\npackageManagers:\n  - name: scoop\n" +
+			"    packages:\n      - name: package1\n        version: 1.0.0\n      - name: package2\n" +
+			"        version: 2.0.0\n"))
+	})
+
+	It("should not record a mutation that returns an error", func() {
+		transaction := configService.BeginTransaction()
+		Expect(transaction.Mutate(addPackage("package1", "1.0.0"))).To(Succeed())
+		Expect(transaction.Mutate(addPackage("package1", "1.0.0"))).ToNot(Succeed())
+		Expect(transaction.Commit()).To(Succeed())
+
+		config, err := configService.GetSharedConfig()
+		Expect(err).To(BeNil())
+		Expect(config.PackageManagers[0].Packages).To(HaveLen(1))
+	})
+
+	It("should not write the file when nothing was changed", func() {
+		transaction := configService.BeginTransaction()
+		_, err := transaction.Config()
+		Expect(err).To(BeNil())
+
+		Expect(os.Remove(configLocation)).To(Succeed())
+		Expect(transaction.Commit()).To(Succeed())
+		Expect(configLocation).ToNot(BeAnExistingFile())
+	})
+
+	It("should reapply the mutations when the file was changed by another machine", func() {
+		transaction := configService.BeginTransaction()
+		Expect(transaction.Mutate(addPackage("package1", "1.0.0"))).To(Succeed())
+
+		writeConfigFile(configLocation, "version: 1\nfiles:\n  - sourcePath: .bashrc\nscripts: []\npackageManagers:\n"+
+			"  - name: scoop\n    packages: []\n")
+		Expect(transaction.Commit()).To(Succeed())
+
+		config, err := configService.GetSharedConfig()
+		Expect(err).To(BeNil())
+		Expect(config.Files).To(Equal([]ConfiguredFile{{SourcePath: ".bashrc"}}))
+		Expect(config.PackageManagers[0].Packages).To(Equal([]ConfiguredPackage{
+			{Name: "package1", Version: "1.0.0"},
+		}))
+	})
+
+	It("should save the changes made before an error when finishing", func() {
+		transaction := configService.BeginTransaction()
+		Expect(transaction.Mutate(addPackage("package1", "1.0.0"))).To(Succeed())
+
+		commandErr := fmt.Errorf("command failed")
+		Expect(transaction.Finish(commandErr)).To(MatchError(commandErr))
+		Expect(readConfigFile()).To(ContainSubstring("package1"))
+	})
+})
@@ -0,0 +1,421 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/colececil/familiar.sh/internal/collections"
+	"github.com/colececil/familiar.sh/internal/packagemanagers"
+	"github.com/colececil/familiar.sh/internal/system"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// Severity represents how serious a problem found by ConfigValidator is.
+type Severity string
+
+const (
+	// ErrorSeverity means that the problem must be fixed before the config can be used.
+	ErrorSeverity Severity = "error"
+
+	// WarningSeverity means that the config can be used, but it probably doesn't do what was intended.
+	WarningSeverity Severity = "warning"
+)
+
+// Diagnostic describes a problem found by ConfigValidator.
+type Diagnostic struct {
+	// Path is the path of the file the problem was found in.
+	Path string `yaml:"path"`
+
+	// Line is the line the problem was found on, or 0 if it isn't tied to a specific line.
+	Line int `yaml:"line,omitempty"`
+
+	// Column is the column the problem was found at, or 0 if it isn't tied to a specific column.
+	Column int `yaml:"column,omitempty"`
+
+	Severity Severity `yaml:"severity"`
+	Message  string   `yaml:"message"`
+}
+
+// String returns the diagnostic in the form "path:line:column: severity: message", leaving out the line and column if
+// they aren't known.
+func (diagnostic Diagnostic) String() string {
+	location := diagnostic.Path
+	if diagnostic.Line > 0 {
+		location += ":" + strconv.Itoa(diagnostic.Line)
+		if diagnostic.Column > 0 {
+			location += ":" + strconv.Itoa(diagnostic.Column)
+		}
+	}
+
+	return fmt.Sprintf("%s: %s: %s", location, diagnostic.Severity, diagnostic.Message)
+}
+
+// HasErrors returns whether any of the given diagnostics are errors.
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == ErrorSeverity {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigValidator checks the shared config file, the files it includes, and the local overrides file for problems,
+// so they can be reported before anything is changed on the machine.
+type ConfigValidator struct {
+	configService          *ConfigService
+	packageManagerRegistry packagemanagers.PackageManagerRegistry
+}
+
+// NewConfigValidator creates a new instance of ConfigValidator.
+func NewConfigValidator(configService *ConfigService,
+	packageManagerRegistry packagemanagers.PackageManagerRegistry) *ConfigValidator {
+	return &ConfigValidator{
+		configService:          configService,
+		packageManagerRegistry: packageManagerRegistry,
+	}
+}
+
+// yamlErrorLineRegex captures the line number and message from an error reported by the YAML decoder.
+var yamlErrorLineRegex = regexp.MustCompile(`line (\d+): (.*)`)
+
+// Validate checks all config files that make up the effective configuration, and returns the problems that were found.
+// When a layered context is in use, the config file of each of its layers is checked, along with the files it
+// includes. Each file is decoded strictly, so unknown fields are reported, and then its contents are checked against
+// the registered package managers and operating systems. Entries that are defined in more than one file, conflicting
+// copies of the config files made by cloud drives, and "remove" sections in the first layer (which have nothing to
+// remove entries from) are reported as warnings.
+//
+// An error is only returned if the files couldn't be read at all.
+func (configValidator *ConfigValidator) Validate() ([]Diagnostic, error) {
+	layers, _, err := configValidator.configService.resolveConfigLayers()
+	if err != nil {
+		return nil, err
+	}
+
+	var diagnostics []Diagnostic
+	for i, layer := range layers {
+		layerDiagnostics, err := configValidator.validateConfigLayer(layer, i == 0)
+		if err != nil {
+			return nil, err
+		}
+		diagnostics = append(diagnostics, layerDiagnostics...)
+	}
+
+	overridesContents, err := os.ReadFile(ConfigOverridesLocation())
+	if err == nil {
+		diagnostics = append(diagnostics, configValidator.validateOverridesFile(ConfigOverridesLocation(),
+			overridesContents)...)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if HasErrors(diagnostics) {
+		return diagnostics, nil
+	}
+
+	_, provenance, err := configValidator.configService.GetEffectiveConfig()
+	if err != nil {
+		return append(diagnostics, Diagnostic{Path: writableConfigLayer(layers).location.Path,
+			Severity: ErrorSeverity, Message: err.Error()}), nil
+	}
+
+	for _, duplicate := range provenance.Duplicates() {
+		diagnostics = append(diagnostics, Diagnostic{Path: duplicate.Source, Severity: WarningSeverity,
+			Message: fmt.Sprintf("%s is ignored, because it is already defined in %s", duplicate.Description,
+				duplicate.KeptSource)})
+	}
+
+	return diagnostics, nil
+}
+
+// validateConfigLayer checks the config file of the given layer, the files it includes, and any conflicting copies of
+// them made by cloud drives. If the writable layer's config file doesn't exist yet, nothing is reported, since it is
+// created when it is first read.
+//
+// It takes the following parameters:
+//   - layer: The layer to check.
+//   - isFirstLayer: Whether the layer is the first one applied, so that a "remove" section in it has no effect.
+func (configValidator *ConfigValidator) validateConfigLayer(layer configLayer, isFirstLayer bool) ([]Diagnostic,
+	error) {
+	configLocation := layer.location.Path
+	contents, err := configValidator.configService.readConfigLayerFile(layer)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		if layer.writable {
+			return nil, nil
+		}
+		return []Diagnostic{{Path: configLocation, Severity: ErrorSeverity,
+			Message: fmt.Sprintf("the config file of layer %q doesn't exist", layer.name)}}, nil
+	}
+
+	configDirectory := filepath.Dir(configLocation)
+	diagnostics := configValidator.validateConfigFile(configLocation, contents, configDirectory)
+
+	var document yaml.Node
+	if err = yaml.Unmarshal(contents, &document); err != nil {
+		return diagnostics, nil
+	}
+	if remove := mappingValue(&document, "remove"); remove != nil && isFirstLayer {
+		diagnostics = append(diagnostics, Diagnostic{Path: configLocation, Line: remove.Line, Column: remove.Column,
+			Severity: WarningSeverity, Message: "the \"remove\" section is ignored, because there are no earlier " +
+				"layers to remove entries from"})
+	}
+
+	var sharedConfig Config
+	if err = document.Decode(&sharedConfig); err != nil {
+		return diagnostics, nil
+	}
+
+	includePaths, err := ResolveIncludes(&sharedConfig, configLocation)
+	if err != nil {
+		return append(diagnostics, Diagnostic{Path: configLocation, Severity: ErrorSeverity,
+			Message: err.Error()}), nil
+	}
+
+	for _, includePath := range includePaths {
+		includedContents, err := os.ReadFile(includePath)
+		if err != nil {
+			return nil, err
+		}
+		diagnostics = append(diagnostics, configValidator.validateConfigFile(includePath, includedContents,
+			configDirectory)...)
+	}
+
+	conflictCopies, err := FindConflictCopies(configLocation, includePaths)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, conflictCopy := range conflictCopies {
+		diagnostics = append(diagnostics, Diagnostic{Path: conflictCopy, Severity: WarningSeverity,
+			Message: "this looks like a conflicting copy of the shared config file made by a cloud drive. Merge it " +
+				"into the shared config file with \"familiar config conflicts merge\""})
+	}
+
+	return diagnostics, nil
+}
+
+// validateConfigFile checks the contents of a single shared or included config file.
+//
+// It takes the following parameters:
+//   - path: The path of the file, used in the diagnostics.
+//   - contents: The contents of the file.
+//   - configDirectory: The directory that file and script source paths are relative to.
+func (configValidator *ConfigValidator) validateConfigFile(path string, contents []byte,
+	configDirectory string) []Diagnostic {
+	diagnostics := decodeStrictly(path, contents, &Config{})
+
+	// If the contents can't be parsed at all, the syntax error has already been reported by the strict decoding.
+	var document yaml.Node
+	if err := yaml.Unmarshal(contents, &document); err != nil || len(document.Content) == 0 {
+		return diagnostics
+	}
+
+	report := func(node *yaml.Node, severity Severity, format string, args ...any) {
+		diagnostics = append(diagnostics, Diagnostic{Path: path, Line: node.Line, Column: node.Column,
+			Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, key := range []string{"files", "scripts"} {
+		entries := mappingValue(&document, key)
+		if entries == nil {
+			continue
+		}
+
+		seenSourcePaths := make(map[string]bool)
+		for _, entry := range entries.Content {
+			sourcePath := mappingValue(entry, "sourcePath")
+			if sourcePath == nil || sourcePath.Value == "" {
+				report(entry, ErrorSeverity, "missing \"sourcePath\"")
+				continue
+			}
+
+			if seenSourcePaths[sourcePath.Value] {
+				report(sourcePath, ErrorSeverity, "duplicate source path %q", sourcePath.Value)
+			}
+			seenSourcePaths[sourcePath.Value] = true
+
+			fullSourcePath := sourcePath.Value
+			if !filepath.IsAbs(fullSourcePath) {
+				fullSourcePath = filepath.Join(configDirectory, fullSourcePath)
+			}
+			if _, err := os.Stat(fullSourcePath); err != nil {
+				report(sourcePath, ErrorSeverity, "source file %q does not exist", fullSourcePath)
+			}
+
+			if operatingSystems := mappingValue(entry, "operatingSystems"); operatingSystems != nil {
+				for _, operatingSystem := range operatingSystems.Content {
+					name := mappingValue(operatingSystem, "name")
+					if name == nil {
+						report(operatingSystem, ErrorSeverity, "missing operating system \"name\"")
+					} else if !collections.Contains(system.OperatingSystemNames, name.Value) {
+						report(name, ErrorSeverity, "unknown operating system %q (expected one of %v)", name.Value,
+							system.OperatingSystemNames)
+					}
+				}
+			}
+
+			validateTags(mappingValue(entry, "tags"), report)
+		}
+	}
+
+	if packageManagers := mappingValue(&document, "packageManagers"); packageManagers != nil {
+		diagnostics = append(diagnostics, configValidator.validatePackageManagers(path, packageManagers)...)
+	}
+
+	return diagnostics
+}
+
+// validateOverridesFile checks the contents of the local overrides file.
+//
+// It takes the following parameters:
+//   - path: The path of the file, used in the diagnostics.
+//   - contents: The contents of the file.
+func (configValidator *ConfigValidator) validateOverridesFile(path string, contents []byte) []Diagnostic {
+	diagnostics := decodeStrictly(path, contents, &ConfigOverrides{})
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(contents, &document); err != nil || len(document.Content) == 0 {
+		return diagnostics
+	}
+
+	if packageManagers := mappingValue(&document, "packageManagers"); packageManagers != nil {
+		diagnostics = append(diagnostics, configValidator.validatePackageManagers(path, packageManagers)...)
+	}
+
+	if excludedPackages := mappingValue(&document, "excludedPackages"); excludedPackages != nil {
+		for _, excludedPackage := range excludedPackages.Content {
+			packageManager := mappingValue(excludedPackage, "packageManager")
+			if packageManager == nil {
+				continue
+			}
+
+			if _, err := configValidator.packageManagerRegistry.GetPackageManager(packageManager.Value); err != nil {
+				diagnostics = append(diagnostics, Diagnostic{Path: path, Line: packageManager.Line,
+					Column: packageManager.Column, Severity: ErrorSeverity,
+					Message: fmt.Sprintf("unknown package manager %q", packageManager.Value)})
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+// validatePackageManagers checks a sequence of package managers, along with their packages.
+//
+// It takes the following parameters:
+//   - path: The path of the file, used in the diagnostics.
+//   - packageManagers: The sequence node containing the package managers.
+func (configValidator *ConfigValidator) validatePackageManagers(path string,
+	packageManagers *yaml.Node) []Diagnostic {
+	var diagnostics []Diagnostic
+	report := func(node *yaml.Node, severity Severity, format string, args ...any) {
+		diagnostics = append(diagnostics, Diagnostic{Path: path, Line: node.Line, Column: node.Column,
+			Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	seenPackageManagers := make(map[string]bool)
+	for _, packageManager := range packageManagers.Content {
+		name := mappingValue(packageManager, "name")
+		if name == nil || name.Value == "" {
+			report(packageManager, ErrorSeverity, "missing package manager \"name\"")
+			continue
+		}
+
+		if _, err := configValidator.packageManagerRegistry.GetPackageManager(name.Value); err != nil {
+			report(name, ErrorSeverity, "unknown package manager %q", name.Value)
+		}
+
+		if seenPackageManagers[name.Value] {
+			report(name, ErrorSeverity, "duplicate package manager %q", name.Value)
+		}
+		seenPackageManagers[name.Value] = true
+
+		packages := mappingValue(packageManager, "packages")
+		if packages == nil {
+			continue
+		}
+
+		seenPackages := make(map[string]bool)
+		for _, configuredPackage := range packages.Content {
+			packageName := mappingValue(configuredPackage, "name")
+			if packageName == nil || packageName.Value == "" {
+				report(configuredPackage, ErrorSeverity, "missing package \"name\"")
+				continue
+			}
+
+			if seenPackages[packageName.Value] {
+				report(packageName, ErrorSeverity, "duplicate package %q under package manager %q", packageName.Value,
+					name.Value)
+			}
+			seenPackages[packageName.Value] = true
+
+			if version := mappingValue(configuredPackage, "version"); version == nil || version.Value == "" {
+				report(packageName, ErrorSeverity, "missing \"version\" for package %q", packageName.Value)
+			}
+
+			validateTags(mappingValue(configuredPackage, "tags"), report)
+		}
+	}
+
+	return diagnostics
+}
+
+// validateTags checks that each tag in the given sequence node is valid, reporting any that aren't.
+func validateTags(tags *yaml.Node, report func(node *yaml.Node, severity Severity, format string, args ...any)) {
+	if tags == nil {
+		return
+	}
+
+	for _, tag := range tags.Content {
+		if !validTagRegex.MatchString(tag.Value) {
+			report(tag, ErrorSeverity, "invalid tag %q: tags may only contain letters, numbers, hyphens, and "+
+				"underscores", tag.Value)
+		}
+	}
+}
+
+// decodeStrictly decodes the given YAML contents into the given value, rejecting any fields that the value doesn't
+// have. Any problems are returned as diagnostics.
+//
+// It takes the following parameters:
+//   - path: The path of the file, used in the diagnostics.
+//   - contents: The YAML contents to decode.
+//   - value: A pointer to the value to decode into.
+func decodeStrictly(path string, contents []byte, value any) []Diagnostic {
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	decoder.KnownFields(true)
+
+	err := decoder.Decode(value)
+	if err == nil || errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	var messages []string
+	var typeError *yaml.TypeError
+	if errors.As(err, &typeError) {
+		messages = typeError.Errors
+	} else {
+		messages = []string{err.Error()}
+	}
+
+	var diagnostics []Diagnostic
+	for _, message := range messages {
+		diagnostic := Diagnostic{Path: path, Severity: ErrorSeverity, Message: message}
+		if matches := yamlErrorLineRegex.FindStringSubmatch(message); matches != nil {
+			diagnostic.Line, _ = strconv.Atoi(matches[1])
+			diagnostic.Message = matches[2]
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+
+	return diagnostics
+}
@@ -0,0 +1,152 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/colececil/familiar.sh/internal/config"
+	"github.com/colececil/familiar.sh/internal/packagemanagers"
+	"github.com/colececil/familiar.sh/internal/test"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConfigValidator", func() {
+	var configLocation string
+	var configValidator *ConfigValidator
+
+	var configService *ConfigService
+
+	BeforeEach(func() {
+		configLocation = useTemporaryConfigLocation()
+
+		scoopPackageManager := packagemanagers.NewScoopPackageManager(
+			test.NewOperatingSystemServiceDouble().OperatingSystemService,
+			test.NewShellCommandServiceDouble().ShellCommandService, test.NewMessageWriterFuncDouble())
+		packageManagerRegistry := packagemanagers.NewPackageManagerRegistry(scoopPackageManager)
+		configService = newConfigService()
+		configValidator = NewConfigValidator(configService, packageManagerRegistry)
+	})
+
+	It("should report no problems for a valid config", func() {
+		Expect(os.WriteFile(filepath.Join(filepath.Dir(configLocation), ".bashrc"), []byte{}, 0600)).To(Succeed())
+		writeConfigFile(configLocation, `version: 1
+files:
+  - sourcePath: .bashrc
+    operatingSystems:
+      - name: linux
+scripts: []
+packageManagers:
+  - name: scoop
+    packages:
+      - name: package1
+        version: 1.0.0
+`)
+
+		diagnostics, err := configValidator.Validate()
+		Expect(err).To(BeNil())
+		Expect(diagnostics).To(BeEmpty())
+	})
+
+	It("should report unknown fields, package managers, and operating systems with their positions", func() {
+		writeConfigFile(configLocation, `version: 1
+files: []
+scripts:
+  - sourcePath: setup.sh
+    operatingSystems:
+      - name: windoze
+packageManagers:
+  - name: brew
+    packages:
+      - name: package1
+        verison: 1.0.0
+`)
+
+		diagnostics, err := configValidator.Validate()
+		Expect(err).To(BeNil())
+		Expect(HasErrors(diagnostics)).To(BeTrue())
+		Expect(diagnostics).To(ContainElements(
+			Diagnostic{Path: configLocation, Line: 11, Severity: ErrorSeverity,
+				Message: "field verison not found in type config.ConfiguredPackage"},
+			Diagnostic{Path: configLocation, Line: 4, Column: 17, Severity: ErrorSeverity,
+				Message: "source file \"" + filepath.Join(filepath.Dir(configLocation), "setup.sh") +
+					"\" does not exist"},
+			Diagnostic{Path: configLocation, Line: 6, Column: 15, Severity: ErrorSeverity,
+				Message: "unknown operating system \"windoze\" (expected one of [windows macos linux])"},
+			Diagnostic{Path: configLocation, Line: 8, Column: 11, Severity: ErrorSeverity,
+				Message: "unknown package manager \"brew\""},
+		))
+	})
+
+	It("should report duplicate packages", func() {
+		writeConfigFile(configLocation, `version: 1
+files: []
+scripts: []
+packageManagers:
+  - name: scoop
+    packages:
+      - name: package1
+        version: 1.0.0
+      - name: package1
+        version: 2.0.0
+`)
+
+		diagnostics, err := configValidator.Validate()
+		Expect(err).To(BeNil())
+		Expect(diagnostics).To(Equal([]Diagnostic{
+			{Path: configLocation, Line: 9, Column: 15, Severity: ErrorSeverity,
+				Message: "duplicate package \"package1\" under package manager \"scoop\""},
+		}))
+	})
+
+	Describe("layered contexts", func() {
+		var teamLocation string
+
+		BeforeEach(func() {
+			teamLocation = filepath.Join(GinkgoT().TempDir(), "team.yaml")
+			Expect(configService.AddConfigContext("team", "file", teamLocation, "")).To(Succeed())
+			Expect(configService.SetConfigContextLayers("work", []string{"team", "default"})).To(Succeed())
+			Expect(configService.UseConfigContext("work")).To(Succeed())
+		})
+
+		It("should check the config file and included files of every layer", func() {
+			writeConfigFile(teamLocation, "version: 1\ninclude:\n  - team-packages.yaml\n")
+			writeConfigFile(filepath.Join(filepath.Dir(teamLocation), "team-packages.yaml"),
+				"packageManagers:\n  - name: brew\n    packages: []\n")
+			writeConfigFile(configLocation, "version: 1\nfiels: []\n")
+
+			diagnostics, err := configValidator.Validate()
+			Expect(err).To(BeNil())
+			Expect(diagnostics).To(ConsistOf(
+				Diagnostic{Path: filepath.Join(filepath.Dir(teamLocation), "team-packages.yaml"), Line: 2,
+					Column: 11, Severity: ErrorSeverity, Message: "unknown package manager \"brew\""},
+				Diagnostic{Path: configLocation, Line: 2, Severity: ErrorSeverity,
+					Message: "field fiels not found in type config.Config"},
+			))
+		})
+
+		It("should report a layer whose config file doesn't exist", func() {
+			writeConfigFile(configLocation, "version: 1\n")
+
+			diagnostics, err := configValidator.Validate()
+			Expect(err).To(BeNil())
+			Expect(diagnostics).To(Equal([]Diagnostic{
+				{Path: teamLocation, Severity: ErrorSeverity,
+					Message: "the config file of layer \"team\" doesn't exist"},
+			}))
+		})
+
+		It("should warn about a \"remove\" section in the first layer, but not in later ones", func() {
+			writeConfigFile(teamLocation, "version: 1\nremove:\n  files:\n    - .bashrc\n")
+			writeConfigFile(configLocation, "version: 1\nremove:\n  files:\n    - .vimrc\n")
+
+			diagnostics, err := configValidator.Validate()
+			Expect(err).To(BeNil())
+			Expect(diagnostics).To(Equal([]Diagnostic{
+				{Path: teamLocation, Line: 3, Column: 3, Severity: WarningSeverity,
+					Message: "the \"remove\" section is ignored, because there are no earlier layers to remove " +
+						"entries from"},
+			}))
+		})
+	})
+})
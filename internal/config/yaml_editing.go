@@ -0,0 +1,178 @@
+package config
+
+import (
+	"bytes"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+// defaultYamlIndent is the indentation used when writing YAML that doesn't have an existing indentation to follow.
+const defaultYamlIndent = 4
+
+// sequenceItemKeys contains the keys that identify an item in a sequence of mappings, in order of preference. They are
+// used to match up the items of an existing sequence with the items of its updated version.
+var sequenceItemKeys = []string{"name", "sourcePath"}
+
+// mergeYamlNode updates the existing node in place so that it has the same contents as the updated node, while keeping
+// the comments, key order, and scalar styles of any parts of the existing node that are still present. Mapping keys
+// that aren't in the updated node are removed, and new keys are added after the existing ones.
+//
+// It takes the following parameters:
+//   - existing: The node to update, typically parsed from a file containing hand-written comments.
+//   - updated: The node containing the new contents, typically encoded from a struct.
+func mergeYamlNode(existing *yaml.Node, updated *yaml.Node) {
+	if existing.Kind != updated.Kind {
+		headComment, lineComment, footComment := existing.HeadComment, existing.LineComment, existing.FootComment
+		*existing = *updated
+		existing.HeadComment, existing.LineComment, existing.FootComment = headComment, lineComment, footComment
+		return
+	}
+
+	switch existing.Kind {
+	case yaml.DocumentNode:
+		if len(existing.Content) == 1 && len(updated.Content) == 1 {
+			mergeYamlNode(existing.Content[0], updated.Content[0])
+		} else {
+			existing.Content = updated.Content
+		}
+	case yaml.MappingNode:
+		mergeYamlMapping(existing, updated)
+	case yaml.SequenceNode:
+		mergeYamlSequence(existing, updated)
+	case yaml.ScalarNode:
+		if existing.Value != updated.Value || existing.Tag != updated.Tag {
+			existing.Value = updated.Value
+			existing.Tag = updated.Tag
+			existing.Style = updated.Style
+		}
+	default:
+		existing.Content = updated.Content
+	}
+}
+
+// mergeYamlMapping updates the existing mapping node to have the keys and values of the updated mapping node. Keys that
+// are present in both keep their position and comments.
+func mergeYamlMapping(existing *yaml.Node, updated *yaml.Node) {
+	var content []*yaml.Node
+	var addedContent []*yaml.Node
+
+	updatedValues := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(updated.Content); i += 2 {
+		updatedValues[updated.Content[i].Value] = updated.Content[i+1]
+	}
+
+	existingKeys := map[string]bool{}
+	for i := 0; i+1 < len(existing.Content); i += 2 {
+		key := existing.Content[i]
+		existingKeys[key.Value] = true
+		updatedValue, isPresent := updatedValues[key.Value]
+		if !isPresent {
+			continue
+		}
+
+		mergeYamlNode(existing.Content[i+1], updatedValue)
+		content = append(content, key, existing.Content[i+1])
+	}
+
+	for i := 0; i+1 < len(updated.Content); i += 2 {
+		if !existingKeys[updated.Content[i].Value] {
+			addedContent = append(addedContent, updated.Content[i], updated.Content[i+1])
+		}
+	}
+
+	existing.Content = append(content, addedContent...)
+}
+
+// mergeYamlSequence updates the existing sequence node to have the items of the updated sequence node, in the updated
+// order. Each updated item is merged into the matching existing item, if there is one, so that its comments are kept.
+// Mappings are matched using sequenceItemKeys, scalars by value, and anything else by position. An empty sequence takes
+// on the style of the updated sequence, so that a "[]" placeholder becomes a block sequence once it has items.
+func mergeYamlSequence(existing *yaml.Node, updated *yaml.Node) {
+	wasEmpty := len(existing.Content) == 0
+	used := make([]bool, len(existing.Content))
+	content := make([]*yaml.Node, 0, len(updated.Content))
+
+	for i, updatedItem := range updated.Content {
+		match := -1
+		for j, existingItem := range existing.Content {
+			if !used[j] && sequenceItemsMatch(existingItem, updatedItem, i == j) {
+				match = j
+				break
+			}
+		}
+
+		if match == -1 {
+			content = append(content, updatedItem)
+			continue
+		}
+
+		used[match] = true
+		mergeYamlNode(existing.Content[match], updatedItem)
+		content = append(content, existing.Content[match])
+	}
+
+	existing.Content = content
+	if wasEmpty && existing.Style != updated.Style {
+		existing.Style = updated.Style
+	}
+}
+
+// sequenceItemsMatch returns whether the given existing sequence item should be merged with the given updated sequence
+// item. The samePosition parameter says whether both items are at the same index in their sequences.
+func sequenceItemsMatch(existingItem *yaml.Node, updatedItem *yaml.Node, samePosition bool) bool {
+	if existingItem.Kind != updatedItem.Kind {
+		return false
+	}
+
+	switch existingItem.Kind {
+	case yaml.ScalarNode:
+		return existingItem.Value == updatedItem.Value
+	case yaml.MappingNode:
+		for _, key := range sequenceItemKeys {
+			existingValue, updatedValue := mappingValue(existingItem, key), mappingValue(updatedItem, key)
+			if existingValue != nil || updatedValue != nil {
+				return existingValue != nil && updatedValue != nil && existingValue.Value == updatedValue.Value
+			}
+		}
+		return samePosition
+	default:
+		return samePosition
+	}
+}
+
+// encodeYamlNode encodes the given node as YAML using the given number of spaces for indentation.
+func encodeYamlNode(node *yaml.Node, indent int) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(indent)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// detectYamlIndent returns the number of spaces used for indentation in the given YAML contents, so that it can be kept
+// when the contents are rewritten. This is taken to be the smallest indentation of any line that isn't blank or a
+// comment. If the contents aren't indented at all, defaultYamlIndent is returned.
+func detectYamlIndent(contents []byte) int {
+	indent := 0
+	for _, line := range strings.Split(string(contents), "\n") {
+		trimmedLine := strings.TrimLeft(line, " ")
+		if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") {
+			continue
+		}
+
+		lineIndent := len(line) - len(trimmedLine)
+		if lineIndent > 0 && (indent == 0 || lineIndent < indent) {
+			indent = lineIndent
+		}
+	}
+
+	if indent < 2 || indent > 9 {
+		return defaultYamlIndent
+	}
+	return indent
+}
@@ -0,0 +1,21 @@
+package packagemanagers
+
+// Capabilities describes which optional operations a package manager supports. Commands should check it before relying
+// on one of these operations, so they can adapt or fail with a clear message instead of having parameters silently
+// ignored.
+type Capabilities struct {
+	// VersionedInstall is whether specific versions of packages can be installed or updated to.
+	VersionedInstall bool
+
+	// Downgrade is whether installed packages can be replaced with older versions.
+	Downgrade bool
+
+	// Search is whether the package manager can search for available packages.
+	Search bool
+
+	// Info is whether the package manager can provide detailed information about a package.
+	Info bool
+
+	// Hold is whether the package manager can natively hold a package at its installed version.
+	Hold bool
+}
@@ -0,0 +1,21 @@
+package packagemanagers
+
+// PackageInfo represents detailed information about a package that is available from a package manager.
+type PackageInfo struct {
+	Name              string
+	Description       string
+	Homepage          string
+	License           string
+	AvailableVersions []*Version
+	Dependencies      []string
+	InstallLocation   string
+}
+
+// NewPackageInfo creates a new instance of PackageInfo.
+func NewPackageInfo(name string) *PackageInfo {
+	return &PackageInfo{
+		Name:              name,
+		AvailableVersions: []*Version{},
+		Dependencies:      []string{},
+	}
+}
@@ -8,6 +8,9 @@ type PackageManager interface {
 	// IsSupported returns whether the package manager is supported on the current machine.
 	IsSupported() bool
 
+	// Capabilities returns which optional operations the package manager supports.
+	Capabilities() Capabilities
+
 	// IsInstalled returns whether the package manager is installed.
 	IsInstalled() (bool, error)
 
@@ -23,8 +26,17 @@ type PackageManager interface {
 	// InstalledPackages returns a slice containing information about all packages that are installed.
 	InstalledPackages() ([]*Package, error)
 
+	// SearchPackages returns a slice containing all available packages that match the given search term. If the package
+	// manager doesn't have the Search capability, an error is returned.
+	SearchPackages(term string) ([]*SearchResult, error)
+
+	// PackageInfo returns detailed information about the package of the given name. If the package isn't installed, the
+	// install location is left empty. If the package manager doesn't have the Info capability, an error is returned.
+	PackageInfo(packageName string) (*PackageInfo, error)
+
 	// InstallPackage installs the package of the given name. If a version is given, that specific version of the
-	// package is installed. Otherwise, the latest version is installed.
+	// package is installed. Otherwise, the latest version is installed. If a version is given but the package manager
+	// doesn't have the VersionedInstall capability, an error is returned.
 	//
 	// It takes the following parameters:
 	//   - packageName: The name of the package to install.
@@ -34,7 +46,8 @@ type PackageManager interface {
 	InstallPackage(packageName string, version *Version) (*Version, error)
 
 	// UpdatePackage updates the package of the given name. If a version is given, that specific version of the package
-	// is installed. Otherwise, the latest version is installed.
+	// is installed. Otherwise, the latest version is installed. If a version is given but the package manager doesn't
+	// have the VersionedInstall capability, an error is returned.
 	//
 	// It takes the following parameters:
 	//   - packageName: The name of the package to install.
@@ -43,6 +56,25 @@ type PackageManager interface {
 	// It returns the version of the package that was installed.
 	UpdatePackage(packageName string, version *Version) (*Version, error)
 
+	// DowngradePackage replaces the installed version of the package of the given name with the given older version.
+	// If the package manager doesn't have the Downgrade capability, or is otherwise unable to install that version, an
+	// error is returned explaining why.
+	//
+	// It takes the following parameters:
+	//   - packageName: The name of the package to downgrade.
+	//   - version: The version of the package to install.
+	//
+	// It returns the version of the package that is installed afterward.
+	DowngradePackage(packageName string, version *Version) (*Version, error)
+
 	// UninstallPackage uninstalls the package of the given name.
 	UninstallPackage(packageName string) error
+
+	// HoldPackage holds the package of the given name at its installed version, preventing the package manager itself
+	// from changing it. If the package manager doesn't have the Hold capability, an error is returned.
+	HoldPackage(packageName string) error
+
+	// UnholdPackage releases a hold previously placed on the package of the given name. If the package manager doesn't
+	// have the Hold capability, an error is returned.
+	UnholdPackage(packageName string) error
 }
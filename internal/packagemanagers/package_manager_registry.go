@@ -2,6 +2,7 @@ package packagemanagers
 
 import (
 	"fmt"
+	"sort"
 )
 
 type PackageManagerRegistry map[string]PackageManager
@@ -22,6 +23,16 @@ func (packageManagerRegistry PackageManagerRegistry) GetAllPackageManagers() []P
 	return packageManagersSlice
 }
 
+// GetPackageManagerNames returns the names of all package managers, sorted alphabetically.
+func (packageManagerRegistry PackageManagerRegistry) GetPackageManagerNames() []string {
+	var names []string
+	for name := range packageManagerRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // GetPackageManager returns the package manager with the given name, if it exists.
 //
 // It takes the following parameters:
@@ -3,6 +3,7 @@ package packagemanagers
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/colececil/familiar.sh/internal/collections"
 	"github.com/colececil/familiar.sh/internal/system"
 	"regexp"
 	"strings"
@@ -12,14 +13,16 @@ import (
 type ScoopPackageManager struct {
 	operatingSystemService *system.OperatingSystemService
 	shellCommandService    *system.ShellCommandService
+	messageWriterFunc      system.MessageWriterFunc
 }
 
 // NewScoopPackageManager returns a new instance of ScoopPackageManager.
 func NewScoopPackageManager(operatingSystemService *system.OperatingSystemService,
-	shellCommandService *system.ShellCommandService) *ScoopPackageManager {
+	shellCommandService *system.ShellCommandService, messageWriterFunc system.MessageWriterFunc) *ScoopPackageManager {
 	return &ScoopPackageManager{
 		operatingSystemService: operatingSystemService,
 		shellCommandService:    shellCommandService,
+		messageWriterFunc:      messageWriterFunc,
 	}
 }
 
@@ -33,9 +36,23 @@ func (scoopPackageManager *ScoopPackageManager) IsSupported() bool {
 	return scoopPackageManager.operatingSystemService.IsWindows()
 }
 
+// Capabilities returns which optional operations the package manager supports.
+//
+// Scoop always installs the latest version from its buckets, so it can't install arbitrary versions. It can downgrade,
+// but only to versions that are still installed locally.
+func (scoopPackageManager *ScoopPackageManager) Capabilities() Capabilities {
+	return Capabilities{
+		VersionedInstall: false,
+		Downgrade:        true,
+		Search:           true,
+		Info:             true,
+		Hold:             true,
+	}
+}
+
 // IsInstalled returns true if the package manager is installed.
 func (scoopPackageManager *ScoopPackageManager) IsInstalled() (bool, error) {
-	fmt.Printf("Checking if package manager \"%s\" is installed...\n", scoopPackageManager.Name())
+	scoopPackageManager.printf("Checking if package manager \"%s\" is installed...\n", scoopPackageManager.Name())
 
 	_, err := scoopPackageManager.shellCommandService.RunShellCommand(scoopPackageManager.Name(), false, nil,
 		"--version")
@@ -48,7 +65,7 @@ func (scoopPackageManager *ScoopPackageManager) IsInstalled() (bool, error) {
 
 // Install installs the package manager.
 func (scoopPackageManager *ScoopPackageManager) Install() error {
-	fmt.Printf("Installing package manager \"%s\"...\n", scoopPackageManager.Name())
+	scoopPackageManager.printf("Installing package manager \"%s\"...\n", scoopPackageManager.Name())
 
 	_, err := scoopPackageManager.shellCommandService.RunShellCommand("powershell", true, nil, "irm get.scoop.sh | iex")
 	if err != nil {
@@ -60,7 +77,7 @@ func (scoopPackageManager *ScoopPackageManager) Install() error {
 
 // Update updates the package manager.
 func (scoopPackageManager *ScoopPackageManager) Update() error {
-	fmt.Printf("Updating package manager \"%s\"...\n", scoopPackageManager.Name())
+	scoopPackageManager.printf("Updating package manager \"%s\"...\n", scoopPackageManager.Name())
 
 	_, err := scoopPackageManager.shellCommandService.RunShellCommand(scoopPackageManager.Name(), true, nil, "update")
 	if err != nil {
@@ -72,7 +89,7 @@ func (scoopPackageManager *ScoopPackageManager) Update() error {
 
 // Uninstall uninstalls the package manager.
 func (scoopPackageManager *ScoopPackageManager) Uninstall() error {
-	fmt.Printf("Uninstalling package manager \"%s\"...\n", scoopPackageManager.Name())
+	scoopPackageManager.printf("Uninstalling package manager \"%s\"...\n", scoopPackageManager.Name())
 
 	// Scoop doesn't return non-zero exit codes, so we have to check the output to see if the operation was successful.
 	// Todo: Add a regex to make sure the operation was successful.
@@ -88,7 +105,8 @@ func (scoopPackageManager *ScoopPackageManager) Uninstall() error {
 
 // InstalledPackages returns a slice containing information about all packages that are installed.
 func (scoopPackageManager *ScoopPackageManager) InstalledPackages() ([]*Package, error) {
-	fmt.Printf("Getting installed package information from package manager \"%s\"...\n", scoopPackageManager.Name())
+	scoopPackageManager.printf("Getting installed package information from package manager \"%s\"...\n",
+		scoopPackageManager.Name())
 
 	jsonCaptureRegex, err := regexp.Compile("(?s)(.*)")
 	if err != nil {
@@ -153,12 +171,157 @@ func (scoopPackageManager *ScoopPackageManager) InstalledPackages() ([]*Package,
 	return installedPackagesSlice, nil
 }
 
-// InstallPackage installs the package of the given name. If a version is given, that specific version of the package is
-// installed. Otherwise, the latest version is installed.
+// SearchPackages returns a slice containing all packages in the locally added buckets that match the given search term.
+func (scoopPackageManager *ScoopPackageManager) SearchPackages(term string) ([]*SearchResult, error) {
+	scoopPackageManager.printf("Searching for packages with package manager \"%s\"...\n", scoopPackageManager.Name())
+
+	resultsCaptureRegex, err := regexp.Compile("(?s)^.*----\\n(([^\\n]*(\\n)??)*)\\n*$")
+	if err != nil {
+		return nil, err
+	}
+
+	capturedResults, err := scoopPackageManager.shellCommandService.RunShellCommand(scoopPackageManager.Name(), false,
+		resultsCaptureRegex, "search", term)
+	if err != nil {
+		return nil, err
+	}
+
+	var searchResults []*SearchResult
+	if capturedResults != "" {
+		for _, resultLine := range strings.Split(capturedResults, "\n") {
+			resultFields := strings.Fields(resultLine)
+
+			// The "Binaries" column is only filled in when the search term matched one of the package's binaries.
+			if len(resultFields) < 3 {
+				return nil, fmt.Errorf("unexpected number of fields in line: %s", resultLine)
+			}
+
+			searchResults = append(searchResults, NewSearchResult(resultFields[0], NewVersion(resultFields[1]),
+				resultFields[2]))
+		}
+	}
+
+	return searchResults, nil
+}
+
+// PackageInfo returns detailed information about the package of the given name, using the output of "scoop info". The
+// dependencies are found using "scoop depends", and if the package is installed, the install location is found using
+// "scoop prefix".
+//
+// Scoop only provides the latest version of a package, so the available versions consist of that version along with any
+// other versions that are still installed locally.
+func (scoopPackageManager *ScoopPackageManager) PackageInfo(packageName string) (*PackageInfo, error) {
+	scoopPackageManager.printf("Getting information about package \"%s\" from package manager \"%s\"...\n", packageName,
+		scoopPackageManager.Name())
+
+	outputCaptureRegex, err := regexp.Compile("(?s)(.*)")
+	if err != nil {
+		return nil, err
+	}
+
+	capturedInfo, err := scoopPackageManager.shellCommandService.RunShellCommand(scoopPackageManager.Name(), false,
+		outputCaptureRegex, "info", packageName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each field is printed as "Key : Value", and fields with multiple values continue on indented lines.
+	fields := make(map[string][]string)
+	var currentKey string
+	for _, line := range strings.Split(capturedInfo, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		key, value, isField := strings.Cut(line, " : ")
+		if isField && !strings.HasPrefix(line, " ") {
+			currentKey = strings.TrimSpace(key)
+			fields[currentKey] = append(fields[currentKey], strings.TrimSpace(value))
+		} else if currentKey != "" {
+			fields[currentKey] = append(fields[currentKey], strings.TrimSpace(line))
+		}
+	}
+
+	if len(fields["Name"]) == 0 {
+		return nil, fmt.Errorf("could not find package \"%s\"", packageName)
+	}
+
+	packageInfo := NewPackageInfo(fields["Name"][0])
+	packageInfo.Description = strings.Join(fields["Description"], " ")
+	packageInfo.Homepage = strings.Join(fields["Website"], " ")
+	packageInfo.License = strings.Join(fields["License"], " ")
+
+	isInstalled := false
+	for _, versionString := range append(fields["Version"], fields["Installed"]...) {
+		if versionString == "No" {
+			continue
+		}
+
+		isInstalled = isInstalled || collections.Contains(fields["Installed"], versionString)
+		version := NewVersion(versionString)
+		isDuplicate := false
+		for _, availableVersion := range packageInfo.AvailableVersions {
+			if availableVersion.IsEqualTo(version) {
+				isDuplicate = true
+				break
+			}
+		}
+		if !isDuplicate {
+			packageInfo.AvailableVersions = append(packageInfo.AvailableVersions, version)
+		}
+	}
+
+	dependenciesCaptureRegex, err := regexp.Compile("(?s)^.*----\\n(([^\\n]*(\\n)??)*)\\n*$")
+	if err != nil {
+		return nil, err
+	}
+
+	capturedDependencies, err := scoopPackageManager.shellCommandService.RunShellCommand(scoopPackageManager.Name(),
+		false, dependenciesCaptureRegex, "depends", packageName)
+	if err != nil {
+		return nil, err
+	}
+
+	if capturedDependencies != "" {
+		for _, dependencyLine := range strings.Split(capturedDependencies, "\n") {
+			dependencyFields := strings.Fields(dependencyLine)
+			if len(dependencyFields) != 2 {
+				return nil, fmt.Errorf("unexpected number of fields in line: %s", dependencyLine)
+			}
+
+			// The package itself is listed last, after its dependencies.
+			if dependencyFields[1] != packageInfo.Name {
+				packageInfo.Dependencies = append(packageInfo.Dependencies, dependencyFields[1])
+			}
+		}
+	}
+
+	if isInstalled {
+		prefixCaptureRegex, err := regexp.Compile("(?m)^(.+?)\\s*$")
+		if err != nil {
+			return nil, err
+		}
+
+		packageInfo.InstallLocation, err = scoopPackageManager.shellCommandService.RunShellCommand(
+			scoopPackageManager.Name(), false, prefixCaptureRegex, "prefix", packageName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return packageInfo, nil
+}
+
+// InstallPackage installs the latest version of the package of the given name. Scoop doesn't support installing
+// specific versions, so if a version is given, an error is returned.
 //
 // It returns the version of the package that was installed.
 func (scoopPackageManager *ScoopPackageManager) InstallPackage(packageName string, version *Version) (*Version, error) {
-	fmt.Printf("Installing package \"%s\"...\n", packageName)
+	if version != nil {
+		return nil, scoopPackageManager.versionedInstallNotSupportedError()
+	}
+
+	scoopPackageManager.printf("Installing package \"%s\"...\n", packageName)
 
 	regexString := fmt.Sprintf("'%s' \\((.*)\\) was installed", packageName)
 	versionCaptureRegex, err := regexp.Compile(regexString)
@@ -178,12 +341,16 @@ func (scoopPackageManager *ScoopPackageManager) InstallPackage(packageName strin
 	return NewVersion(capturedVersion), nil
 }
 
-// UpdatePackage updates the package of the given name. If a version is given, that specific version of the package is
-// installed. Otherwise, the latest version is installed.
+// UpdatePackage updates the package of the given name to the latest version. Scoop doesn't support updating to
+// specific versions, so if a version is given, an error is returned.
 //
 // It returns the version of the package that was installed.
 func (scoopPackageManager *ScoopPackageManager) UpdatePackage(packageName string, version *Version) (*Version, error) {
-	fmt.Printf("Updating package \"%s\"...\n", packageName)
+	if version != nil {
+		return nil, scoopPackageManager.versionedInstallNotSupportedError()
+	}
+
+	scoopPackageManager.printf("Updating package \"%s\"...\n", packageName)
 
 	regexString := fmt.Sprintf("'%s' \\((.*)\\) was installed", packageName)
 	versionCaptureRegex, err := regexp.Compile(regexString)
@@ -203,9 +370,39 @@ func (scoopPackageManager *ScoopPackageManager) UpdatePackage(packageName string
 	return NewVersion(capturedVersion), nil
 }
 
+// DowngradePackage replaces the installed version of the package of the given name with the given older version.
+//
+// Scoop can only switch between versions that are still present on the machine (that is, versions that were installed
+// previously and haven't been removed by "scoop cleanup"), so this uses "scoop reset" and returns an error if the
+// given version isn't available locally.
+//
+// It returns the version of the package that is installed afterward.
+func (scoopPackageManager *ScoopPackageManager) DowngradePackage(packageName string, version *Version) (*Version,
+	error) {
+	scoopPackageManager.printf("Downgrading package \"%s\" to version %s...\n", packageName, version)
+
+	regexString := fmt.Sprintf("Resetting %s \\((.*)\\)", regexp.QuoteMeta(packageName))
+	versionCaptureRegex, err := regexp.Compile(regexString)
+	if err != nil {
+		return nil, err
+	}
+
+	capturedVersion, err := scoopPackageManager.shellCommandService.RunShellCommand(scoopPackageManager.Name(), true,
+		versionCaptureRegex, "reset", packageName+"@"+version.String())
+	if err != nil || capturedVersion == "" {
+		if err == nil {
+			err = fmt.Errorf("package manager \"%s\" can only downgrade to versions that are still installed locally, "+
+				"and version %s of package \"%s\" is not", scoopPackageManager.Name(), version, packageName)
+		}
+		return nil, err
+	}
+
+	return NewVersion(capturedVersion), nil
+}
+
 // UninstallPackage uninstalls the package of the given name.
 func (scoopPackageManager *ScoopPackageManager) UninstallPackage(packageName string) error {
-	fmt.Printf("Uninstalling package \"%s\"...\n", packageName)
+	scoopPackageManager.printf("Uninstalling package \"%s\"...\n", packageName)
 
 	// Scoop doesn't return non-zero exit codes, so we have to check the output to see if the operation was successful.
 	regexString := fmt.Sprintf("('%s' was uninstalled)", packageName)
@@ -225,3 +422,60 @@ func (scoopPackageManager *ScoopPackageManager) UninstallPackage(packageName str
 
 	return nil
 }
+
+// HoldPackage holds the package of the given name at its installed version, so that Scoop will not update it.
+func (scoopPackageManager *ScoopPackageManager) HoldPackage(packageName string) error {
+	scoopPackageManager.printf("Holding package \"%s\"...\n", packageName)
+
+	// Scoop doesn't return non-zero exit codes, so we have to check the output to see if the operation was successful.
+	regexString := fmt.Sprintf("(%s is now held)", regexp.QuoteMeta(packageName))
+	successRegex, err := regexp.Compile(regexString)
+	if err != nil {
+		return err
+	}
+
+	capturedSuccess, err := scoopPackageManager.shellCommandService.RunShellCommand(scoopPackageManager.Name(), true,
+		successRegex, "hold", packageName)
+	if err != nil || capturedSuccess == "" {
+		if err == nil {
+			err = fmt.Errorf("error holding package")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// UnholdPackage releases a hold previously placed on the package of the given name, so that Scoop can update it again.
+func (scoopPackageManager *ScoopPackageManager) UnholdPackage(packageName string) error {
+	scoopPackageManager.printf("Releasing hold on package \"%s\"...\n", packageName)
+
+	// Scoop doesn't return non-zero exit codes, so we have to check the output to see if the operation was successful.
+	regexString := fmt.Sprintf("(%s is no longer held)", regexp.QuoteMeta(packageName))
+	successRegex, err := regexp.Compile(regexString)
+	if err != nil {
+		return err
+	}
+
+	capturedSuccess, err := scoopPackageManager.shellCommandService.RunShellCommand(scoopPackageManager.Name(), true,
+		successRegex, "unhold", packageName)
+	if err != nil || capturedSuccess == "" {
+		if err == nil {
+			err = fmt.Errorf("error releasing hold on package")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// versionedInstallNotSupportedError returns the error used when a specific version of a package is requested.
+func (scoopPackageManager *ScoopPackageManager) versionedInstallNotSupportedError() error {
+	return fmt.Errorf("package manager \"%s\" does not support installing specific package versions",
+		scoopPackageManager.Name())
+}
+
+// printf prints a message about the package manager's progress, formatted as with fmt.Printf.
+func (scoopPackageManager *ScoopPackageManager) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(scoopPackageManager.messageWriterFunc(), format, args...)
+}
@@ -17,7 +17,7 @@ var _ = Describe("ScoopPackageManager", func() {
 		operatingSystemServiceDouble = test.NewOperatingSystemServiceDouble()
 		shellCommandServiceDouble = test.NewShellCommandServiceDouble()
 		scoopPackageManager = NewScoopPackageManager(operatingSystemServiceDouble.OperatingSystemService,
-			shellCommandServiceDouble.ShellCommandService)
+			shellCommandServiceDouble.ShellCommandService, test.NewMessageWriterFuncDouble())
 	})
 
 	Describe("Name", func() {
@@ -30,6 +30,18 @@ var _ = Describe("ScoopPackageManager", func() {
 	Describe("IsSupported", func() {
 	})
 
+	Describe("Capabilities", func() {
+		It("should report that specific versions can't be installed, but that downgrading, searching, getting "+
+			"information, and holding are supported", func() {
+			capabilities := scoopPackageManager.Capabilities()
+			Expect(capabilities.VersionedInstall).To(BeFalse())
+			Expect(capabilities.Downgrade).To(BeTrue())
+			Expect(capabilities.Search).To(BeTrue())
+			Expect(capabilities.Info).To(BeTrue())
+			Expect(capabilities.Hold).To(BeTrue())
+		})
+	})
+
 	Describe("IsInstalled", func() {
 	})
 
@@ -145,12 +157,208 @@ Everything is ok!
 		})
 	})
 
+	Describe("SearchPackages", func() {
+		It("should use the output of 'scoop search' to get the list of matching packages", func() {
+			scoopSearchOutput := `Results from local buckets...
+
+Name         Version  Source Binaries
+----         -------  ------ --------
+package1     1.0.0    main
+package1-cli 2.3.4    extras package1.exe
+`
+			shellCommandServiceDouble.SetOutputForExpectedInputs(scoopSearchOutput, "scoop", false, "search",
+				"package1")
+
+			expectedResults := []*SearchResult{
+				{
+					Name:          "package1",
+					LatestVersion: &Version{VersionString: "1.0.0"},
+					Source:        "main",
+				},
+				{
+					Name:          "package1-cli",
+					LatestVersion: &Version{VersionString: "2.3.4"},
+					Source:        "extras",
+				},
+			}
+
+			results, err := scoopPackageManager.SearchPackages("package1")
+			Expect(err).To(BeNil())
+			Expect(results).To(Equal(expectedResults))
+		})
+
+		It("should return no results when no packages match", func() {
+			shellCommandServiceDouble.SetOutputForExpectedInputs("WARN  No matches found.\n", "scoop", false,
+				"search", "package4")
+
+			results, err := scoopPackageManager.SearchPackages("package4")
+			Expect(err).To(BeNil())
+			Expect(results).To(BeEmpty())
+		})
+	})
+
+	Describe("PackageInfo", func() {
+		It("should combine the output of 'scoop info', 'scoop depends', and 'scoop prefix' for an installed package",
+			func() {
+				scoopInfoOutput := `
+Name        : package1
+Description : The first package.
+Version     : 1.2.0
+Bucket      : main
+Website     : https://example.com/package1
+License     : MIT
+Installed   : 1.0.0
+              1.2.0
+Binaries    : package1.exe
+`
+				scoopDependsOutput := `
+Source Name
+------ ----
+main   package2
+main   package1
+`
+				shellCommandServiceDouble.SetOutputForExpectedInputs(scoopInfoOutput, "scoop", false, "info",
+					"package1")
+				shellCommandServiceDouble.SetOutputForExpectedInputs(scoopDependsOutput, "scoop", false, "depends",
+					"package1")
+				shellCommandServiceDouble.SetOutputForExpectedInputs("C:\\scoop\\apps\\package1\\current\n", "scoop",
+					false, "prefix", "package1")
+
+				expectedInfo := &PackageInfo{
+					Name:        "package1",
+					Description: "The first package.",
+					Homepage:    "https://example.com/package1",
+					License:     "MIT",
+					AvailableVersions: []*Version{
+						{VersionString: "1.2.0"},
+						{VersionString: "1.0.0"},
+					},
+					Dependencies:    []string{"package2"},
+					InstallLocation: "C:\\scoop\\apps\\package1\\current",
+				}
+
+				packageInfo, err := scoopPackageManager.PackageInfo("package1")
+				Expect(err).To(BeNil())
+				Expect(packageInfo).To(Equal(expectedInfo))
+			})
+
+		It("should leave the install location empty when the package is not installed", func() {
+			scoopInfoOutput := `
+Name        : package2
+Description : The second package.
+Version     : 2.0.0
+Bucket      : main
+Website     : https://example.com/package2
+License     : Apache-2.0
+Installed   : No
+`
+			scoopDependsOutput := `
+Source Name
+------ ----
+main   package2
+`
+			shellCommandServiceDouble.SetOutputForExpectedInputs(scoopInfoOutput, "scoop", false, "info", "package2")
+			shellCommandServiceDouble.SetOutputForExpectedInputs(scoopDependsOutput, "scoop", false, "depends",
+				"package2")
+
+			expectedInfo := &PackageInfo{
+				Name:              "package2",
+				Description:       "The second package.",
+				Homepage:          "https://example.com/package2",
+				License:           "Apache-2.0",
+				AvailableVersions: []*Version{{VersionString: "2.0.0"}},
+				Dependencies:      []string{},
+				InstallLocation:   "",
+			}
+
+			packageInfo, err := scoopPackageManager.PackageInfo("package2")
+			Expect(err).To(BeNil())
+			Expect(packageInfo).To(Equal(expectedInfo))
+		})
+
+		It("should return an error when the package could not be found", func() {
+			shellCommandServiceDouble.SetOutputForExpectedInputs("Could not find manifest for 'package4'.\n", "scoop",
+				false, "info", "package4")
+
+			packageInfo, err := scoopPackageManager.PackageInfo("package4")
+			Expect(err).ToNot(BeNil())
+			Expect(packageInfo).To(BeNil())
+		})
+	})
+
 	Describe("InstallPackage", func() {
+		It("should return an error instead of ignoring the version when a specific version is requested", func() {
+			version, err := scoopPackageManager.InstallPackage("package1", NewVersion("1.0.0"))
+			Expect(err).ToNot(BeNil())
+			Expect(version).To(BeNil())
+		})
 	})
 
 	Describe("UpdatePackage", func() {
+		It("should return an error instead of ignoring the version when a specific version is requested", func() {
+			version, err := scoopPackageManager.UpdatePackage("package1", NewVersion("1.0.0"))
+			Expect(err).ToNot(BeNil())
+			Expect(version).To(BeNil())
+		})
+	})
+
+	Describe("DowngradePackage", func() {
+		It("should use 'scoop reset' to switch to the given version and return the version that was reset to",
+			func() {
+				shellCommandServiceDouble.SetOutputForExpectedInputs("Resetting package1 (1.0.0).\nLinking ...\n",
+					"scoop", true, "reset", "package1@1.0.0")
+
+				version, err := scoopPackageManager.DowngradePackage("package1", NewVersion("1.0.0"))
+				Expect(err).To(BeNil())
+				Expect(version).To(Equal(&Version{VersionString: "1.0.0"}))
+			})
+
+		It("should return an error when the given version is not installed locally", func() {
+			shellCommandServiceDouble.SetOutputForExpectedInputs("'package1' (0.9.0) isn't installed.\n", "scoop",
+				true, "reset", "package1@0.9.0")
+
+			version, err := scoopPackageManager.DowngradePackage("package1", NewVersion("0.9.0"))
+			Expect(err).ToNot(BeNil())
+			Expect(version).To(BeNil())
+		})
 	})
 
 	Describe("UninstallPackage", func() {
 	})
+
+	Describe("HoldPackage", func() {
+		It("should return no error when 'scoop hold' reports that the package is held", func() {
+			shellCommandServiceDouble.SetOutputForExpectedInputs(
+				"package1 is now held and can not be updated anymore.\n", "scoop", true, "hold", "package1")
+
+			err := scoopPackageManager.HoldPackage("package1")
+			Expect(err).To(BeNil())
+		})
+
+		It("should return an error when 'scoop hold' does not report that the package is held", func() {
+			shellCommandServiceDouble.SetOutputForExpectedInputs("'package1' isn't installed.\n", "scoop", true,
+				"hold", "package1")
+
+			err := scoopPackageManager.HoldPackage("package1")
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Describe("UnholdPackage", func() {
+		It("should return no error when 'scoop unhold' reports that the package is no longer held", func() {
+			shellCommandServiceDouble.SetOutputForExpectedInputs(
+				"package1 is no longer held and can be updated again.\n", "scoop", true, "unhold", "package1")
+
+			err := scoopPackageManager.UnholdPackage("package1")
+			Expect(err).To(BeNil())
+		})
+
+		It("should return an error when 'scoop unhold' does not report that the package is no longer held", func() {
+			shellCommandServiceDouble.SetOutputForExpectedInputs("'package1' isn't installed.\n", "scoop", true,
+				"unhold", "package1")
+
+			err := scoopPackageManager.UnholdPackage("package1")
+			Expect(err).ToNot(BeNil())
+		})
+	})
 })
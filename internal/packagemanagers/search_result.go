@@ -0,0 +1,17 @@
+package packagemanagers
+
+// SearchResult represents a package that was found by searching a package manager.
+type SearchResult struct {
+	Name          string
+	LatestVersion *Version
+	Source        string
+}
+
+// NewSearchResult creates a new instance of SearchResult.
+func NewSearchResult(name string, latestVersion *Version, source string) *SearchResult {
+	return &SearchResult{
+		Name:          name,
+		LatestVersion: latestVersion,
+		Source:        source,
+	}
+}
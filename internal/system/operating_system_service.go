@@ -2,6 +2,17 @@ package system
 
 import "runtime"
 
+// The names of the operating systems supported by Familiar.sh, as used in the shared config file.
+const (
+	WindowsOperatingSystemName = "windows"
+	MacOsOperatingSystemName   = "macos"
+	LinuxOperatingSystemName   = "linux"
+)
+
+// OperatingSystemNames contains the names of all operating systems supported by Familiar.sh, which are the names that
+// OperatingSystemService.Name can return.
+var OperatingSystemNames = []string{WindowsOperatingSystemName, MacOsOperatingSystemName, LinuxOperatingSystemName}
+
 // OperatingSystemService provides information about the operating system.
 type OperatingSystemService struct {
 	isWindowsFunc IsWindowsFunc
@@ -27,6 +38,18 @@ func (operatingSystemService *OperatingSystemService) IsWindows() bool {
 	return operatingSystemService.isWindowsFunc()
 }
 
+// Name returns the name of the current operating system, as used in the shared config file. It is one of
+// OperatingSystemNames.
+func (operatingSystemService *OperatingSystemService) Name() string {
+	if operatingSystemService.IsWindows() {
+		return WindowsOperatingSystemName
+	}
+	if runtime.GOOS == "darwin" {
+		return MacOsOperatingSystemName
+	}
+	return LinuxOperatingSystemName
+}
+
 // defaultIsWindowsFunc returns the default implementation of IsWindowsFunc.
 func defaultIsWindowsFunc() bool {
 	return runtime.GOOS == "windows"
@@ -6,22 +6,38 @@ import (
 	"io"
 	"os/exec"
 	"regexp"
+	"strings"
 )
 
 // ShellCommandService provides functionality for running shell commands.
 type ShellCommandService struct {
 	runShellCommandFunc RunShellCommandFunc
+	isVerboseFunc       IsVerboseFunc
+	messageWriterFunc   MessageWriterFunc
 }
 
 // NewShellCommandService returns a new instance of ShellCommandService.
-func NewShellCommandService(runShellCommandFunc RunShellCommandFunc) *ShellCommandService {
+func NewShellCommandService(runShellCommandFunc RunShellCommandFunc, isVerboseFunc IsVerboseFunc,
+	messageWriterFunc MessageWriterFunc) *ShellCommandService {
 	return &ShellCommandService{
 		runShellCommandFunc: runShellCommandFunc,
+		isVerboseFunc:       isVerboseFunc,
+		messageWriterFunc:   messageWriterFunc,
 	}
 }
 
-// RunShellCommandFunc is a function for running a shell command.
-type RunShellCommandFunc func(program string, printOutput bool, resultCaptureRegex *regexp.Regexp,
+// IsVerboseFunc is a function for determining whether shell commands should be printed as they are run, along with
+// their output.
+type IsVerboseFunc func() bool
+
+// MessageWriterFunc is a function for getting the writer that messages about a command's progress are printed to, such
+// as warnings and the output of shell commands. It is called each time a message is printed, since where messages go
+// depends on the output format, which is only known once the command line has been parsed.
+type MessageWriterFunc func() io.Writer
+
+// RunShellCommandFunc is a function for running a shell command. If the given output writer is nil, the command's
+// output isn't printed.
+type RunShellCommandFunc func(program string, outputWriter io.Writer, resultCaptureRegex *regexp.Regexp,
 	args ...string) (string, error)
 
 // NewRunShellCommandFunc returns a new instance of RunShellCommandFunc.
@@ -30,7 +46,8 @@ func NewRunShellCommandFunc() RunShellCommandFunc {
 }
 
 // RunShellCommand runs a shell command for the given program and the given arguments. The command's output is printed
-// to stdout.
+// as a message, if requested. In verbose mode, the command itself is printed first, with any passwords in URLs
+// redacted, and its output is always printed.
 //
 // It takes the following parameters:
 //   - program: The name of the program to run.
@@ -43,11 +60,21 @@ func NewRunShellCommandFunc() RunShellCommandFunc {
 // the result is an empty string.
 func (shellCommandService *ShellCommandService) RunShellCommand(program string, printOutput bool,
 	resultCaptureRegex *regexp.Regexp, args ...string) (string, error) {
-	return shellCommandService.runShellCommandFunc(program, printOutput, resultCaptureRegex, args...)
+	if shellCommandService.isVerboseFunc() {
+		_, _ = fmt.Fprintf(shellCommandService.messageWriterFunc(), "Running \"%s\"\n",
+			strings.Join(append([]string{program}, redactArgs(args)...), " "))
+		printOutput = true
+	}
+
+	var outputWriter io.Writer
+	if printOutput {
+		outputWriter = shellCommandService.messageWriterFunc()
+	}
+	return shellCommandService.runShellCommandFunc(program, outputWriter, resultCaptureRegex, args...)
 }
 
 // defaultRunShellCommandFunc is the default implementation of RunShellCommandFunc.
-func defaultRunShellCommandFunc(program string, printOutput bool,
+func defaultRunShellCommandFunc(program string, outputWriter io.Writer,
 	resultCaptureRegex *regexp.Regexp, args ...string) (string, error) {
 	command := exec.Command(program, args...)
 
@@ -67,8 +94,8 @@ func defaultRunShellCommandFunc(program string, printOutput bool,
 
 	errs := make(chan error)
 	results := make(chan string)
-	go readLines(stdout, printOutput, resultCaptureRegex, results, errs)
-	go readLines(stderr, printOutput, nil, results, errs)
+	go readLines(stdout, outputWriter, resultCaptureRegex, results, errs)
+	go readLines(stderr, outputWriter, nil, results, errs)
 
 	select {
 	case err := <-errs:
@@ -87,24 +114,24 @@ func defaultRunShellCommandFunc(program string, printOutput bool,
 	}
 
 	if command.ProcessState.ExitCode() != 0 {
-		return "", fmt.Errorf("error running command \"%s %s\", with exit code %d", program, args,
+		return "", fmt.Errorf("error running command \"%s %s\", with exit code %d", program, redactArgs(args),
 			command.ProcessState.ExitCode())
 	}
 
 	return result, nil
 }
 
-// readLines reads all lines of text from the given Reader and prints them to stdout. If the given regular expression
-// finds a match, its submatch is written to the given results channel. If any error is encountered, it is written to
-// the given error channel.
+// readLines reads all lines of text from the given Reader and prints them to the given Writer. If the given regular
+// expression finds a match, its submatch is written to the given results channel. If any error is encountered, it is
+// written to the given error channel.
 //
 // It takes the following parameters:
 //   - reader: The Reader to read from.
-//   - printOutput: Whether to print the output.
+//   - outputWriter: The Writer to print the output to. If this is nil, the output isn't printed.
 //   - resultCaptureRegex: A regular expression that captures any results. If this is nil, no results are captured.
 //   - results: The channel to write any results to.
 //   - errs: The channel to write any errors to.
-func readLines(reader io.Reader, printOutput bool, resultCaptureRegex *regexp.Regexp, results chan<- string,
+func readLines(reader io.Reader, outputWriter io.Writer, resultCaptureRegex *regexp.Regexp, results chan<- string,
 	errs chan<- error) {
 	scanner := bufio.NewScanner(reader)
 
@@ -112,8 +139,8 @@ func readLines(reader io.Reader, printOutput bool, resultCaptureRegex *regexp.Re
 	for scanner.Scan() {
 		line := scanner.Text()
 		cumulativeOutput += line + "\n"
-		if printOutput {
-			fmt.Println(line)
+		if outputWriter != nil {
+			_, _ = fmt.Fprintln(outputWriter, line)
 		}
 	}
 
@@ -0,0 +1,29 @@
+package system
+
+import "net/url"
+
+// RedactUrl returns the given URL with its password, if it has one, replaced by "xxxxx", so that it can be shown to the
+// user. Anything that isn't a URL with a password, such as a file path or an scp-like git location, is returned as it
+// is.
+//
+// It takes the following parameters:
+//   - rawUrl: The URL to redact.
+func RedactUrl(rawUrl string) string {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil || parsedUrl.User == nil {
+		return rawUrl
+	}
+	if _, hasPassword := parsedUrl.User.Password(); !hasPassword {
+		return rawUrl
+	}
+	return parsedUrl.Redacted()
+}
+
+// redactArgs returns the given command line arguments with any passwords in URLs redacted using RedactUrl.
+func redactArgs(args []string) []string {
+	redactedArgs := make([]string, len(args))
+	for i, arg := range args {
+		redactedArgs[i] = RedactUrl(arg)
+	}
+	return redactedArgs
+}
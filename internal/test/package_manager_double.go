@@ -0,0 +1,193 @@
+package test
+
+import (
+	"fmt"
+	"github.com/colececil/familiar.sh/internal/packagemanagers"
+	"sort"
+	"strings"
+)
+
+// PackageManagerDouble is a test double for packagemanagers.PackageManager. It keeps track of installed packages in
+// memory, so that tests can check what a command installed, uninstalled, or held.
+type PackageManagerDouble struct {
+	// InstalledVersions contains the installed version of each installed package, keyed by package name.
+	InstalledVersions map[string]string
+
+	// LatestVersions contains the latest available version of packages, keyed by package name. Packages that aren't in
+	// it are assumed to be up to date.
+	LatestVersions map[string]string
+
+	// HeldPackages contains the names of the packages that are held.
+	HeldPackages map[string]bool
+
+	// PackageInfos contains the information PackageInfo returns for packages, keyed by package name.
+	PackageInfos map[string]*packagemanagers.PackageInfo
+
+	// UpdateErrors contains the errors UpdatePackage returns for packages, keyed by package name.
+	UpdateErrors map[string]error
+
+	// Updated is whether the package manager itself has been updated.
+	Updated bool
+
+	name         string
+	capabilities packagemanagers.Capabilities
+}
+
+// NewPackageManagerDouble returns a new instance of PackageManagerDouble with the given name and capabilities, and with
+// no packages installed.
+func NewPackageManagerDouble(name string, capabilities packagemanagers.Capabilities) *PackageManagerDouble {
+	return &PackageManagerDouble{
+		InstalledVersions: make(map[string]string),
+		LatestVersions:    make(map[string]string),
+		HeldPackages:      make(map[string]bool),
+		PackageInfos:      make(map[string]*packagemanagers.PackageInfo),
+		UpdateErrors:      make(map[string]error),
+		name:              name,
+		capabilities:      capabilities,
+	}
+}
+
+// Name returns the name of the package manager.
+func (packageManagerDouble *PackageManagerDouble) Name() string {
+	return packageManagerDouble.name
+}
+
+// IsSupported always returns true.
+func (packageManagerDouble *PackageManagerDouble) IsSupported() bool {
+	return true
+}
+
+// Capabilities returns the capabilities the test double was created with.
+func (packageManagerDouble *PackageManagerDouble) Capabilities() packagemanagers.Capabilities {
+	return packageManagerDouble.capabilities
+}
+
+// IsInstalled always returns true.
+func (packageManagerDouble *PackageManagerDouble) IsInstalled() (bool, error) {
+	return true, nil
+}
+
+// Install does nothing.
+func (packageManagerDouble *PackageManagerDouble) Install() error {
+	return nil
+}
+
+// Update records that the package manager has been updated.
+func (packageManagerDouble *PackageManagerDouble) Update() error {
+	packageManagerDouble.Updated = true
+	return nil
+}
+
+// Uninstall does nothing.
+func (packageManagerDouble *PackageManagerDouble) Uninstall() error {
+	return nil
+}
+
+// InstalledPackages returns the installed packages, sorted by name.
+func (packageManagerDouble *PackageManagerDouble) InstalledPackages() ([]*packagemanagers.Package, error) {
+	var installedPackages []*packagemanagers.Package
+	for name, installedVersion := range packageManagerDouble.InstalledVersions {
+		latestVersion := packageManagerDouble.latestVersion(name)
+		installedPackages = append(installedPackages, packagemanagers.NewPackage(name,
+			packagemanagers.NewVersion(installedVersion), packagemanagers.NewVersion(latestVersion)))
+	}
+	sort.Slice(installedPackages, func(i, j int) bool {
+		return installedPackages[i].Name < installedPackages[j].Name
+	})
+	return installedPackages, nil
+}
+
+// SearchPackages returns the packages in LatestVersions whose names contain the given term, with "main" as their
+// source.
+func (packageManagerDouble *PackageManagerDouble) SearchPackages(term string) ([]*packagemanagers.SearchResult, error) {
+	var searchResults []*packagemanagers.SearchResult
+	for name, latestVersion := range packageManagerDouble.LatestVersions {
+		if strings.Contains(name, term) {
+			searchResults = append(searchResults, packagemanagers.NewSearchResult(name,
+				packagemanagers.NewVersion(latestVersion), "main"))
+		}
+	}
+	return searchResults, nil
+}
+
+// PackageInfo returns the information for the given package from PackageInfos, or an error if it isn't there.
+func (packageManagerDouble *PackageManagerDouble) PackageInfo(packageName string) (*packagemanagers.PackageInfo,
+	error) {
+	packageInfo, isPresent := packageManagerDouble.PackageInfos[packageName]
+	if !isPresent {
+		return nil, fmt.Errorf("package \"%s\" not found", packageName)
+	}
+	return packageInfo, nil
+}
+
+// InstallPackage installs the given package at the given version, or at its latest version if no version is given.
+func (packageManagerDouble *PackageManagerDouble) InstallPackage(packageName string,
+	version *packagemanagers.Version) (*packagemanagers.Version, error) {
+	if _, isInstalled := packageManagerDouble.InstalledVersions[packageName]; isInstalled {
+		return nil, fmt.Errorf("package \"%s\" is already installed", packageName)
+	}
+	return packageManagerDouble.setInstalledVersion(packageName, version), nil
+}
+
+// UpdatePackage updates the given package to the given version, or to its latest version if no version is given. If
+// there is an error for the package in UpdateErrors, it is returned instead.
+func (packageManagerDouble *PackageManagerDouble) UpdatePackage(packageName string,
+	version *packagemanagers.Version) (*packagemanagers.Version, error) {
+	if err := packageManagerDouble.UpdateErrors[packageName]; err != nil {
+		return nil, err
+	}
+	if _, isInstalled := packageManagerDouble.InstalledVersions[packageName]; !isInstalled {
+		return nil, fmt.Errorf("package \"%s\" is not installed", packageName)
+	}
+	return packageManagerDouble.setInstalledVersion(packageName, version), nil
+}
+
+// DowngradePackage replaces the installed version of the given package with the given version.
+func (packageManagerDouble *PackageManagerDouble) DowngradePackage(packageName string,
+	version *packagemanagers.Version) (*packagemanagers.Version, error) {
+	return packageManagerDouble.UpdatePackage(packageName, version)
+}
+
+// UninstallPackage uninstalls the given package.
+func (packageManagerDouble *PackageManagerDouble) UninstallPackage(packageName string) error {
+	if _, isInstalled := packageManagerDouble.InstalledVersions[packageName]; !isInstalled {
+		return fmt.Errorf("package \"%s\" is not installed", packageName)
+	}
+	delete(packageManagerDouble.InstalledVersions, packageName)
+	delete(packageManagerDouble.HeldPackages, packageName)
+	return nil
+}
+
+// HoldPackage holds the given package.
+func (packageManagerDouble *PackageManagerDouble) HoldPackage(packageName string) error {
+	packageManagerDouble.HeldPackages[packageName] = true
+	return nil
+}
+
+// UnholdPackage releases the hold on the given package.
+func (packageManagerDouble *PackageManagerDouble) UnholdPackage(packageName string) error {
+	delete(packageManagerDouble.HeldPackages, packageName)
+	return nil
+}
+
+// latestVersion returns the latest available version of the given package.
+func (packageManagerDouble *PackageManagerDouble) latestVersion(packageName string) string {
+	if latestVersion, isPresent := packageManagerDouble.LatestVersions[packageName]; isPresent {
+		return latestVersion
+	}
+	if installedVersion, isInstalled := packageManagerDouble.InstalledVersions[packageName]; isInstalled {
+		return installedVersion
+	}
+	return "1.0.0"
+}
+
+// setInstalledVersion sets the installed version of the given package to the given version, or to its latest version
+// if no version is given, and returns the version.
+func (packageManagerDouble *PackageManagerDouble) setInstalledVersion(packageName string,
+	version *packagemanagers.Version) *packagemanagers.Version {
+	if version == nil {
+		version = packagemanagers.NewVersion(packageManagerDouble.latestVersion(packageName))
+	}
+	packageManagerDouble.InstalledVersions[packageName] = version.String()
+	return version
+}
@@ -3,6 +3,7 @@ package test
 import (
 	"fmt"
 	"github.com/colececil/familiar.sh/internal/system"
+	"io"
 	"regexp"
 	"strings"
 )
@@ -19,7 +20,8 @@ var expectedInputToOutput map[shellCommandFuncInputs]string
 func NewShellCommandServiceDouble() *ShellCommandServiceDouble {
 	expectedInputToOutput = make(map[shellCommandFuncInputs]string)
 	return &ShellCommandServiceDouble{
-		ShellCommandService: system.NewShellCommandService(runShellCommandFuncDouble),
+		ShellCommandService: system.NewShellCommandService(runShellCommandFuncDouble, isVerboseFuncDouble,
+			NewMessageWriterFuncDouble()),
 	}
 }
 
@@ -43,13 +45,25 @@ func (shellCommandServiceDouble *ShellCommandServiceDouble) SetOutputForExpected
 	expectedInputToOutput[inputs] = output
 }
 
+// NewMessageWriterFuncDouble returns a system.MessageWriterFunc that discards all messages.
+func NewMessageWriterFuncDouble() system.MessageWriterFunc {
+	return func() io.Writer {
+		return io.Discard
+	}
+}
+
+// isVerboseFuncDouble is the implementation for the test double's verbose mode, which is always off.
+func isVerboseFuncDouble() bool {
+	return false
+}
+
 // runShellCommandFuncDouble is the implementation for the test double's RunShellCommand function. If an output has been
 // set for the given inputs, resultCaptureRegex is run on the output and the result is returned.
-func runShellCommandFuncDouble(program string, printOutput bool, resultCaptureRegex *regexp.Regexp,
+func runShellCommandFuncDouble(program string, outputWriter io.Writer, resultCaptureRegex *regexp.Regexp,
 	args ...string) (string, error) {
 	inputs := shellCommandFuncInputs{
 		program:     program,
-		printOutput: printOutput,
+		printOutput: outputWriter != nil,
 		args:        strings.Join(args, " "),
 	}
 